@@ -7,7 +7,6 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
 )
 
 //go:generate mockgen -destination ../test_mocks/mock_imageapiclient.go -package test_mocks github.com/docker/docker/client ImageAPIClient
@@ -15,59 +14,104 @@ import (
 
 const (
 	contextTimeout = 10 * time.Second
+	// defaultStopTimeout is how long engine waits for container to stop by default.
+	defaultStopTimeout = 10 * time.Second
 )
 
 func getContext() (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), contextTimeout)
 }
 
-func cliImageList(cli client.ImageAPIClient) ([]types.ImageSummary, error) {
+func cliImageList(cli ImageRuntime) ([]types.ImageSummary, error) {
 	ctx, cancel := getContext()
 	defer cancel()
-	return cli.ImageList(ctx, types.ImageListOptions{})
+	list, err := cli.ImageList(ctx, types.ImageListOptions{})
+	return list, wrapError(err)
 }
 
-func cliContainerList(cli client.ContainerAPIClient) ([]types.Container, error) {
+func cliImageRemove(cli ImageRuntime, ref string) error {
 	ctx, cancel := getContext()
 	defer cancel()
-	return cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	_, err := cli.ImageRemove(ctx, ref, types.ImageRemoveOptions{})
+	return wrapError(err)
+}
+
+func cliContainerList(cli ContainerRuntime) ([]types.Container, error) {
+	ctx, cancel := getContext()
+	defer cancel()
+	list, err := cli.ContainerList(ctx, container.ListOptions{All: true})
+	return list, wrapError(err)
 }
 
 func cliContainerCreate(
-	cli client.ContainerAPIClient,
+	cli ContainerRuntime,
 	config *container.Config, hostConfig *container.HostConfig, name string,
 ) (container.CreateResponse, error) {
 	ctx, cancel := getContext()
 	defer cancel()
-	return cli.ContainerCreate(ctx, config, hostConfig, nil, nil, name)
+	body, err := cli.ContainerCreate(ctx, config, hostConfig, nil, nil, name)
+	return body, wrapError(err)
+}
+
+func cliContainerStart(cli ContainerRuntime, name string) error {
+	ctx, cancel := getContext()
+	defer cancel()
+	return wrapError(cli.ContainerStart(ctx, name, container.StartOptions{}))
+}
+
+// cliContainerStop waits while engine stops container, so context timeout is extended with stop timeout.
+func cliContainerStop(cli ContainerRuntime, name string, options container.StopOptions) error {
+	stopTimeout := defaultStopTimeout
+	if options.Timeout != nil {
+		stopTimeout = time.Duration(*options.Timeout) * time.Second
+	}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if stopTimeout >= 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), contextTimeout+stopTimeout)
+	} else {
+		// Negative timeout means that engine waits until container stops.
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+	return wrapError(cli.ContainerStop(ctx, name, options))
+}
+
+func cliContainerRename(cli ContainerRuntime, name string, newName string) error {
+	ctx, cancel := getContext()
+	defer cancel()
+	return wrapError(cli.ContainerRename(ctx, name, newName))
 }
 
-func cliContainerStart(cli client.ContainerAPIClient, name string) error {
+func cliContainerRemove(cli ContainerRuntime, name string) error {
 	ctx, cancel := getContext()
 	defer cancel()
-	return cli.ContainerStart(ctx, name, types.ContainerStartOptions{})
+	return wrapError(cli.ContainerRemove(ctx, name, container.RemoveOptions{Force: true}))
 }
 
-func cliContainerStop(cli client.ContainerAPIClient, name string) error {
+func cliContainerInspect(cli ContainerRuntime, name string) (types.ContainerJSON, error) {
 	ctx, cancel := getContext()
 	defer cancel()
-	return cli.ContainerStop(ctx, name, container.StopOptions{})
+	info, err := cli.ContainerInspect(ctx, name)
+	return info, wrapError(err)
 }
 
-func cliContainerRename(cli client.ContainerAPIClient, name string, newName string) error {
+func cliContainerExecCreate(cli ContainerRuntime, name string, config types.ExecConfig) (string, error) {
 	ctx, cancel := getContext()
 	defer cancel()
-	return cli.ContainerRename(ctx, name, newName)
+	response, err := cli.ContainerExecCreate(ctx, name, config)
+	return response.ID, wrapError(err)
 }
 
-func cliContainerRemove(cli client.ContainerAPIClient, name string) error {
+func cliContainerExecStart(cli ContainerRuntime, execID string) error {
 	ctx, cancel := getContext()
 	defer cancel()
-	return cli.ContainerRemove(ctx, name, types.ContainerRemoveOptions{Force: true})
+	return wrapError(cli.ContainerExecStart(ctx, execID, types.ExecStartCheck{Detach: true}))
 }
 
-func cliContainerInspect(cli client.ContainerAPIClient, name string) (types.ContainerJSON, error) {
+func cliContainerExecInspect(cli ContainerRuntime, execID string) (types.ContainerExecInspect, error) {
 	ctx, cancel := getContext()
 	defer cancel()
-	return cli.ContainerInspect(ctx, name)
+	info, err := cli.ContainerExecInspect(ctx, execID)
+	return info, wrapError(err)
 }
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+// ClientOptions contains options used to create docker client.
+type ClientOptions struct {
+	Host       string // Daemon address - "unix:///var/run/docker.sock", "tcp://host:2376", "ssh://user@host"
+	Context    string // Docker context name; used if Host is not set
+	APIVersion string // Docker API version; negotiated with daemon if not set
+	CertPath   string // Directory with "ca.pem", "cert.pem", "key.pem" files; enables TLS
+	TLSVerify  bool   // If set daemon certificate is verified
+}
+
+const (
+	envDockerContext = "DOCKER_CONTEXT"
+	envDockerConfig  = "DOCKER_CONFIG"
+	defaultContext   = "default"
+)
+
+type _Endpoint struct {
+	host      string
+	certPath  string
+	tlsVerify bool
+}
+
+type _ContextMeta struct {
+	Name      string `json:"Name"`
+	Endpoints map[string]struct {
+		Host          string `json:"Host"`
+		SkipTLSVerify bool   `json:"SkipTLSVerify"`
+	} `json:"Endpoints"`
+}
+
+func getDockerConfigDir() string {
+	if dir := os.Getenv(envDockerConfig); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker")
+}
+
+// getCurrentContext takes context name from DOCKER_CONTEXT or from docker config file.
+//
+// Current context from config file is ignored if DOCKER_HOST is set (same as docker cli does).
+func getCurrentContext(configDir string) string {
+	if name := os.Getenv(envDockerContext); name != "" {
+		return name
+	}
+	if os.Getenv(client.EnvOverrideHost) != "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		return ""
+	}
+	var config struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	json.Unmarshal(data, &config)
+	return config.CurrentContext
+}
+
+// readContext reads docker context from "contexts/meta" directory.
+//
+// Context directories are named with sha256 of context name.
+func readContext(configDir string, name string) (_Endpoint, error) {
+	hash := sha256.Sum256([]byte(name))
+	dirName := hex.EncodeToString(hash[:])
+	data, err := os.ReadFile(filepath.Join(configDir, "contexts", "meta", dirName, "meta.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return _Endpoint{}, notFound("context", name)
+	}
+	if err != nil {
+		return _Endpoint{}, err
+	}
+	var meta _ContextMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return _Endpoint{}, fmt.Errorf("context '%s': %w", name, err)
+	}
+	docker, ok := meta.Endpoints["docker"]
+	if !ok || docker.Host == "" {
+		return _Endpoint{}, fmt.Errorf("context '%s' has no docker endpoint", name)
+	}
+	endpoint := _Endpoint{host: docker.Host, tlsVerify: !docker.SkipTLSVerify}
+	tlsDir := filepath.Join(configDir, "contexts", "tls", dirName, "docker")
+	if _, err := os.Stat(tlsDir); err == nil {
+		endpoint.certPath = tlsDir
+	}
+	return endpoint, nil
+}
+
+func resolveEndpoint(options *ClientOptions) (_Endpoint, error) {
+	if options.Host != "" {
+		return _Endpoint{options.Host, options.CertPath, options.TLSVerify}, nil
+	}
+	configDir := getDockerConfigDir()
+	name := options.Context
+	if name == "" {
+		name = getCurrentContext(configDir)
+	}
+	if name == "" || name == defaultContext {
+		return _Endpoint{"", options.CertPath, options.TLSVerify}, nil
+	}
+	return readContext(configDir, name)
+}
+
+// optionalFile returns path if file exists.
+func optionalFile(dir string, name string) string {
+	filePath := filepath.Join(dir, name)
+	if _, err := os.Stat(filePath); err != nil {
+		return ""
+	}
+	return filePath
+}
+
+func withTLS(certPath string, verify bool) client.Opt {
+	return func(cli *client.Client) error {
+		transport, ok := cli.HTTPClient().Transport.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("cannot apply tls config to transport: %T", cli.HTTPClient().Transport)
+		}
+		config, err := tlsconfig.Client(tlsconfig.Options{
+			CAFile:             optionalFile(certPath, "ca.pem"),
+			CertFile:           optionalFile(certPath, "cert.pem"),
+			KeyFile:            optionalFile(certPath, "key.pem"),
+			InsecureSkipVerify: !verify,
+		})
+		if err != nil {
+			return err
+		}
+		transport.TLSClientConfig = config
+		return nil
+	}
+}
+
+/*
+NewClient creates docker client.
+
+Daemon address is taken from options, then from docker context, then from environment
+(DOCKER_HOST, DOCKER_TLS_VERIFY, DOCKER_CERT_PATH, DOCKER_API_VERSION).
+Docker context is taken from options, then from DOCKER_CONTEXT, then from "currentContext" of docker config file.
+Contexts are read from "~/.docker/contexts/meta" ("DOCKER_CONFIG" overrides "~/.docker").
+API version is negotiated with daemon unless it is set in options or environment.
+
+	NewClient(&ClientOptions{}) -> &client, err
+	NewClient(&ClientOptions{Context: "remote"}) -> &client, err
+	NewClient(&ClientOptions{Host: "tcp://host:2376", CertPath: "/path/to/certs", TLSVerify: true}) -> &client, err
+	NewClient(&ClientOptions{Host: "ssh://user@host"}) -> &client, err
+*/
+func NewClient(options *ClientOptions) (*client.Client, error) {
+	endpoint, err := resolveEndpoint(options)
+	if err != nil {
+		return nil, err
+	}
+	opts := []client.Opt{client.FromEnv}
+	if strings.HasPrefix(endpoint.host, "ssh://") {
+		dialer, err := newSSHDialer(endpoint.host)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithHost(sshDummyHost), client.WithDialContext(dialer))
+	} else if endpoint.host != "" {
+		opts = append(opts, client.WithHost(endpoint.host))
+	}
+	if endpoint.certPath != "" {
+		opts = append(opts, withTLS(endpoint.certPath, endpoint.tlsVerify))
+	}
+	if options.APIVersion != "" {
+		opts = append(opts, client.WithVersion(options.APIVersion))
+	} else {
+		opts = append(opts, client.WithAPIVersionNegotiation())
+	}
+	return client.NewClientWithOpts(opts...)
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"time"
+)
+
+// sshDummyHost is passed to docker client for ssh connections; actual connection is made by ssh dialer.
+const sshDummyHost = "http://docker.example.com"
+
+// buildSSHArgs makes arguments for ssh command that connects to remote docker daemon.
+//
+//	buildSSHArgs("ssh://user@host:2222") -> ["-l", "user", "-p", "2222", "--", "host", "docker", "system", "dial-stdio"]
+func buildSSHArgs(host string) ([]string, error) {
+	address, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("bad ssh host '%s': %w", host, err)
+	}
+	if address.Scheme != "ssh" || address.Hostname() == "" {
+		return nil, fmt.Errorf("bad ssh host '%s'", host)
+	}
+	if address.Path != "" && address.Path != "/" {
+		return nil, fmt.Errorf("bad ssh host '%s': path is not supported", host)
+	}
+	var args []string
+	if address.User != nil {
+		args = append(args, "-l", address.User.Username())
+	}
+	if port := address.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	args = append(args, "--", address.Hostname(), "docker", "system", "dial-stdio")
+	return args, nil
+}
+
+func newSSHDialer(host string) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	args, err := buildSSHArgs(host)
+	if err != nil {
+		return nil, err
+	}
+	return func(_ context.Context, _, _ string) (net.Conn, error) {
+		return dialCommand(exec.Command("ssh", args...))
+	}, nil
+}
+
+// _CommandConn is a connection over stdin and stdout of a process.
+type _CommandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+func dialCommand(cmd *exec.Cmd) (net.Conn, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &_CommandConn{cmd, stdin, stdout}, nil
+}
+
+func (conn *_CommandConn) Read(data []byte) (int, error) {
+	return conn.stdout.Read(data)
+}
+
+func (conn *_CommandConn) Write(data []byte) (int, error) {
+	return conn.stdin.Write(data)
+}
+
+func (conn *_CommandConn) Close() error {
+	conn.stdin.Close()
+	conn.cmd.Process.Kill()
+	conn.cmd.Wait()
+	return nil
+}
+
+type _CommandAddr struct{}
+
+func (_CommandAddr) Network() string {
+	return "command"
+}
+
+func (_CommandAddr) String() string {
+	return "command"
+}
+
+func (conn *_CommandConn) LocalAddr() net.Addr {
+	return _CommandAddr{}
+}
+
+func (conn *_CommandConn) RemoteAddr() net.Addr {
+	return _CommandAddr{}
+}
+
+func (conn *_CommandConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (conn *_CommandConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (conn *_CommandConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestContext(t *testing.T, configDir string, name string, host string) string {
+	hash := sha256.Sum256([]byte(name))
+	dir := filepath.Join(configDir, "contexts", "meta", hex.EncodeToString(hash[:]))
+	assert.NoError(t, os.MkdirAll(dir, os.ModePerm))
+	meta := `{"Name":"` + name + `","Metadata":{},"Endpoints":{"docker":{"Host":"` + host + `","SkipTLSVerify":false}}}`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "meta.json"), []byte(meta), 0644))
+	return hex.EncodeToString(hash[:])
+}
+
+func newVersionServer(handler http.Handler) *httptest.Server {
+	return httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_ping" {
+			w.Header().Set("Api-Version", "1.41")
+			w.Write([]byte("OK"))
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+func TestNewClient(t *testing.T) {
+	t.Setenv(client.EnvOverrideHost, "")
+	t.Setenv(client.EnvOverrideAPIVersion, "")
+	t.Setenv(client.EnvOverrideCertPath, "")
+	t.Setenv(envDockerContext, "")
+	configDir := t.TempDir()
+	t.Setenv(envDockerConfig, configDir)
+
+	t.Run("Negotiation", func(t *testing.T) {
+		var path string
+		server := newVersionServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			w.Write([]byte("[]"))
+		}))
+		server.Start()
+		defer server.Close()
+
+		cli, err := NewClient(&ClientOptions{Host: "tcp://" + server.Listener.Addr().String()})
+		assert.NoError(t, err)
+		_, err = ListAllContainerIDs(cli)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "/v1.41/containers/json", path)
+	})
+
+	t.Run("Fixed version", func(t *testing.T) {
+		cli, err := NewClient(&ClientOptions{Host: "tcp://localhost:2375", APIVersion: "1.40"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1.40", cli.ClientVersion())
+	})
+
+	t.Run("TLS", func(t *testing.T) {
+		server := newVersionServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("[]"))
+		}))
+		server.StartTLS()
+		defer server.Close()
+		certPath := t.TempDir()
+		certData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		assert.NoError(t, os.WriteFile(filepath.Join(certPath, "ca.pem"), certData, 0644))
+
+		cli, err := NewClient(&ClientOptions{
+			Host: "tcp://" + server.Listener.Addr().String(), CertPath: certPath, TLSVerify: true,
+		})
+		assert.NoError(t, err)
+		_, err = cli.Ping(context.Background())
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		writeTestContext(t, configDir, "remote", "tcp://remote-host:2375")
+
+		cli, err := NewClient(&ClientOptions{Context: "remote"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "tcp://remote-host:2375", cli.DaemonHost())
+	})
+
+	t.Run("Current context", func(t *testing.T) {
+		writeTestContext(t, configDir, "current", "unix:///tmp/docker.sock")
+		config := `{"currentContext":"current"}`
+		assert.NoError(t, os.WriteFile(filepath.Join(configDir, "config.json"), []byte(config), 0644))
+		defer os.Remove(filepath.Join(configDir, "config.json"))
+
+		cli, err := NewClient(&ClientOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "unix:///tmp/docker.sock", cli.DaemonHost())
+
+		cli, err = NewClient(&ClientOptions{Context: "default"})
+		assert.NoError(t, err)
+		assert.Equal(t, client.DefaultDockerHost, cli.DaemonHost())
+	})
+
+	t.Run("Unknown context", func(t *testing.T) {
+		_, err := NewClient(&ClientOptions{Context: "unknown"})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.EqualError(t, err, "context 'unknown' is not found")
+	})
+
+	t.Run("SSH", func(t *testing.T) {
+		cli, err := NewClient(&ClientOptions{Host: "ssh://user@remote-host"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, sshDummyHost, cli.DaemonHost())
+	})
+}
+
+func TestBuildSSHArgs(t *testing.T) {
+	args, err := buildSSHArgs("ssh://user@remote-host:2222")
+	assert.NoError(t, err)
+	assert.Equal(t, "-l user -p 2222 -- remote-host docker system dial-stdio", strings.Join(args, " "))
+
+	args, err = buildSSHArgs("ssh://remote-host")
+	assert.NoError(t, err)
+	assert.Equal(t, "-- remote-host docker system dial-stdio", strings.Join(args, " "))
+
+	_, err = buildSSHArgs("ssh://remote-host/path")
+	assert.Error(t, err)
+}
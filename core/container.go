@@ -17,6 +17,7 @@ type Container interface {
 	Name() string
 	ImageID() string
 	State() string
+	Labels() map[string]string
 }
 
 type _Container struct {
@@ -64,6 +65,13 @@ func (container *_Container) State() string {
 	return container.object.State
 }
 
+// Labels returns container labels.
+//
+//	container.Labels() -> map[string]string{"containerator.name": "my-container"}
+func (container *_Container) Labels() map[string]string {
+	return container.object.Labels
+}
+
 func makeContainer(object *types.Container) Container {
 	return &_Container{object}
 }
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// execPollInterval defines how often state of executed command is checked.
+const execPollInterval = 100 * time.Millisecond
+
+/*
+ExecContainer runs command in running container and waits until it exits; returns exit code.
+
+Environment variables are passed as "KEY=value". Engine cannot stop executed command,
+so when timeout expires command keeps running and error is returned.
+
+	ExecContainer(cli, container, []string{"migrate", "up"}, []string{"A=1"}, time.Minute) -> 0, err
+*/
+func ExecContainer(cli ContainerRuntime, container Container, cmd []string, env []string, timeout time.Duration) (int, error) {
+	execID, err := cliContainerExecCreate(cli, container.ID(), types.ExecConfig{Cmd: cmd, Env: env})
+	if err != nil {
+		return 0, err
+	}
+	if err := cliContainerExecStart(cli, execID); err != nil {
+		return 0, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		info, err := cliContainerExecInspect(cli, execID)
+		if err != nil {
+			return 0, err
+		}
+		if !info.Running {
+			return info.ExitCode, nil
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("command %v has not exited in %s", cmd, timeout)
+		}
+		time.Sleep(execPollInterval)
+	}
+}
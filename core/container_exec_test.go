@@ -0,0 +1,33 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/test_mocks"
+	"github.com/docker/docker/api/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cli := test_mocks.NewMockContainerAPIClient(ctrl)
+	cli.EXPECT().ContainerExecCreate(
+		gomock.Any(), "0123456789ab", types.ExecConfig{Cmd: []string{"ls"}, Env: []string{"A=1"}},
+	).Return(types.IDResponse{ID: "exec-1"}, nil)
+	cli.EXPECT().ContainerExecStart(gomock.Any(), "exec-1", types.ExecStartCheck{Detach: true}).Return(nil)
+	gomock.InOrder(
+		cli.EXPECT().ContainerExecInspect(gomock.Any(), "exec-1").
+			Return(types.ContainerExecInspect{Running: true}, nil),
+		cli.EXPECT().ContainerExecInspect(gomock.Any(), "exec-1").
+			Return(types.ContainerExecInspect{ExitCode: 3}, nil),
+	)
+
+	code, err := ExecContainer(cli, testContainer("0123456789ab", ""), []string{"ls"}, []string{"A=1"}, time.Second)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, code)
+}
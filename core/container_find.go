@@ -4,15 +4,15 @@ import (
 	"strings"
 
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 )
 
 // FindContainerByID searches container by id.
 //
 // `id` is a full (64 characters) identifier.
+// Returns ErrNotFound if there is no such container.
 //
 //	FindContainerByID(cli, "<guid>") -> container
-func FindContainerByID(cli client.ContainerAPIClient, id string) (Container, error) {
+func FindContainerByID(cli ContainerRuntime, id string) (Container, error) {
 	containers, err := cliContainerList(cli)
 	if err != nil {
 		return nil, err
@@ -22,34 +22,45 @@ func FindContainerByID(cli client.ContainerAPIClient, id string) (Container, err
 			return makeContainer(&containers[i]), nil
 		}
 	}
-	return nil, nil
+	return nil, notFound("container", id)
 }
 
 // FindContainerByShortID searches container by short id.
 //
 // Uses `strings.HasPrefix` to compare container identifiers.
 // Any substring of actual identifier can be passed.
+// Returns AmbiguousIDError if several containers match.
 //
 //	FindContainerByShortID(cli, "1234") -> container
-func FindContainerByShortID(cli client.ContainerAPIClient, id string) (Container, error) {
+func FindContainerByShortID(cli ContainerRuntime, id string) (Container, error) {
 	containers, err := cliContainerList(cli)
 	if err != nil {
 		return nil, err
 	}
+	var objects []*types.Container
 	for i, container := range containers {
 		if strings.HasPrefix(container.ID, id) {
-			return makeContainer(&containers[i]), nil
+			objects = append(objects, &containers[i])
 		}
 	}
-	return nil, nil
+	if len(objects) > 1 {
+		return nil, &AmbiguousIDError{id, TransformSlice(objects, func(object *types.Container) string {
+			return object.ID
+		})}
+	}
+	if len(objects) == 0 {
+		return nil, notFound("container", id)
+	}
+	return makeContainer(objects[0]), nil
 }
 
 // FindContainerByName searches container by name.
 //
 // Adds leading "/" character to passed value.
+// Returns ErrNotFound if there is no such container.
 //
 //	FindContainerByName(cli, "my-container") -> container
-func FindContainerByName(cli client.ContainerAPIClient, name string) (Container, error) {
+func FindContainerByName(cli ContainerRuntime, name string) (Container, error) {
 	containers, err := cliContainerList(cli)
 	if err != nil {
 		return nil, err
@@ -62,7 +73,7 @@ func FindContainerByName(cli client.ContainerAPIClient, name string) (Container,
 			}
 		}
 	}
-	return nil, nil
+	return nil, notFound("container", name)
 }
 
 // FindContainersByImageID searches containers by image id.
@@ -70,7 +81,7 @@ func FindContainerByName(cli client.ContainerAPIClient, name string) (Container,
 // `imageID` is a full image identifier - 64 characters with leading "sha256:".
 //
 //	FindContainersByImageID(cli, "sha256:<guid>") -> []container
-func FindContainersByImageID(cli client.ContainerAPIClient, imageID string) ([]Container, error) {
+func FindContainersByImageID(cli ContainerRuntime, imageID string) ([]Container, error) {
 	containers, err := cliContainerList(cli)
 	if err != nil {
 		return nil, err
@@ -83,3 +94,20 @@ func FindContainersByImageID(cli client.ContainerAPIClient, imageID string) ([]C
 	}
 	return TransformSlice(objects, makeContainer), nil
 }
+
+// FindContainersByLabel searches containers that have label with the value.
+//
+//	FindContainersByLabel(cli, "containerator.name", "my-container") -> []container
+func FindContainersByLabel(cli ContainerRuntime, key string, value string) ([]Container, error) {
+	containers, err := cliContainerList(cli)
+	if err != nil {
+		return nil, err
+	}
+	var objects []*types.Container
+	for i, container := range containers {
+		if label, ok := container.Labels[key]; ok && label == value {
+			objects = append(objects, &containers[i])
+		}
+	}
+	return TransformSlice(objects, makeContainer), nil
+}
@@ -29,11 +29,13 @@ func TestFindContainer(t *testing.T) {
 			ID:      "22334455667788990011",
 			Names:   []string{"/tester-3"},
 			ImageID: "i2",
+			Labels:  map[string]string{"app": "a"},
 		},
 		{
 			ID:      "33445566778899001122",
 			Names:   []string{"/tester-4", "/tester-4a", "/tester-4b"},
 			ImageID: "i1",
+			Labels:  map[string]string{"app": "b"},
 		},
 		{
 			ID:      "44556677889900112233",
@@ -53,7 +55,8 @@ func TestFindContainer(t *testing.T) {
 
 	t.Run("ByID / not found", func(t *testing.T) {
 		cont, err := FindContainerByID(cli, "unknown")
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, "container 'unknown' is not found", err.Error())
 		assert.Nil(t, cont)
 	})
 
@@ -64,8 +67,22 @@ func TestFindContainer(t *testing.T) {
 	})
 
 	t.Run("ByShortID / not found", func(t *testing.T) {
-		cont, err := FindContainerByID(cli, "unknown")
-		assert.NoError(t, err)
+		cont, err := FindContainerByShortID(cli, "unknown")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, cont)
+	})
+
+	t.Run("ByShortID / ambiguous", func(t *testing.T) {
+		cont, err := FindContainerByShortID(cli, "")
+		var ambiguousErr *AmbiguousIDError
+		assert.ErrorAs(t, err, &ambiguousErr)
+		assert.Equal(t, []string{
+			"00112233445566778899",
+			"11223344556677889900",
+			"22334455667788990011",
+			"33445566778899001122",
+			"44556677889900112233",
+		}, ambiguousErr.Candidates())
 		assert.Nil(t, cont)
 	})
 
@@ -77,7 +94,7 @@ func TestFindContainer(t *testing.T) {
 
 	t.Run("ByName / not found", func(t *testing.T) {
 		cont, err := FindContainerByName(cli, "unknown")
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, ErrNotFound)
 		assert.Nil(t, cont)
 	})
 
@@ -97,4 +114,16 @@ func TestFindContainer(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, []Container(nil), conts)
 	})
+
+	t.Run("ByLabel", func(t *testing.T) {
+		conts, err := FindContainersByLabel(cli, "app", "b")
+		assert.NoError(t, err)
+		assert.Equal(t, []Container{makeContainer(&testContainers[3])}, conts)
+	})
+
+	t.Run("ByLabel / not found", func(t *testing.T) {
+		conts, err := FindContainersByLabel(cli, "app", "c")
+		assert.NoError(t, err)
+		assert.Equal(t, []Container(nil), conts)
+	})
 }
@@ -0,0 +1,16 @@
+package core
+
+import (
+	"github.com/docker/docker/api/types"
+)
+
+// InspectContainer returns low-level information about container.
+//
+//	InspectContainer(cli, container) -> &types.ContainerJSON{...}
+func InspectContainer(cli ContainerRuntime, container Container) (*types.ContainerJSON, error) {
+	info, err := cliContainerInspect(cli, container.ID())
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DmitryBogomolov/containerator/test_mocks"
+	"github.com/docker/docker/api/types"
+	"github.com/golang/mock/gomock"
+)
+
+func TestInspectContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cli := test_mocks.NewMockContainerAPIClient(ctrl)
+	info := types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{ID: "0123456789ab", Image: "sha256:1"}}
+	cli.EXPECT().ContainerInspect(gomock.Any(), "0123456789ab").Return(info, nil)
+
+	result, err := InspectContainer(cli, testContainer("0123456789ab", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, &info, result)
+}
@@ -1,14 +1,16 @@
 package core
 
 import (
+	"strconv"
+
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
 )
 
 // ListAllContainerIDs returns all container ids.
 //
 //	ListAllContainerIDs(cli) -> []string
-func ListAllContainerIDs(cli client.ContainerAPIClient) ([]string, error) {
+func ListAllContainerIDs(cli ContainerRuntime) ([]string, error) {
 	containers, err := cliContainerList(cli)
 	if err != nil {
 		return nil, err
@@ -17,3 +19,25 @@ func ListAllContainerIDs(cli client.ContainerAPIClient) ([]string, error) {
 		return container.ID
 	}), nil
 }
+
+// ListHostPorts returns host ports with protocols bound by containers.
+//
+// Container ports that are not published on host are skipped.
+//
+//	ListHostPorts(cli) -> map[nat.Port]Container{"5001/tcp": container, "53/udp": container}
+func ListHostPorts(cli ContainerRuntime) (map[nat.Port]Container, error) {
+	containers, err := cliContainerList(cli)
+	if err != nil {
+		return nil, err
+	}
+	result := map[nat.Port]Container{}
+	for i, container := range containers {
+		for _, port := range container.Ports {
+			if port.PublicPort != 0 {
+				hostPort, _ := nat.NewPort(port.Type, strconv.Itoa(int(port.PublicPort)))
+				result[hostPort] = makeContainer(&containers[i])
+			}
+		}
+	}
+	return result, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/DmitryBogomolov/containerator/test_mocks"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -45,3 +46,39 @@ func TestListAllContainerIDs(t *testing.T) {
 		"44556677889900112233",
 	}, containerIDs)
 }
+
+func TestListHostPorts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testContainers := []types.Container{
+		{
+			ID: "00112233445566778899",
+			Ports: []types.Port{
+				{IP: "0.0.0.0", PrivatePort: 80, PublicPort: 5001, Type: "tcp"},
+				{IP: "::", PrivatePort: 80, PublicPort: 5001, Type: "tcp"},
+				{PrivatePort: 81, Type: "tcp"},
+			},
+		},
+		{
+			ID: "11223344556677889900",
+			Ports: []types.Port{
+				{IP: "0.0.0.0", PrivatePort: 53, PublicPort: 5053, Type: "udp"},
+			},
+		},
+		{
+			ID: "22334455667788990011",
+		},
+	}
+
+	cli := test_mocks.NewMockContainerAPIClient(ctrl)
+	cli.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return(testContainers, nil)
+
+	ports, err := ListHostPorts(cli)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[nat.Port]Container{
+		"5001/tcp": makeContainer(&testContainers[0]),
+		"5053/udp": makeContainer(&testContainers[1]),
+	}, ports)
+}
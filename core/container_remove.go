@@ -1,10 +1,17 @@
 package core
 
-import (
-	"github.com/docker/docker/client"
-)
-
 // RemoveContainer removes container.
-func RemoveContainer(cli client.ContainerAPIClient, container Container) error {
+//
+// Running container is stopped first (see StopContainer), so it can shut down gracefully.
+func RemoveContainer(cli ContainerRuntime, container Container) error {
+	options, running, err := getStopOptions(cli, container.ID())
+	if err != nil {
+		return err
+	}
+	if running {
+		if err := cliContainerStop(cli, container.ID(), options); err != nil {
+			return err
+		}
+	}
 	return cliContainerRemove(cli, container.ID())
 }
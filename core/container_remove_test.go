@@ -6,7 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/DmitryBogomolov/containerator/test_mocks"
-	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/golang/mock/gomock"
 )
 
@@ -15,7 +15,24 @@ func TestRemoveContainer(t *testing.T) {
 	defer ctrl.Finish()
 
 	cli := test_mocks.NewMockContainerAPIClient(ctrl)
-	cli.EXPECT().ContainerRemove(gomock.Any(), "0123456789ab", types.ContainerRemoveOptions{Force: true}).Return(nil)
+	cli.EXPECT().ContainerInspect(gomock.Any(), "0123456789ab").Return(testStopInfo(false, "", nil), nil)
+	cli.EXPECT().ContainerRemove(gomock.Any(), "0123456789ab", container.RemoveOptions{Force: true}).Return(nil)
+
+	err := RemoveContainer(cli, testContainer("0123456789ab", ""))
+	assert.NoError(t, err)
+}
+
+func TestRemoveContainer_Running(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cli := test_mocks.NewMockContainerAPIClient(ctrl)
+	timeout := 60
+	gomock.InOrder(
+		cli.EXPECT().ContainerInspect(gomock.Any(), "0123456789ab").Return(testStopInfo(true, "SIGINT", &timeout), nil),
+		cli.EXPECT().ContainerStop(gomock.Any(), "0123456789ab", container.StopOptions{Signal: "SIGINT", Timeout: &timeout}).Return(nil),
+		cli.EXPECT().ContainerRemove(gomock.Any(), "0123456789ab", container.RemoveOptions{Force: true}).Return(nil),
+	)
 
 	err := RemoveContainer(cli, testContainer("0123456789ab", ""))
 	assert.NoError(t, err)
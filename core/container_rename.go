@@ -0,0 +1,6 @@
+package core
+
+// RenameContainer renames container.
+func RenameContainer(cli ContainerRuntime, container Container, name string) error {
+	return cliContainerRename(cli, container.ID(), name)
+}
@@ -0,0 +1,21 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DmitryBogomolov/containerator/test_mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRenameContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cli := test_mocks.NewMockContainerAPIClient(ctrl)
+	cli.EXPECT().ContainerRename(gomock.Any(), "0123456789ab", "test-name").Return(nil)
+
+	err := RenameContainer(cli, testContainer("0123456789ab", ""), "test-name")
+	assert.NoError(t, err)
+}
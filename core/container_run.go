@@ -3,10 +3,10 @@ package core
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
-	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
 )
 
@@ -19,18 +19,44 @@ type RunContainerOptions struct {
 	Env           []Mapping                   `json:"env,omitempty" yaml:",omitempty"`            // List of environment variables; has priority over `EnvReader`
 	RestartPolicy container.RestartPolicyMode `json:"restart,omitempty" yaml:"restart,omitempty"` // Container restart policy
 	Network       string                      `json:"network,omitempty" yaml:",omitempty"`        // Container network
+	Labels        map[string]string           `json:"labels,omitempty" yaml:",omitempty"`         // Container labels
+	// Maximum number of restarts for "on-failure" restart policy; not limited if not set
+	RestartMaxRetries int `json:"restart_max_retries,omitempty" yaml:"restart_max_retries,omitempty"`
+	// Signal that stops container, e.g. "SIGINT"; image or engine default if not set
+	StopSignal string `json:"stop_signal,omitempty" yaml:"stop_signal,omitempty"`
+	// Seconds to wait for container to stop before it is killed; engine default (10) if not set
+	StopTimeout *int `json:"stop_timeout,omitempty" yaml:"stop_timeout,omitempty"`
+	// If set empty env values are passed as is; otherwise they are taken from host environment
+	KeepEmptyEnv bool `json:"-" yaml:"-"`
 }
 
-func buildPortBindings(mappings []Mapping) (nat.PortSet, nat.PortMap) {
+func buildPortBindings(mappings []Mapping) (nat.PortSet, nat.PortMap, error) {
 	if len(mappings) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 	ports := make([]string, len(mappings))
 	for i, mapping := range mappings {
-		ports[i] = fmt.Sprintf("0.0.0.0:%s:%s/tcp", mapping.Source, mapping.Target)
+		source := mapping.Source
+		if ip, _ := SplitHostIP(source); ip == "" {
+			source = "0.0.0.0:" + source
+		}
+		target := mapping.Target
+		if !strings.Contains(target, "/") {
+			target += "/tcp"
+		}
+		ports[i] = fmt.Sprintf("%s:%s", source, target)
+	}
+	return nat.ParsePortSpecs(ports)
+}
+
+// SplitVolumeTarget separates access mode (":ro" or ":rw") from volume target.
+//
+//	SplitVolumeTarget("/usr/app:ro") -> "/usr/app", true
+func SplitVolumeTarget(target string) (string, bool) {
+	if strings.HasSuffix(target, ":ro") {
+		return strings.TrimSuffix(target, ":ro"), true
 	}
-	exposedPorts, portBindings, _ := nat.ParsePortSpecs(ports)
-	return exposedPorts, portBindings
+	return strings.TrimSuffix(target, ":rw"), false
 }
 
 func buildMounts(mappings []Mapping) []mount.Mount {
@@ -39,16 +65,18 @@ func buildMounts(mappings []Mapping) []mount.Mount {
 	}
 	result := make([]mount.Mount, 0, len(mappings))
 	for _, mapping := range mappings {
+		target, readOnly := SplitVolumeTarget(mapping.Target)
 		result = append(result, mount.Mount{
-			Type:   mount.TypeBind,
-			Source: mapping.Source,
-			Target: mapping.Target,
+			Type:     mount.TypeBind,
+			Source:   mapping.Source,
+			Target:   target,
+			ReadOnly: readOnly,
 		})
 	}
 	return result
 }
 
-func buildEnvironment(mappings []Mapping) []string {
+func buildEnvironment(mappings []Mapping, keepEmpty bool) []string {
 	if len(mappings) == 0 {
 		return nil
 	}
@@ -56,7 +84,7 @@ func buildEnvironment(mappings []Mapping) []string {
 	for _, mapping := range mappings {
 		name := mapping.Source
 		value := mapping.Target
-		if value == "" {
+		if value == "" && !keepEmpty {
 			value = os.Getenv(name)
 		}
 		result = append(result, fmt.Sprintf("%s=%s", name, value))
@@ -64,6 +92,36 @@ func buildEnvironment(mappings []Mapping) []string {
 	return result
 }
 
+// BuildContainerConfig makes container configuration that RunContainer passes to engine.
+//
+// Returns error if port mapping cannot be parsed.
+//
+//	BuildContainerConfig(&RunContainerOptions{Image: "my-image:1", Network: "my-network-1"}) -> &config, &hostConfig, nil
+func BuildContainerConfig(options *RunContainerOptions) (*container.Config, *container.HostConfig, error) {
+	config := container.Config{}
+	hostConfig := container.HostConfig{}
+
+	var err error
+	config.Image = options.Image
+	config.ExposedPorts, hostConfig.PortBindings, err = buildPortBindings(options.Ports)
+	if err != nil {
+		return nil, nil, err
+	}
+	config.Env = buildEnvironment(options.Env, options.KeepEmptyEnv)
+	config.Labels = options.Labels
+	hostConfig.Mounts = buildMounts(options.Volumes)
+	if options.RestartPolicy != "" {
+		hostConfig.RestartPolicy.Name = options.RestartPolicy
+		hostConfig.RestartPolicy.MaximumRetryCount = options.RestartMaxRetries
+	}
+	config.StopSignal = options.StopSignal
+	config.StopTimeout = options.StopTimeout
+	if options.Network != "" {
+		hostConfig.NetworkMode = container.NetworkMode(options.Network)
+	}
+	return &config, &hostConfig, nil
+}
+
 /*
 RunContainer creates and starts container.
 
@@ -86,22 +144,13 @@ If created container fails at start it is removed.
 		},
 	}) -> &container
 */
-func RunContainer(cli client.ContainerAPIClient, options *RunContainerOptions) (Container, error) {
-	config := container.Config{}
-	hostConfig := container.HostConfig{}
-
-	config.Image = options.Image
-	config.ExposedPorts, hostConfig.PortBindings = buildPortBindings(options.Ports)
-	config.Env = buildEnvironment(options.Env)
-	hostConfig.Mounts = buildMounts(options.Volumes)
-	if options.RestartPolicy != "" {
-		hostConfig.RestartPolicy.Name = options.RestartPolicy
-	}
-	if options.Network != "" {
-		hostConfig.NetworkMode = container.NetworkMode(options.Network)
+func RunContainer(cli ContainerRuntime, options *RunContainerOptions) (Container, error) {
+	config, hostConfig, err := BuildContainerConfig(options)
+	if err != nil {
+		return nil, err
 	}
 
-	body, err := cliContainerCreate(cli, &config, &hostConfig, options.Name)
+	body, err := cliContainerCreate(cli, config, hostConfig, options.Name)
 	if err != nil {
 		return nil, err
 	}
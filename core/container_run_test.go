@@ -169,6 +169,72 @@ func TestRunContainer(t *testing.T) {
 		})
 	})
 
+	t.Run("Keep empty env", func(t *testing.T) {
+		cli := test_mocks.NewMockContainerAPIClient(ctrl)
+		os.Setenv("D", "test")
+		defer os.Unsetenv("D")
+		cli.EXPECT().
+			ContainerCreate(
+				gomock.Any(),
+				&container.Config{
+					Image: "image:1",
+					Env: []string{
+						"A=1",
+						"B=",
+						"C=3",
+						"D=",
+					},
+				},
+				&container.HostConfig{},
+				nil, nil, "container-1").
+			Return(container.CreateResponse{ID: "cid1"}, nil)
+		cli.EXPECT().
+			ContainerStart(gomock.Any(), "cid1", gomock.Any()).
+			Return(nil)
+		cli.EXPECT().
+			ContainerList(gomock.Any(), gomock.Any()).
+			Return([]types.Container{
+				{},
+			}, nil)
+
+		RunContainer(cli, &RunContainerOptions{
+			Image: "image:1",
+			Name:  "container-1",
+			Env: []Mapping{
+				{"A", "1"},
+				{"B", ""},
+				{"C", "3"},
+				{"D", ""},
+			},
+			KeepEmptyEnv: true,
+		})
+	})
+
+	t.Run("Labels", func(t *testing.T) {
+		cli := test_mocks.NewMockContainerAPIClient(ctrl)
+		cli.EXPECT().
+			ContainerCreate(
+				gomock.Any(),
+				&container.Config{Image: "image:1", Labels: map[string]string{"a": "1"}},
+				&container.HostConfig{},
+				nil, nil, "container-1").
+			Return(container.CreateResponse{ID: "cid1"}, nil)
+		cli.EXPECT().
+			ContainerStart(gomock.Any(), "cid1", gomock.Any()).
+			Return(nil)
+		cli.EXPECT().
+			ContainerList(gomock.Any(), gomock.Any()).
+			Return([]types.Container{
+				{},
+			}, nil)
+
+		RunContainer(cli, &RunContainerOptions{
+			Image:  "image:1",
+			Name:   "container-1",
+			Labels: map[string]string{"a": "1"},
+		})
+	})
+
 	t.Run("RestartPolicy", func(t *testing.T) {
 		cli := test_mocks.NewMockContainerAPIClient(ctrl)
 		cli.EXPECT().
@@ -343,3 +409,61 @@ func TestRunContainerOptions(t *testing.T) {
 		}, options)
 	})
 }
+
+func TestSplitVolumeTarget(t *testing.T) {
+	for _, item := range []struct {
+		target   string
+		path     string
+		readOnly bool
+	}{
+		{"/data", "/data", false},
+		{"/data:ro", "/data", true},
+		{"/data:rw", "/data", false},
+	} {
+		path, readOnly := SplitVolumeTarget(item.target)
+
+		assert.Equal(t, item.path, path, item.target)
+		assert.Equal(t, item.readOnly, readOnly, item.target)
+	}
+}
+
+func TestBuildContainerConfig(t *testing.T) {
+	t.Run("ReadOnlyVolume", func(t *testing.T) {
+		_, hostConfig, err := BuildContainerConfig(&RunContainerOptions{
+			Image:   "image:1",
+			Volumes: []Mapping{{"/src1", "/dst1:ro"}, {"/src2", "/dst2:rw"}},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []mount.Mount{
+			{Type: mount.TypeBind, Source: "/src1", Target: "/dst1", ReadOnly: true},
+			{Type: mount.TypeBind, Source: "/src2", Target: "/dst2"},
+		}, hostConfig.Mounts)
+	})
+
+	t.Run("HostIPAndProtocol", func(t *testing.T) {
+		var dummy struct{}
+
+		config, hostConfig, err := BuildContainerConfig(&RunContainerOptions{
+			Image: "image:1",
+			Ports: []Mapping{{"127.0.0.1:1001", "1000"}, {"[::1]:2001", "2000"}, {"5353", "53/udp"}},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, nat.PortSet{"1000/tcp": dummy, "2000/tcp": dummy, "53/udp": dummy}, config.ExposedPorts)
+		assert.Equal(t, nat.PortMap{
+			"1000/tcp": []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: "1001"}},
+			"2000/tcp": []nat.PortBinding{{HostIP: "::1", HostPort: "2001"}},
+			"53/udp":   []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "5353"}},
+		}, hostConfig.PortBindings)
+	})
+
+	t.Run("BadPort", func(t *testing.T) {
+		_, _, err := BuildContainerConfig(&RunContainerOptions{
+			Image: "image:1",
+			Ports: []Mapping{{"1001", "http"}},
+		})
+
+		assert.EqualError(t, err, "Invalid containerPort: http")
+	})
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"github.com/docker/docker/api/types/container"
+)
+
+// StartContainer starts container.
+func StartContainer(cli ContainerRuntime, container Container) error {
+	return cliContainerStart(cli, container.ID())
+}
+
+// getStopOptions returns stop signal and timeout that container is created with and whether container is running.
+func getStopOptions(cli ContainerRuntime, containerID string) (container.StopOptions, bool, error) {
+	info, err := cliContainerInspect(cli, containerID)
+	if err != nil {
+		return container.StopOptions{}, false, err
+	}
+	var options container.StopOptions
+	if info.Config != nil {
+		options.Signal = info.Config.StopSignal
+		options.Timeout = info.Config.StopTimeout
+	}
+	running := info.ContainerJSONBase != nil && info.State != nil && info.State.Running
+	return options, running, nil
+}
+
+// stopContainer stops container with its stop signal and timeout.
+func stopContainer(cli ContainerRuntime, containerID string) error {
+	options, _, err := getStopOptions(cli, containerID)
+	if err != nil {
+		return err
+	}
+	return cliContainerStop(cli, containerID, options)
+}
+
+// StopContainer stops container.
+//
+// Container gets its stop signal (RunContainerOptions.StopSignal) and is killed
+// if it does not stop in its stop timeout (RunContainerOptions.StopTimeout).
+func StopContainer(cli ContainerRuntime, container Container) error {
+	return stopContainer(cli, container.ID())
+}
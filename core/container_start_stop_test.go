@@ -0,0 +1,42 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/test_mocks"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func testStopInfo(running bool, signal string, timeout *int) types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "0123456789ab", State: &types.ContainerState{Running: running}},
+		Config:            &container.Config{StopSignal: signal, StopTimeout: timeout},
+	}
+}
+
+func TestStartContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cli := test_mocks.NewMockContainerAPIClient(ctrl)
+	cli.EXPECT().ContainerStart(gomock.Any(), "0123456789ab", container.StartOptions{}).Return(nil)
+
+	err := StartContainer(cli, testContainer("0123456789ab", ""))
+	assert.NoError(t, err)
+}
+
+func TestStopContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cli := test_mocks.NewMockContainerAPIClient(ctrl)
+	timeout := 30
+	cli.EXPECT().ContainerInspect(gomock.Any(), "0123456789ab").Return(testStopInfo(true, "SIGINT", &timeout), nil)
+	cli.EXPECT().ContainerStop(gomock.Any(), "0123456789ab", container.StopOptions{Signal: "SIGINT", Timeout: &timeout}).Return(nil)
+
+	err := StopContainer(cli, testContainer("0123456789ab", ""))
+	assert.NoError(t, err)
+}
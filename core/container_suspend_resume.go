@@ -1,26 +1,30 @@
 package core
 
 import (
-	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/namesgenerator"
 )
 
-// SuspendContainer renames and stops container.
-//
-// Uses docker names generator to acquire temporary container name.
-func SuspendContainer(cli client.ContainerAPIClient, container Container) error {
+/*
+SuspendContainer renames and stops container.
+
+Uses docker names generator to acquire temporary container name. Temporary name does not keep original name,
+so callers that need to find container that is left suspended (e.g. when process is terminated before container
+is resumed or removed) should identify it by labels (see manage.Recover).
+Container is stopped with its stop signal and timeout (see StopContainer).
+*/
+func SuspendContainer(cli ContainerRuntime, container Container) error {
 	tmpName := namesgenerator.GetRandomName(2)
 	if err := cliContainerRename(cli, container.ID(), tmpName); err != nil {
 		return err
 	}
-	if err := cliContainerStop(cli, container.ID()); err != nil {
+	if err := stopContainer(cli, container.ID()); err != nil {
 		return err
 	}
 	return nil
 }
 
 // ResumeContainer renames and starts container.
-func ResumeContainer(cli client.ContainerAPIClient, container Container, name string) error {
+func ResumeContainer(cli ContainerRuntime, container Container, name string) error {
 	if err := cliContainerRename(cli, container.ID(), name); err != nil {
 		return err
 	}
@@ -4,7 +4,6 @@ import (
 	"testing"
 
 	"github.com/DmitryBogomolov/containerator/test_mocks"
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -16,7 +15,8 @@ func TestSuspendContainer(t *testing.T) {
 
 	cli := test_mocks.NewMockContainerAPIClient(ctrl)
 	cli.EXPECT().ContainerRename(gomock.Any(), "0123456789ab", gomock.Any()).Return(nil)
-	cli.EXPECT().ContainerStop(gomock.Any(), "0123456789ab", container.StopOptions{}).Return(nil)
+	cli.EXPECT().ContainerInspect(gomock.Any(), "0123456789ab").Return(testStopInfo(true, "SIGQUIT", nil), nil)
+	cli.EXPECT().ContainerStop(gomock.Any(), "0123456789ab", container.StopOptions{Signal: "SIGQUIT"}).Return(nil)
 
 	err := SuspendContainer(cli, testContainer("0123456789ab", ""))
 	assert.NoError(t, err)
@@ -28,7 +28,7 @@ func TestResumeContainer(t *testing.T) {
 
 	cli := test_mocks.NewMockContainerAPIClient(ctrl)
 	cli.EXPECT().ContainerRename(gomock.Any(), "0123456789ab", "my-container").Return(nil)
-	cli.EXPECT().ContainerStart(gomock.Any(), "0123456789ab", types.ContainerStartOptions{}).Return(nil)
+	cli.EXPECT().ContainerStart(gomock.Any(), "0123456789ab", container.StartOptions{}).Return(nil)
 
 	err := ResumeContainer(cli, testContainer("0123456789ab", ""), "my-container")
 	assert.NoError(t, err)
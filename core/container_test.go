@@ -38,3 +38,8 @@ func TestContainer_ImageID(t *testing.T) {
 	container := testContainer("", "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
 	assert.Equal(t, "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", container.ImageID())
 }
+
+func TestContainer_Labels(t *testing.T) {
+	container := &_Container{&types.Container{Labels: map[string]string{"a": "1"}}}
+	assert.Equal(t, map[string]string{"a": "1"}, container.Labels())
+}
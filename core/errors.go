@@ -0,0 +1,88 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/errdefs"
+)
+
+var (
+	// ErrNotFound is returned when container or image is not found.
+	//
+	//	_, err := FindContainerByName(cli, "my-container")
+	//	errors.Is(err, ErrNotFound) -> true
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when operation conflicts with the current state of docker objects.
+	// For example container name is already in use.
+	ErrConflict = errors.New("conflict")
+)
+
+// AmbiguousIDError is returned when short id matches several objects.
+type AmbiguousIDError struct {
+	id         string
+	candidates []string
+}
+
+func (err AmbiguousIDError) Error() string {
+	return fmt.Sprintf("id '%s' is ambiguous: %s", err.id, strings.Join(err.candidates, ", "))
+}
+
+// ID returns requested id.
+func (err AmbiguousIDError) ID() string {
+	return err.id
+}
+
+// Candidates returns ids of all matching objects.
+func (err AmbiguousIDError) Candidates() []string {
+	return err.candidates
+}
+
+type _DockerError struct {
+	kind error
+	err  error
+}
+
+func (err *_DockerError) Error() string {
+	return err.err.Error()
+}
+
+func (err *_DockerError) Unwrap() error {
+	return err.err
+}
+
+func (err *_DockerError) Is(target error) bool {
+	return target == err.kind
+}
+
+// wrapError makes docker not found and conflict errors match ErrNotFound and ErrConflict.
+func wrapError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errdefs.IsNotFound(err):
+		return &_DockerError{ErrNotFound, err}
+	case errdefs.IsConflict(err):
+		return &_DockerError{ErrConflict, err}
+	default:
+		return err
+	}
+}
+
+func notFound(kind string, key string) error {
+	return fmt.Errorf("%s '%s' is %w", kind, key, ErrNotFound)
+}
+
+// IgnoreNotFound turns ErrNotFound into zero value and nil error.
+//
+// Restores behavior of finders that returned nil without error when nothing matched.
+//
+//	IgnoreNotFound(FindContainerByName(cli, "my-container")) -> nil, nil
+func IgnoreNotFound[T any](value T, err error) (T, error) {
+	if errors.Is(err, ErrNotFound) {
+		var zero T
+		return zero, nil
+	}
+	return value, err
+}
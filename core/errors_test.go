@@ -0,0 +1,58 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapError(t *testing.T) {
+	t.Run("Nil", func(t *testing.T) {
+		assert.NoError(t, wrapError(nil))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		err := wrapError(errdefs.NotFound(errors.New("no such container")))
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.True(t, errdefs.IsNotFound(err))
+		assert.Equal(t, "no such container", err.Error())
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		err := wrapError(errdefs.Conflict(errors.New("name is in use")))
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.NotErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Other", func(t *testing.T) {
+		original := errors.New("test")
+		assert.Equal(t, original, wrapError(original))
+	})
+}
+
+func TestAmbiguousIDError(t *testing.T) {
+	err := AmbiguousIDError{"12", []string{"123", "124"}}
+	assert.Equal(t, "id '12' is ambiguous: 123, 124", err.Error())
+}
+
+func TestIgnoreNotFound(t *testing.T) {
+	t.Run("NotFound", func(t *testing.T) {
+		value, err := IgnoreNotFound("test", notFound("container", "test"))
+		assert.NoError(t, err)
+		assert.Equal(t, "", value)
+	})
+
+	t.Run("Other", func(t *testing.T) {
+		original := errors.New("test")
+		_, err := IgnoreNotFound("test", original)
+		assert.Equal(t, original, err)
+	})
+
+	t.Run("Found", func(t *testing.T) {
+		value, err := IgnoreNotFound("test", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "test", value)
+	})
+}
@@ -0,0 +1,715 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/namesgenerator"
+	"github.com/docker/go-connections/nat"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var containerNamePattern = regexp.MustCompile("^" + containerNameChar + "+$")
+
+func (engine *Engine) findContainer(ref string) (*_Container, error) {
+	name := strings.TrimPrefix(ref, namesPrefix)
+	for _, object := range engine.containers {
+		if object.id == ref || object.name == name {
+			return object, nil
+		}
+	}
+	var found *_Container
+	if ref != "" {
+		for _, object := range engine.containers {
+			if strings.HasPrefix(object.id, ref) {
+				if found != nil {
+					return nil, invalidParameter("multiple IDs found with provided prefix: %s", ref)
+				}
+				found = object
+			}
+		}
+	}
+	if found == nil {
+		return nil, notFound("No such container: %s", ref)
+	}
+	return found, nil
+}
+
+func (engine *Engine) checkContainerName(name string, self *_Container) error {
+	if !containerNamePattern.MatchString(name) {
+		return invalidParameter("Invalid container name (%s), only %s are allowed", name, containerNameChar)
+	}
+	for _, object := range engine.containers {
+		if object.name == name && object != self {
+			return conflict(
+				"Conflict. The container name \"/%s\" is already in use by container \"%s\". "+
+					"You have to remove (or rename) that container to be able to reuse that name.",
+				name, object.id,
+			)
+		}
+	}
+	return nil
+}
+
+func (engine *Engine) checkNetwork(mode container.NetworkMode) error {
+	name := string(mode)
+	if name == "" || name == "default" || mode.IsContainer() {
+		return nil
+	}
+	if _, err := engine.findNetwork(name); err != nil {
+		return notFound("network %s not found", name)
+	}
+	return nil
+}
+
+func bindingsOverlap(first nat.PortBinding, second nat.PortBinding) bool {
+	if first.HostPort != second.HostPort {
+		return false
+	}
+	isAny := func(ip string) bool {
+		return ip == "" || ip == "0.0.0.0" || ip == "::"
+	}
+	return first.HostIP == second.HostIP || isAny(first.HostIP) || isAny(second.HostIP)
+}
+
+func (engine *Engine) allocatePorts(object *_Container) (nat.PortMap, error) {
+	ports := nat.PortMap{}
+	for port, bindings := range object.hostConfig.PortBindings {
+		for _, binding := range bindings {
+			if binding.HostPort == "" {
+				binding.HostPort = strconv.Itoa(engine.nextPort)
+				engine.nextPort++
+			}
+			for _, other := range engine.containers {
+				if other == object || other.state != stateRunning {
+					continue
+				}
+				for otherPort, otherBindings := range other.ports {
+					if otherPort.Proto() != port.Proto() {
+						continue
+					}
+					for _, otherBinding := range otherBindings {
+						if bindingsOverlap(binding, otherBinding) {
+							return nil, fmt.Errorf(
+								"driver failed programming external connectivity on endpoint %s (%s): "+
+									"Bind for %s:%s failed: port is already allocated",
+								object.name, object.id, "0.0.0.0", binding.HostPort,
+							)
+						}
+					}
+				}
+			}
+			ports[port] = append(ports[port], binding)
+		}
+	}
+	return ports, nil
+}
+
+func (engine *Engine) start(object *_Container) error {
+	ports, err := engine.allocatePorts(object)
+	if err != nil {
+		return err
+	}
+	object.ports = ports
+	object.state = stateRunning
+	object.startedAt = time.Now()
+	if object.config.Healthcheck != nil && object.health == "" {
+		object.health = types.Starting
+	}
+	return nil
+}
+
+func (engine *Engine) stop(object *_Container) {
+	object.state = stateExited
+	object.ports = nil
+	object.finishedAt = time.Now()
+	if object.health != "" {
+		object.health = types.Unhealthy
+	}
+}
+
+func (object *_Container) summary() types.Container {
+	result := types.Container{
+		ID:      object.id,
+		Names:   []string{namesPrefix + object.name},
+		Image:   object.image,
+		ImageID: object.imageID,
+		Command: strings.Join(object.config.Cmd, " "),
+		Created: object.created.Unix(),
+		Labels:  copyMap(object.config.Labels),
+		State:   object.state,
+		Status:  object.status(),
+	}
+	result.HostConfig.NetworkMode = string(object.hostConfig.NetworkMode)
+	for port := range object.config.ExposedPorts {
+		bindings := object.ports[port]
+		if len(bindings) == 0 {
+			result.Ports = append(result.Ports, types.Port{PrivatePort: uint16(port.Int()), Type: port.Proto()})
+		}
+		for _, binding := range bindings {
+			hostPort, _ := strconv.Atoi(binding.HostPort)
+			result.Ports = append(result.Ports, types.Port{
+				IP:          binding.HostIP,
+				PrivatePort: uint16(port.Int()),
+				PublicPort:  uint16(hostPort),
+				Type:        port.Proto(),
+			})
+		}
+	}
+	return result
+}
+
+func (object *_Container) status() string {
+	switch object.state {
+	case stateRunning:
+		return "Up"
+	case statePaused:
+		return "Up (Paused)"
+	case stateExited:
+		return "Exited (0)"
+	default:
+		return "Created"
+	}
+}
+
+func formatTime(value time.Time) string {
+	if value.IsZero() {
+		return "0001-01-01T00:00:00Z"
+	}
+	return value.UTC().Format(time.RFC3339Nano)
+}
+
+// copyConfig copies config with its maps and slices, so caller and engine do not share them.
+func copyConfig(source *container.Config) container.Config {
+	result := *source
+	result.Labels = copyMap(source.Labels)
+	result.Env = append([]string(nil), source.Env...)
+	result.Cmd = append([]string(nil), source.Cmd...)
+	result.Entrypoint = append([]string(nil), source.Entrypoint...)
+	if source.ExposedPorts != nil {
+		result.ExposedPorts = make(nat.PortSet, len(source.ExposedPorts))
+		for port := range source.ExposedPorts {
+			result.ExposedPorts[port] = struct{}{}
+		}
+	}
+	if source.Volumes != nil {
+		result.Volumes = make(map[string]struct{}, len(source.Volumes))
+		for path := range source.Volumes {
+			result.Volumes[path] = struct{}{}
+		}
+	}
+	if source.Healthcheck != nil {
+		healthcheck := *source.Healthcheck
+		healthcheck.Test = append([]string(nil), source.Healthcheck.Test...)
+		result.Healthcheck = &healthcheck
+	}
+	if source.StopTimeout != nil {
+		timeout := *source.StopTimeout
+		result.StopTimeout = &timeout
+	}
+	return result
+}
+
+// copyHostConfig copies host config with its port bindings, mounts and binds.
+func copyHostConfig(source *container.HostConfig) container.HostConfig {
+	result := *source
+	result.Binds = append([]string(nil), source.Binds...)
+	result.Mounts = append([]mount.Mount(nil), source.Mounts...)
+	if source.PortBindings != nil {
+		result.PortBindings = make(nat.PortMap, len(source.PortBindings))
+		for port, bindings := range source.PortBindings {
+			result.PortBindings[port] = append([]nat.PortBinding(nil), bindings...)
+		}
+	}
+	return result
+}
+
+func (object *_Container) inspect() types.ContainerJSON {
+	config := copyConfig(&object.config)
+	hostConfig := copyHostConfig(&object.hostConfig)
+	state := &types.ContainerState{
+		Status:     object.state,
+		Running:    object.state == stateRunning || object.state == statePaused,
+		Paused:     object.state == statePaused,
+		StartedAt:  formatTime(object.startedAt),
+		FinishedAt: formatTime(object.finishedAt),
+	}
+	if object.health != "" {
+		state.Health = &types.Health{Status: object.health}
+	}
+	ports := nat.PortMap{}
+	for port := range config.ExposedPorts {
+		ports[port] = object.ports[port]
+	}
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         object.id,
+			Created:    formatTime(object.created),
+			State:      state,
+			Image:      object.imageID,
+			Name:       namesPrefix + object.name,
+			Driver:     "overlay2",
+			Platform:   "linux",
+			HostConfig: &hostConfig,
+		},
+		Config: &config,
+		NetworkSettings: &types.NetworkSettings{
+			NetworkSettingsBase: types.NetworkSettingsBase{Ports: ports},
+		},
+	}
+}
+
+func matchContainer(object *_Container, args filters.Args) bool {
+	if args.Contains("id") && !args.FuzzyMatch("id", object.id) {
+		return false
+	}
+	if args.Contains("name") && !args.Match("name", object.name) {
+		return false
+	}
+	if args.Contains("label") && !args.MatchKVList("label", object.config.Labels) {
+		return false
+	}
+	if args.Contains("status") && !args.ExactMatch("status", object.state) {
+		return false
+	}
+	if args.Contains("ancestor") && !args.ExactMatch("ancestor", object.image) && !args.ExactMatch("ancestor", object.imageID) {
+		return false
+	}
+	return true
+}
+
+// ContainerList implements `client.ContainerAPIClient` interface.
+//
+// Supports "id", "name", "label", "status", "ancestor" filters.
+func (engine *Engine) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	var result []types.Container
+	for i := len(engine.containers) - 1; i >= 0; i-- {
+		object := engine.containers[i]
+		if !options.All && object.state != stateRunning && !options.Filters.Contains("status") {
+			continue
+		}
+		if matchContainer(object, options.Filters) {
+			result = append(result, object.summary())
+		}
+	}
+	if options.Limit > 0 && len(result) > options.Limit {
+		result = result[:options.Limit]
+	}
+	return result, nil
+}
+
+// ContainerCreate implements `client.ContainerAPIClient` interface.
+//
+// Generates random name if name is not provided.
+// Fails if name is already in use, image or network is not found.
+func (engine *Engine) ContainerCreate(
+	ctx context.Context,
+	config *container.Config, hostConfig *container.HostConfig,
+	networkingConfig *network.NetworkingConfig, platform *ocispec.Platform,
+	containerName string,
+) (container.CreateResponse, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	if config == nil {
+		config = &container.Config{}
+	}
+	if hostConfig == nil {
+		hostConfig = &container.HostConfig{}
+	}
+	name := strings.TrimPrefix(containerName, namesPrefix)
+	if name == "" {
+		name = namesgenerator.GetRandomName(0)
+	}
+	if err := engine.checkContainerName(name, nil); err != nil {
+		return container.CreateResponse{}, err
+	}
+	image, err := engine.findImage(config.Image)
+	if err != nil {
+		return container.CreateResponse{}, notFound("No such image: %s", config.Image)
+	}
+	if err := engine.checkNetwork(hostConfig.NetworkMode); err != nil {
+		return container.CreateResponse{}, err
+	}
+	object := &_Container{
+		id:         engine.generateID(),
+		name:       name,
+		image:      config.Image,
+		imageID:    image.id,
+		config:     copyConfig(config),
+		hostConfig: copyHostConfig(hostConfig),
+		state:      stateCreated,
+		created:    time.Now(),
+	}
+	if mode := hostConfig.NetworkMode; mode == "" || mode.IsDefault() {
+		object.networks = []string{defaultNetwork}
+	} else if mode.IsUserDefined() || mode.IsBridge() {
+		object.networks = []string{string(mode)}
+	}
+	object.config.Env = append(append([]string(nil), image.env...), config.Env...)
+	if object.config.ExposedPorts == nil && len(hostConfig.PortBindings) > 0 {
+		object.config.ExposedPorts = nat.PortSet{}
+	}
+	for port := range hostConfig.PortBindings {
+		object.config.ExposedPorts[port] = struct{}{}
+	}
+	engine.containers = append(engine.containers, object)
+	return container.CreateResponse{ID: object.id}, nil
+}
+
+// ContainerStart implements `client.ContainerAPIClient` interface.
+//
+// Fails if any of host ports is already bound by other running container.
+func (engine *Engine) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+	if object.state == stateRunning || object.state == statePaused {
+		return nil
+	}
+	return engine.start(object)
+}
+
+// ContainerStop implements `client.ContainerAPIClient` interface.
+func (engine *Engine) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+	if object.state == stateRunning || object.state == statePaused {
+		engine.stop(object)
+	}
+	return nil
+}
+
+// ContainerRestart implements `client.ContainerAPIClient` interface.
+func (engine *Engine) ContainerRestart(ctx context.Context, containerID string, options container.StopOptions) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+	engine.stop(object)
+	return engine.start(object)
+}
+
+// ContainerKill implements `client.ContainerAPIClient` interface.
+func (engine *Engine) ContainerKill(ctx context.Context, containerID, signal string) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+	if object.state != stateRunning && object.state != statePaused {
+		return conflict("Container %s is not running", object.id)
+	}
+	engine.stop(object)
+	return nil
+}
+
+// ContainerPause implements `client.ContainerAPIClient` interface.
+func (engine *Engine) ContainerPause(ctx context.Context, containerID string) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+	if object.state != stateRunning {
+		return conflict("Container %s is not running", object.id)
+	}
+	object.state = statePaused
+	return nil
+}
+
+// ContainerUnpause implements `client.ContainerAPIClient` interface.
+func (engine *Engine) ContainerUnpause(ctx context.Context, containerID string) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+	if object.state != statePaused {
+		return conflict("Container %s is not paused", object.id)
+	}
+	object.state = stateRunning
+	return nil
+}
+
+// ContainerRename implements `client.ContainerAPIClient` interface.
+//
+// Fails if new name is already in use.
+func (engine *Engine) ContainerRename(ctx context.Context, containerID, newContainerName string) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimPrefix(newContainerName, namesPrefix)
+	if name == object.name {
+		return conflict("Renaming a container with the same name as its current name")
+	}
+	if err := engine.checkContainerName(name, object); err != nil {
+		return err
+	}
+	object.name = name
+	return nil
+}
+
+// ContainerRemove implements `client.ContainerAPIClient` interface.
+//
+// Fails if container is running and `Force` is not set.
+func (engine *Engine) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+	if (object.state == stateRunning || object.state == statePaused) && !options.Force {
+		return conflict(
+			"You cannot remove a running container %s. Stop the container before attempting removal or force remove",
+			object.id,
+		)
+	}
+	engine.removeContainer(object)
+	return nil
+}
+
+func (engine *Engine) removeContainer(object *_Container) {
+	for i, item := range engine.containers {
+		if item == object {
+			engine.containers = append(engine.containers[:i], engine.containers[i+1:]...)
+			return
+		}
+	}
+}
+
+// ContainerInspect implements `client.ContainerAPIClient` interface.
+func (engine *Engine) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findContainer(containerID)
+	if err != nil {
+		return types.ContainerJSON{}, err
+	}
+	return object.inspect(), nil
+}
+
+// ContainerInspectWithRaw implements `client.ContainerAPIClient` interface.
+func (engine *Engine) ContainerInspectWithRaw(
+	ctx context.Context, containerID string, getSize bool,
+) (types.ContainerJSON, []byte, error) {
+	info, err := engine.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return info, nil, err
+	}
+	raw, err := json.Marshal(info)
+	return info, raw, err
+}
+
+// ContainersPrune implements `client.ContainerAPIClient` interface.
+//
+// Removes all stopped containers.
+func (engine *Engine) ContainersPrune(ctx context.Context, pruneFilters filters.Args) (types.ContainersPruneReport, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	var report types.ContainersPruneReport
+	var kept []*_Container
+	for _, object := range engine.containers {
+		if object.state == stateRunning || object.state == statePaused || !matchContainer(object, pruneFilters) {
+			kept = append(kept, object)
+		} else {
+			report.ContainersDeleted = append(report.ContainersDeleted, object.id)
+		}
+	}
+	engine.containers = kept
+	sort.Strings(report.ContainersDeleted)
+	return report, nil
+}
+
+// ContainerAttach implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerAttach(
+	ctx context.Context, containerID string, options container.AttachOptions,
+) (types.HijackedResponse, error) {
+	return types.HijackedResponse{}, notImplemented("ContainerAttach")
+}
+
+// ContainerCommit implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerCommit(
+	ctx context.Context, containerID string, options container.CommitOptions,
+) (types.IDResponse, error) {
+	return types.IDResponse{}, notImplemented("ContainerCommit")
+}
+
+// ContainerDiff implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerDiff(ctx context.Context, containerID string) ([]container.FilesystemChange, error) {
+	return nil, notImplemented("ContainerDiff")
+}
+
+// ContainerExecAttach implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerExecAttach(
+	ctx context.Context, execID string, config types.ExecStartCheck,
+) (types.HijackedResponse, error) {
+	return types.HijackedResponse{}, notImplemented("ContainerExecAttach")
+}
+
+// ContainerExecCreate implements `client.ContainerAPIClient` interface.
+func (engine *Engine) ContainerExecCreate(
+	ctx context.Context, containerID string, config types.ExecConfig,
+) (types.IDResponse, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findContainer(containerID)
+	if err != nil {
+		return types.IDResponse{}, err
+	}
+	if object.state != stateRunning {
+		return types.IDResponse{}, conflict("Container %s is not running", object.id)
+	}
+	if len(config.Cmd) == 0 {
+		return types.IDResponse{}, invalidParameter("No exec command specified")
+	}
+	exec := &_Exec{id: engine.generateID(), containerID: object.id, cmd: config.Cmd, env: config.Env}
+	engine.execs[exec.id] = exec
+	return types.IDResponse{ID: exec.id}, nil
+}
+
+// ContainerExecInspect implements `client.ContainerAPIClient` interface.
+func (engine *Engine) ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	exec, ok := engine.execs[execID]
+	if !ok {
+		return types.ContainerExecInspect{}, notFound("No such exec instance: %s", execID)
+	}
+	return types.ContainerExecInspect{
+		ExecID:      exec.id,
+		ContainerID: exec.containerID,
+		Running:     false,
+		ExitCode:    exec.exitCode,
+	}, nil
+}
+
+// ContainerExecResize implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerExecResize(ctx context.Context, execID string, options container.ResizeOptions) error {
+	return notImplemented("ContainerExecResize")
+}
+
+// ContainerExecStart implements `client.ContainerAPIClient` interface.
+//
+// Command is run by handler set with SetExecHandler before method returns.
+func (engine *Engine) ContainerExecStart(ctx context.Context, execID string, config types.ExecStartCheck) error {
+	engine.lock.Lock()
+	exec, ok := engine.execs[execID]
+	if !ok {
+		engine.lock.Unlock()
+		return notFound("No such exec instance: %s", execID)
+	}
+	if exec.started {
+		engine.lock.Unlock()
+		return conflict("Exec %s has already run", execID)
+	}
+	exec.started = true
+	handler := engine.onExec
+	engine.lock.Unlock()
+	exitCode := 0
+	if handler != nil {
+		exitCode = handler(exec.containerID, exec.cmd, exec.env)
+	}
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	exec.exitCode = exitCode
+	return nil
+}
+
+// ContainerExport implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerExport(ctx context.Context, containerID string) (io.ReadCloser, error) {
+	return nil, notImplemented("ContainerExport")
+}
+
+// ContainerLogs implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerLogs(
+	ctx context.Context, containerID string, options container.LogsOptions,
+) (io.ReadCloser, error) {
+	return nil, notImplemented("ContainerLogs")
+}
+
+// ContainerResize implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerResize(ctx context.Context, containerID string, options container.ResizeOptions) error {
+	return notImplemented("ContainerResize")
+}
+
+// ContainerStatPath implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerStatPath(ctx context.Context, containerID, path string) (types.ContainerPathStat, error) {
+	return types.ContainerPathStat{}, notImplemented("ContainerStatPath")
+}
+
+// ContainerStats implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerStats(ctx context.Context, containerID string, stream bool) (types.ContainerStats, error) {
+	return types.ContainerStats{}, notImplemented("ContainerStats")
+}
+
+// ContainerStatsOneShot implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerStatsOneShot(ctx context.Context, containerID string) (types.ContainerStats, error) {
+	return types.ContainerStats{}, notImplemented("ContainerStatsOneShot")
+}
+
+// ContainerTop implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerTop(
+	ctx context.Context, containerID string, arguments []string,
+) (container.ContainerTopOKBody, error) {
+	return container.ContainerTopOKBody{}, notImplemented("ContainerTop")
+}
+
+// ContainerUpdate implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerUpdate(
+	ctx context.Context, containerID string, updateConfig container.UpdateConfig,
+) (container.ContainerUpdateOKBody, error) {
+	return container.ContainerUpdateOKBody{}, notImplemented("ContainerUpdate")
+}
+
+// ContainerWait implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) ContainerWait(
+	ctx context.Context, containerID string, condition container.WaitCondition,
+) (<-chan container.WaitResponse, <-chan error) {
+	errCh := make(chan error, 1)
+	errCh <- notImplemented("ContainerWait")
+	return make(chan container.WaitResponse), errCh
+}
+
+// CopyFromContainer implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) CopyFromContainer(
+	ctx context.Context, containerID, srcPath string,
+) (io.ReadCloser, types.ContainerPathStat, error) {
+	return nil, types.ContainerPathStat{}, notImplemented("CopyFromContainer")
+}
+
+// CopyToContainer implements `client.ContainerAPIClient` interface. Not supported.
+func (engine *Engine) CopyToContainer(
+	ctx context.Context, containerID, path string, content io.Reader, options types.CopyToContainerOptions,
+) error {
+	return notImplemented("CopyToContainer")
+}
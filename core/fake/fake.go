@@ -0,0 +1,247 @@
+/*
+Package fake contains in-memory docker engine for tests.
+
+Engine keeps containers, images, networks and volumes in memory and
+implements `client.ContainerAPIClient`, `client.ImageAPIClient`,
+`client.NetworkAPIClient` and `client.VolumeAPIClient`.
+It follows docker daemon rules where they matter for container management -
+container names are unique, running containers cannot be removed without force,
+host ports cannot be bound twice, images used by containers cannot be removed.
+Errors are built with `errdefs` package so they can be checked the same way as real ones.
+
+	engine := fake.New()
+	engine.AddImage("my-image:1", "my-image:latest")
+	manage.RunContainer(engine, cfg, &manage.Options{})
+*/
+package fake
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+)
+
+var (
+	_ client.ContainerAPIClient = (*Engine)(nil)
+	_ client.ImageAPIClient     = (*Engine)(nil)
+	_ client.NetworkAPIClient   = (*Engine)(nil)
+	_ client.VolumeAPIClient    = (*Engine)(nil)
+)
+
+const (
+	imageIDPrefix     = "sha256:"
+	firstDynamicPort  = 32768
+	defaultNetwork    = "bridge"
+	stateCreated      = "created"
+	stateRunning      = "running"
+	statePaused       = "paused"
+	stateExited       = "exited"
+	defaultTagLatest  = "latest"
+	namesPrefix       = "/"
+	containerNameChar = "[a-zA-Z0-9][a-zA-Z0-9_.-]"
+)
+
+// Image describes image added to the engine.
+type Image struct {
+	RepoTags []string          // List of repo:tag pairs
+	Created  time.Time         // Creation time; current time if not set
+	Size     int64             // Image size
+	Labels   map[string]string // Image labels
+	Env      []string          // Environment variables set in the image
+}
+
+type _Container struct {
+	id         string
+	name       string
+	image      string
+	imageID    string
+	config     container.Config
+	hostConfig container.HostConfig
+	ports      nat.PortMap
+	networks   []string
+	state      string
+	created    time.Time
+	startedAt  time.Time
+	finishedAt time.Time
+	health     string
+}
+
+type _Exec struct {
+	id          string
+	containerID string
+	cmd         []string
+	env         []string
+	started     bool
+	exitCode    int
+}
+
+// ExecHandler runs command executed in container; returns exit code.
+type ExecHandler func(containerID string, cmd []string, env []string) int
+
+type _Image struct {
+	id       string
+	repoTags []string
+	created  time.Time
+	size     int64
+	labels   map[string]string
+	env      []string
+}
+
+type _Network struct {
+	id      string
+	name    string
+	driver  string
+	created time.Time
+	labels  map[string]string
+}
+
+type _Volume struct {
+	name    string
+	driver  string
+	created time.Time
+	labels  map[string]string
+	options map[string]string
+}
+
+// Engine is in-memory docker engine.
+type Engine struct {
+	lock       sync.Mutex
+	counter    int
+	nextPort   int
+	containers []*_Container
+	images     []*_Image
+	networks   []*_Network
+	volumes    []*_Volume
+	execs      map[string]*_Exec
+	onExec     ExecHandler
+}
+
+// New creates Engine instance.
+//
+// Engine has default "bridge", "host" and "none" networks.
+//
+//	New() -> &engine
+func New() *Engine {
+	engine := &Engine{nextPort: firstDynamicPort, execs: map[string]*_Exec{}}
+	for _, name := range []string{defaultNetwork, "host", "none"} {
+		engine.networks = append(engine.networks, &_Network{
+			id:      engine.generateID(),
+			name:    name,
+			driver:  name,
+			created: time.Now(),
+		})
+	}
+	return engine
+}
+
+// AddImage adds image with specified repo:tag pairs and returns its id.
+//
+//	engine.AddImage("my-image:1", "my-image:latest") -> "sha256:<guid>"
+func (engine *Engine) AddImage(repoTags ...string) string {
+	return engine.PutImage(Image{RepoTags: repoTags})
+}
+
+// PutImage adds image and returns its id.
+//
+// Tags that already belong to other images are moved to the new image.
+//
+//	engine.PutImage(Image{RepoTags: []string{"my-image:1"}, Size: 1000}) -> "sha256:<guid>"
+func (engine *Engine) PutImage(image Image) string {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	created := image.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+	object := &_Image{
+		id:      imageIDPrefix + engine.generateID(),
+		created: created,
+		size:    image.Size,
+		labels:  image.Labels,
+		env:     image.Env,
+	}
+	engine.images = append(engine.images, object)
+	for _, repoTag := range image.RepoTags {
+		engine.tagImage(object, normalizeImageName(repoTag))
+	}
+	return object.id
+}
+
+// SetHealth sets health status of the container.
+//
+// Status is one of "starting", "healthy", "unhealthy" or empty string that removes health information.
+//
+//	engine.SetHealth("my-container", "healthy")
+func (engine *Engine) SetHealth(containerID string, status string) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+	object.health = status
+	return nil
+}
+
+// SetExecHandler sets function that runs commands executed in containers.
+//
+// Commands exit with code 0 if handler is not set.
+//
+//	engine.SetExecHandler(func(containerID string, cmd []string, env []string) int { return 1 })
+func (engine *Engine) SetExecHandler(handler ExecHandler) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	engine.onExec = handler
+}
+
+func (engine *Engine) generateID() string {
+	engine.counter++
+	hash := sha256.Sum256([]byte(fmt.Sprintf("fake-engine-object-%d", engine.counter)))
+	return hex.EncodeToString(hash[:])
+}
+
+func normalizeImageName(name string) string {
+	if strings.Contains(name, ":") {
+		return name
+	}
+	return name + ":" + defaultTagLatest
+}
+
+func notFound(format string, args ...any) error {
+	return errdefs.NotFound(fmt.Errorf(format, args...))
+}
+
+func conflict(format string, args ...any) error {
+	return errdefs.Conflict(fmt.Errorf(format, args...))
+}
+
+func forbidden(format string, args ...any) error {
+	return errdefs.Forbidden(fmt.Errorf(format, args...))
+}
+
+func invalidParameter(format string, args ...any) error {
+	return errdefs.InvalidParameter(fmt.Errorf(format, args...))
+}
+
+func notImplemented(method string) error {
+	return errdefs.NotImplemented(fmt.Errorf("fake engine does not implement %s", method))
+}
+
+func copyMap(source map[string]string) map[string]string {
+	if source == nil {
+		return nil
+	}
+	result := make(map[string]string, len(source))
+	for key, value := range source {
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,301 @@
+package fake
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+)
+
+func createContainer(t *testing.T, engine *Engine, image string, name string) string {
+	body, err := engine.ContainerCreate(context.Background(), &container.Config{Image: image}, nil, nil, nil, name)
+	assert.NoError(t, err)
+	return body.ID
+}
+
+func TestContainers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Lifecycle", func(t *testing.T) {
+		engine := New()
+		imageID := engine.AddImage("test-image:1")
+		id := createContainer(t, engine, "test-image:1", "test-1")
+
+		list, err := engine.ContainerList(ctx, container.ListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, list, 0)
+		list, err = engine.ContainerList(ctx, container.ListOptions{All: true})
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+		assert.Equal(t, id, list[0].ID)
+		assert.Equal(t, []string{"/test-1"}, list[0].Names)
+		assert.Equal(t, imageID, list[0].ImageID)
+		assert.Equal(t, "created", list[0].State)
+
+		assert.NoError(t, engine.ContainerStart(ctx, "test-1", container.StartOptions{}))
+		info, err := engine.ContainerInspect(ctx, id[:6])
+		assert.NoError(t, err)
+		assert.Equal(t, "running", info.State.Status)
+		assert.Equal(t, "/test-1", info.Name)
+
+		assert.NoError(t, engine.ContainerRename(ctx, id, "test-2"))
+		assert.NoError(t, engine.ContainerStop(ctx, "test-2", container.StopOptions{}))
+		info, err = engine.ContainerInspect(ctx, "test-2")
+		assert.NoError(t, err)
+		assert.Equal(t, "exited", info.State.Status)
+
+		assert.NoError(t, engine.ContainerRemove(ctx, id, container.RemoveOptions{}))
+		_, err = engine.ContainerInspect(ctx, id)
+		assert.True(t, errdefs.IsNotFound(err))
+	})
+
+	t.Run("Name conflict", func(t *testing.T) {
+		engine := New()
+		engine.AddImage("test-image")
+		createContainer(t, engine, "test-image", "test-1")
+		id := createContainer(t, engine, "test-image", "test-2")
+
+		_, err := engine.ContainerCreate(ctx, &container.Config{Image: "test-image"}, nil, nil, nil, "test-1")
+		assert.True(t, errdefs.IsConflict(err))
+		err = engine.ContainerRename(ctx, id, "test-1")
+		assert.True(t, errdefs.IsConflict(err))
+	})
+
+	t.Run("Invalid name", func(t *testing.T) {
+		engine := New()
+		engine.AddImage("test-image")
+		_, err := engine.ContainerCreate(ctx, &container.Config{Image: "test-image"}, nil, nil, nil, "test 1")
+		assert.True(t, errdefs.IsInvalidParameter(err))
+	})
+
+	t.Run("No image", func(t *testing.T) {
+		engine := New()
+		_, err := engine.ContainerCreate(ctx, &container.Config{Image: "test-image"}, nil, nil, nil, "test-1")
+		assert.True(t, errdefs.IsNotFound(err))
+	})
+
+	t.Run("No network", func(t *testing.T) {
+		engine := New()
+		engine.AddImage("test-image")
+		_, err := engine.ContainerCreate(
+			ctx,
+			&container.Config{Image: "test-image"},
+			&container.HostConfig{NetworkMode: "test-net"},
+			nil, nil, "test-1",
+		)
+		assert.True(t, errdefs.IsNotFound(err))
+	})
+
+	t.Run("Remove running", func(t *testing.T) {
+		engine := New()
+		engine.AddImage("test-image")
+		id := createContainer(t, engine, "test-image", "test-1")
+		assert.NoError(t, engine.ContainerStart(ctx, id, container.StartOptions{}))
+
+		err := engine.ContainerRemove(ctx, id, container.RemoveOptions{})
+		assert.True(t, errdefs.IsConflict(err))
+		assert.NoError(t, engine.ContainerRemove(ctx, id, container.RemoveOptions{Force: true}))
+	})
+
+	t.Run("Port allocation", func(t *testing.T) {
+		engine := New()
+		engine.AddImage("test-image")
+		hostConfig := &container.HostConfig{
+			PortBindings: nat.PortMap{"80/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "8080"}}},
+		}
+		config := &container.Config{Image: "test-image"}
+		first, _ := engine.ContainerCreate(ctx, config, hostConfig, nil, nil, "test-1")
+		second, _ := engine.ContainerCreate(ctx, config, hostConfig, nil, nil, "test-2")
+
+		assert.NoError(t, engine.ContainerStart(ctx, first.ID, container.StartOptions{}))
+		assert.Error(t, engine.ContainerStart(ctx, second.ID, container.StartOptions{}))
+		assert.NoError(t, engine.ContainerStop(ctx, first.ID, container.StopOptions{}))
+		assert.NoError(t, engine.ContainerStart(ctx, second.ID, container.StartOptions{}))
+
+		list, _ := engine.ContainerList(ctx, container.ListOptions{})
+		assert.Equal(t, []types.Port{{IP: "0.0.0.0", PrivatePort: 80, PublicPort: 8080, Type: "tcp"}}, list[0].Ports)
+	})
+
+	t.Run("Config is copied", func(t *testing.T) {
+		engine := New()
+		engine.AddImage("test-image")
+		config := &container.Config{
+			Image:        "test-image",
+			ExposedPorts: nat.PortSet{"90/tcp": {}},
+			Labels:       map[string]string{"a": "1"},
+		}
+		hostConfig := &container.HostConfig{
+			PortBindings: nat.PortMap{"80/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "8080"}}},
+		}
+		body, _ := engine.ContainerCreate(ctx, config, hostConfig, nil, nil, "test-1")
+
+		assert.Equal(t, nat.PortSet{"90/tcp": {}}, config.ExposedPorts)
+		info, _ := engine.ContainerInspect(ctx, body.ID)
+		info.Config.Labels["a"] = "2"
+		info.Config.ExposedPorts["100/tcp"] = struct{}{}
+		info.HostConfig.PortBindings["80/tcp"][0].HostPort = "9090"
+		config.Labels["a"] = "3"
+		info, _ = engine.ContainerInspect(ctx, body.ID)
+		assert.Equal(t, map[string]string{"a": "1"}, info.Config.Labels)
+		assert.Equal(t, nat.PortSet{"80/tcp": {}, "90/tcp": {}}, info.Config.ExposedPorts)
+		assert.Equal(t, "8080", info.HostConfig.PortBindings["80/tcp"][0].HostPort)
+	})
+
+	t.Run("Dynamic port", func(t *testing.T) {
+		engine := New()
+		engine.AddImage("test-image")
+		hostConfig := &container.HostConfig{
+			PortBindings: nat.PortMap{"80/tcp": []nat.PortBinding{{HostIP: "0.0.0.0"}}},
+		}
+		body, _ := engine.ContainerCreate(ctx, &container.Config{Image: "test-image"}, hostConfig, nil, nil, "test-1")
+		assert.NoError(t, engine.ContainerStart(ctx, body.ID, container.StartOptions{}))
+
+		info, _ := engine.ContainerInspect(ctx, body.ID)
+		assert.Equal(t, []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "32768"}}, info.NetworkSettings.Ports["80/tcp"])
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		engine := New()
+		engine.AddImage("test-image")
+		engine.ContainerCreate(
+			ctx, &container.Config{Image: "test-image", Labels: map[string]string{"a": "1"}}, nil, nil, nil, "test-1",
+		)
+		engine.ContainerCreate(
+			ctx, &container.Config{Image: "test-image", Labels: map[string]string{"a": "2"}}, nil, nil, nil, "test-2",
+		)
+
+		list, err := engine.ContainerList(ctx, container.ListOptions{
+			All:     true,
+			Filters: filters.NewArgs(filters.Arg("label", "a=2")),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+		assert.Equal(t, []string{"/test-2"}, list[0].Names)
+	})
+
+	t.Run("Health", func(t *testing.T) {
+		engine := New()
+		engine.AddImage("test-image")
+		id := createContainer(t, engine, "test-image", "test-1")
+		engine.ContainerStart(ctx, id, container.StartOptions{})
+		assert.NoError(t, engine.SetHealth("test-1", "healthy"))
+
+		info, _ := engine.ContainerInspect(ctx, id)
+		assert.Equal(t, "healthy", info.State.Health.Status)
+	})
+
+	t.Run("Exec", func(t *testing.T) {
+		engine := New()
+		engine.AddImage("test-image")
+		id := createContainer(t, engine, "test-image", "test-1")
+		_, err := engine.ContainerExecCreate(ctx, id, types.ExecConfig{Cmd: []string{"ls"}})
+		assert.True(t, errdefs.IsConflict(err))
+		engine.ContainerStart(ctx, id, container.StartOptions{})
+		var executed []string
+		engine.SetExecHandler(func(containerID string, cmd []string, env []string) int {
+			executed = append(executed, containerID, cmd[0], env[0])
+			return 2
+		})
+
+		config := types.ExecConfig{Cmd: []string{"ls"}, Env: []string{"A=1"}}
+		response, err := engine.ContainerExecCreate(ctx, "test-1", config)
+		assert.NoError(t, err)
+		assert.NoError(t, engine.ContainerExecStart(ctx, response.ID, types.ExecStartCheck{Detach: true}))
+		info, err := engine.ContainerExecInspect(ctx, response.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, info.ExitCode)
+		assert.False(t, info.Running)
+		assert.Equal(t, []string{id, "ls", "A=1"}, executed)
+	})
+}
+
+func TestImages(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("List and tag", func(t *testing.T) {
+		engine := New()
+		first := engine.AddImage("test-image:1", "test-image:latest")
+		second := engine.AddImage("test-image:2")
+		assert.NoError(t, engine.ImageTag(ctx, second, "test-image"))
+
+		list, err := engine.ImageList(ctx, types.ImageListOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, list, 2)
+		assert.Equal(t, second, list[0].ID)
+		assert.Equal(t, []string{"test-image:2", "test-image:latest"}, list[0].RepoTags)
+		assert.Equal(t, first, list[1].ID)
+		assert.Equal(t, []string{"test-image:1"}, list[1].RepoTags)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		engine := New()
+		id := engine.AddImage("test-image:1", "test-image:2")
+
+		result, err := engine.ImageRemove(ctx, "test-image:1", types.ImageRemoveOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "test-image:1", result[0].Untagged)
+		result, err = engine.ImageRemove(ctx, "test-image:2", types.ImageRemoveOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, id, result[1].Deleted)
+		_, _, err = engine.ImageInspectWithRaw(ctx, id)
+		assert.True(t, errdefs.IsNotFound(err))
+	})
+
+	t.Run("Remove used", func(t *testing.T) {
+		engine := New()
+		id := engine.AddImage("test-image:1")
+		containerID := createContainer(t, engine, "test-image:1", "test-1")
+
+		_, err := engine.ImageRemove(ctx, id, types.ImageRemoveOptions{})
+		assert.True(t, errdefs.IsConflict(err))
+		engine.ContainerStart(ctx, containerID, container.StartOptions{})
+		_, err = engine.ImageRemove(ctx, id, types.ImageRemoveOptions{Force: true})
+		assert.True(t, errdefs.IsConflict(err))
+	})
+}
+
+func TestNetworks(t *testing.T) {
+	ctx := context.Background()
+	engine := New()
+	engine.AddImage("test-image")
+
+	_, err := engine.NetworkCreate(ctx, "test-net", types.NetworkCreate{})
+	assert.NoError(t, err)
+	_, err = engine.NetworkCreate(ctx, "test-net", types.NetworkCreate{})
+	assert.True(t, errdefs.IsConflict(err))
+
+	body, err := engine.ContainerCreate(
+		ctx, &container.Config{Image: "test-image"}, &container.HostConfig{NetworkMode: "test-net"}, nil, nil, "test-1",
+	)
+	assert.NoError(t, err)
+	info, err := engine.NetworkInspect(ctx, "test-net", types.NetworkInspectOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, info.Containers, body.ID)
+	assert.True(t, errdefs.IsForbidden(engine.NetworkRemove(ctx, "test-net")))
+
+	assert.NoError(t, engine.ContainerRemove(ctx, body.ID, container.RemoveOptions{}))
+	assert.NoError(t, engine.NetworkRemove(ctx, "test-net"))
+}
+
+func TestVolumes(t *testing.T) {
+	ctx := context.Background()
+	engine := New()
+
+	_, err := engine.VolumeCreate(ctx, volume.CreateOptions{Name: "test-volume"})
+	assert.NoError(t, err)
+	list, err := engine.VolumeList(ctx, volume.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Volumes, 1)
+	assert.Equal(t, "test-volume", list.Volumes[0].Name)
+
+	assert.NoError(t, engine.VolumeRemove(ctx, "test-volume", false))
+	_, err = engine.VolumeInspect(ctx, "test-volume")
+	assert.True(t, errdefs.IsNotFound(err))
+}
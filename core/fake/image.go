@@ -0,0 +1,325 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
+)
+
+func (engine *Engine) findImage(ref string) (*_Image, error) {
+	if ref == "" {
+		return nil, notFound("No such image: %s", ref)
+	}
+	name := normalizeImageName(ref)
+	for _, object := range engine.images {
+		if object.id == ref {
+			return object, nil
+		}
+		for _, repoTag := range object.repoTags {
+			if repoTag == name {
+				return object, nil
+			}
+		}
+	}
+	id := ref
+	if !strings.HasPrefix(id, imageIDPrefix) {
+		id = imageIDPrefix + id
+	}
+	var found *_Image
+	for _, object := range engine.images {
+		if strings.HasPrefix(object.id, id) {
+			if found != nil {
+				return nil, invalidParameter("multiple IDs found with provided prefix: %s", ref)
+			}
+			found = object
+		}
+	}
+	if found == nil {
+		return nil, notFound("No such image: %s", ref)
+	}
+	return found, nil
+}
+
+func (engine *Engine) tagImage(object *_Image, repoTag string) {
+	for _, other := range engine.images {
+		other.repoTags = removeString(other.repoTags, repoTag)
+	}
+	object.repoTags = append(object.repoTags, repoTag)
+}
+
+func removeString(list []string, value string) []string {
+	var result []string
+	for _, item := range list {
+		if item != value {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func (engine *Engine) imageUsers(object *_Image) (running []*_Container, stopped []*_Container) {
+	for _, item := range engine.containers {
+		if item.imageID != object.id {
+			continue
+		}
+		if item.state == stateRunning || item.state == statePaused {
+			running = append(running, item)
+		} else {
+			stopped = append(stopped, item)
+		}
+	}
+	return
+}
+
+func (engine *Engine) deleteImage(object *_Image) []image.DeleteResponse {
+	var result []image.DeleteResponse
+	for _, repoTag := range object.repoTags {
+		result = append(result, image.DeleteResponse{Untagged: repoTag})
+	}
+	result = append(result, image.DeleteResponse{Deleted: object.id})
+	var kept []*_Image
+	for _, item := range engine.images {
+		if item != object {
+			kept = append(kept, item)
+		}
+	}
+	engine.images = kept
+	return result
+}
+
+func (object *_Image) summary(containers int64) image.Summary {
+	return image.Summary{
+		Containers: containers,
+		Created:    object.created.Unix(),
+		ID:         object.id,
+		Labels:     copyMap(object.labels),
+		RepoTags:   append([]string(nil), object.repoTags...),
+		Size:       object.size,
+	}
+}
+
+// ImageList implements `client.ImageAPIClient` interface.
+//
+// Supports "reference", "label", "dangling" filters.
+func (engine *Engine) ImageList(ctx context.Context, options types.ImageListOptions) ([]image.Summary, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	var result []image.Summary
+	for i := len(engine.images) - 1; i >= 0; i-- {
+		object := engine.images[i]
+		if options.Filters.Contains("label") && !options.Filters.MatchKVList("label", object.labels) {
+			continue
+		}
+		if options.Filters.Contains("dangling") {
+			dangling := len(object.repoTags) == 0
+			if !options.Filters.ExactMatch("dangling", map[bool]string{true: "true", false: "false"}[dangling]) {
+				continue
+			}
+		}
+		if options.Filters.Contains("reference") && !matchReference(object, options.Filters) {
+			continue
+		}
+		running, stopped := engine.imageUsers(object)
+		result = append(result, object.summary(int64(len(running)+len(stopped))))
+	}
+	return result, nil
+}
+
+func matchReference(object *_Image, args filters.Args) bool {
+	for _, repoTag := range object.repoTags {
+		if args.ExactMatch("reference", repoTag) || args.ExactMatch("reference", strings.Split(repoTag, ":")[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ImageInspectWithRaw implements `client.ImageAPIClient` interface.
+func (engine *Engine) ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findImage(imageID)
+	if err != nil {
+		return types.ImageInspect{}, nil, err
+	}
+	info := types.ImageInspect{
+		ID:           object.id,
+		RepoTags:     append([]string(nil), object.repoTags...),
+		Created:      formatTime(object.created),
+		Size:         object.size,
+		Architecture: "amd64",
+		Os:           "linux",
+		Config: &container.Config{
+			Env:    append([]string(nil), object.env...),
+			Labels: copyMap(object.labels),
+		},
+	}
+	raw, err := json.Marshal(info)
+	return info, raw, err
+}
+
+// ImageTag implements `client.ImageAPIClient` interface.
+//
+// Moves tag from other image if it is already used.
+func (engine *Engine) ImageTag(ctx context.Context, source, target string) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findImage(source)
+	if err != nil {
+		return err
+	}
+	engine.tagImage(object, normalizeImageName(target))
+	return nil
+}
+
+// ImageRemove implements `client.ImageAPIClient` interface.
+//
+// Removing by repo:tag untags image and deletes it when the last tag is removed.
+// Images used by containers are not deleted unless `Force` is set;
+// images used by running containers are never deleted.
+func (engine *Engine) ImageRemove(
+	ctx context.Context, imageID string, options types.ImageRemoveOptions,
+) ([]image.DeleteResponse, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findImage(imageID)
+	if err != nil {
+		return nil, err
+	}
+	running, stopped := engine.imageUsers(object)
+	repoTag := normalizeImageName(imageID)
+	isTag := object.id != imageID && containsString(object.repoTags, repoTag)
+	if isTag && (len(object.repoTags) > 1 || len(running)+len(stopped) > 0 && options.Force) {
+		object.repoTags = removeString(object.repoTags, repoTag)
+		return []image.DeleteResponse{{Untagged: repoTag}}, nil
+	}
+	if len(running) > 0 {
+		return nil, conflict(
+			"conflict: unable to delete %s (cannot be forced) - image is being used by running container %s",
+			shortImageID(object.id), running[0].id[:12],
+		)
+	}
+	if len(stopped) > 0 && !options.Force {
+		return nil, conflict(
+			"conflict: unable to delete %s (must be forced) - image is being used by stopped container %s",
+			shortImageID(object.id), stopped[0].id[:12],
+		)
+	}
+	if !isTag && len(object.repoTags) > 1 && !options.Force {
+		return nil, conflict(
+			"conflict: unable to delete %s (must be forced) - image is referenced in multiple repositories",
+			shortImageID(object.id),
+		)
+	}
+	return engine.deleteImage(object), nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func shortImageID(id string) string {
+	return strings.TrimPrefix(id, imageIDPrefix)[:12]
+}
+
+// ImagesPrune implements `client.ImageAPIClient` interface.
+//
+// Removes dangling images that are not used by containers.
+func (engine *Engine) ImagesPrune(ctx context.Context, pruneFilter filters.Args) (types.ImagesPruneReport, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	var report types.ImagesPruneReport
+	var candidates []*_Image
+	for _, object := range engine.images {
+		running, stopped := engine.imageUsers(object)
+		if len(object.repoTags) == 0 && len(running)+len(stopped) == 0 {
+			candidates = append(candidates, object)
+		}
+	}
+	for _, object := range candidates {
+		report.ImagesDeleted = append(report.ImagesDeleted, engine.deleteImage(object)...)
+		report.SpaceReclaimed += uint64(object.size)
+	}
+	sort.Slice(report.ImagesDeleted, func(i, j int) bool {
+		return report.ImagesDeleted[i].Deleted < report.ImagesDeleted[j].Deleted
+	})
+	return report, nil
+}
+
+// ImageBuild implements `client.ImageAPIClient` interface. Not supported.
+func (engine *Engine) ImageBuild(
+	ctx context.Context, context io.Reader, options types.ImageBuildOptions,
+) (types.ImageBuildResponse, error) {
+	return types.ImageBuildResponse{}, notImplemented("ImageBuild")
+}
+
+// BuildCachePrune implements `client.ImageAPIClient` interface. Not supported.
+func (engine *Engine) BuildCachePrune(
+	ctx context.Context, opts types.BuildCachePruneOptions,
+) (*types.BuildCachePruneReport, error) {
+	return nil, notImplemented("BuildCachePrune")
+}
+
+// BuildCancel implements `client.ImageAPIClient` interface. Not supported.
+func (engine *Engine) BuildCancel(ctx context.Context, id string) error {
+	return notImplemented("BuildCancel")
+}
+
+// ImageCreate implements `client.ImageAPIClient` interface. Not supported.
+func (engine *Engine) ImageCreate(
+	ctx context.Context, parentReference string, options types.ImageCreateOptions,
+) (io.ReadCloser, error) {
+	return nil, notImplemented("ImageCreate")
+}
+
+// ImageHistory implements `client.ImageAPIClient` interface. Not supported.
+func (engine *Engine) ImageHistory(ctx context.Context, image string) ([]image.HistoryResponseItem, error) {
+	return nil, notImplemented("ImageHistory")
+}
+
+// ImageImport implements `client.ImageAPIClient` interface. Not supported.
+func (engine *Engine) ImageImport(
+	ctx context.Context, source types.ImageImportSource, ref string, options types.ImageImportOptions,
+) (io.ReadCloser, error) {
+	return nil, notImplemented("ImageImport")
+}
+
+// ImageLoad implements `client.ImageAPIClient` interface. Not supported.
+func (engine *Engine) ImageLoad(ctx context.Context, input io.Reader, quiet bool) (types.ImageLoadResponse, error) {
+	return types.ImageLoadResponse{}, notImplemented("ImageLoad")
+}
+
+// ImagePull implements `client.ImageAPIClient` interface. Not supported.
+func (engine *Engine) ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error) {
+	return nil, notImplemented("ImagePull")
+}
+
+// ImagePush implements `client.ImageAPIClient` interface. Not supported.
+func (engine *Engine) ImagePush(ctx context.Context, ref string, options types.ImagePushOptions) (io.ReadCloser, error) {
+	return nil, notImplemented("ImagePush")
+}
+
+// ImageSearch implements `client.ImageAPIClient` interface. Not supported.
+func (engine *Engine) ImageSearch(
+	ctx context.Context, term string, options types.ImageSearchOptions,
+) ([]registry.SearchResult, error) {
+	return nil, notImplemented("ImageSearch")
+}
+
+// ImageSave implements `client.ImageAPIClient` interface. Not supported.
+func (engine *Engine) ImageSave(ctx context.Context, images []string) (io.ReadCloser, error) {
+	return nil, notImplemented("ImageSave")
+}
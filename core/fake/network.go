@@ -0,0 +1,203 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+)
+
+func (engine *Engine) findNetwork(ref string) (*_Network, error) {
+	for _, object := range engine.networks {
+		if object.id == ref || object.name == ref {
+			return object, nil
+		}
+	}
+	for _, object := range engine.networks {
+		if ref != "" && strings.HasPrefix(object.id, ref) {
+			return object, nil
+		}
+	}
+	return nil, notFound("network %s not found", ref)
+}
+
+func (engine *Engine) networkResource(object *_Network) types.NetworkResource {
+	result := types.NetworkResource{
+		Name:       object.name,
+		ID:         object.id,
+		Created:    object.created,
+		Scope:      "local",
+		Driver:     object.driver,
+		Labels:     copyMap(object.labels),
+		Containers: map[string]types.EndpointResource{},
+	}
+	for _, item := range engine.containers {
+		if containsString(item.networks, object.name) {
+			result.Containers[item.id] = types.EndpointResource{Name: item.name}
+		}
+	}
+	return result
+}
+
+// NetworkCreate implements `client.NetworkAPIClient` interface.
+//
+// Fails if network with the same name exists.
+func (engine *Engine) NetworkCreate(
+	ctx context.Context, name string, options types.NetworkCreate,
+) (types.NetworkCreateResponse, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	if _, err := engine.findNetwork(name); err == nil {
+		return types.NetworkCreateResponse{}, conflict("network with name %s already exists", name)
+	}
+	driver := options.Driver
+	if driver == "" {
+		driver = defaultNetwork
+	}
+	object := &_Network{
+		id:      engine.generateID(),
+		name:    name,
+		driver:  driver,
+		created: time.Now(),
+		labels:  copyMap(options.Labels),
+	}
+	engine.networks = append(engine.networks, object)
+	return types.NetworkCreateResponse{ID: object.id}, nil
+}
+
+// NetworkConnect implements `client.NetworkAPIClient` interface.
+func (engine *Engine) NetworkConnect(
+	ctx context.Context, networkID, containerID string, config *network.EndpointSettings,
+) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findNetwork(networkID)
+	if err != nil {
+		return err
+	}
+	item, err := engine.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+	if containsString(item.networks, object.name) {
+		return forbidden("endpoint with name %s already exists in network %s", item.name, object.name)
+	}
+	item.networks = append(item.networks, object.name)
+	return nil
+}
+
+// NetworkDisconnect implements `client.NetworkAPIClient` interface.
+func (engine *Engine) NetworkDisconnect(ctx context.Context, networkID, containerID string, force bool) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findNetwork(networkID)
+	if err != nil {
+		return err
+	}
+	item, err := engine.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+	if !containsString(item.networks, object.name) {
+		return forbidden("container %s is not connected to network %s", item.id, object.name)
+	}
+	item.networks = removeString(item.networks, object.name)
+	return nil
+}
+
+// NetworkInspect implements `client.NetworkAPIClient` interface.
+func (engine *Engine) NetworkInspect(
+	ctx context.Context, networkID string, options types.NetworkInspectOptions,
+) (types.NetworkResource, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findNetwork(networkID)
+	if err != nil {
+		return types.NetworkResource{}, err
+	}
+	return engine.networkResource(object), nil
+}
+
+// NetworkInspectWithRaw implements `client.NetworkAPIClient` interface.
+func (engine *Engine) NetworkInspectWithRaw(
+	ctx context.Context, networkID string, options types.NetworkInspectOptions,
+) (types.NetworkResource, []byte, error) {
+	info, err := engine.NetworkInspect(ctx, networkID, options)
+	if err != nil {
+		return info, nil, err
+	}
+	raw, err := json.Marshal(info)
+	return info, raw, err
+}
+
+// NetworkList implements `client.NetworkAPIClient` interface.
+//
+// Supports "name" and "label" filters.
+func (engine *Engine) NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	var result []types.NetworkResource
+	for _, object := range engine.networks {
+		if options.Filters.Contains("name") && !options.Filters.Match("name", object.name) {
+			continue
+		}
+		if options.Filters.Contains("label") && !options.Filters.MatchKVList("label", object.labels) {
+			continue
+		}
+		result = append(result, engine.networkResource(object))
+	}
+	return result, nil
+}
+
+// NetworkRemove implements `client.NetworkAPIClient` interface.
+//
+// Fails if network has connected containers.
+func (engine *Engine) NetworkRemove(ctx context.Context, networkID string) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findNetwork(networkID)
+	if err != nil {
+		return err
+	}
+	if object.name == defaultNetwork || object.name == "host" || object.name == "none" {
+		return forbidden("%s is a pre-defined network and cannot be removed", object.name)
+	}
+	if len(engine.networkResource(object).Containers) > 0 {
+		return forbidden("error while removing network: network %s id %s has active endpoints", object.name, object.id)
+	}
+	engine.removeNetwork(object)
+	return nil
+}
+
+func (engine *Engine) removeNetwork(object *_Network) {
+	var kept []*_Network
+	for _, item := range engine.networks {
+		if item != object {
+			kept = append(kept, item)
+		}
+	}
+	engine.networks = kept
+}
+
+// NetworksPrune implements `client.NetworkAPIClient` interface.
+//
+// Removes user defined networks without containers.
+func (engine *Engine) NetworksPrune(ctx context.Context, pruneFilter filters.Args) (types.NetworksPruneReport, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	var report types.NetworksPruneReport
+	for _, object := range append([]*_Network(nil), engine.networks...) {
+		if object.name == defaultNetwork || object.name == "host" || object.name == "none" {
+			continue
+		}
+		if len(engine.networkResource(object).Containers) == 0 {
+			engine.removeNetwork(object)
+			report.NetworksDeleted = append(report.NetworksDeleted, object.name)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,169 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/api/types/volume"
+)
+
+func (engine *Engine) findVolume(name string) (*_Volume, error) {
+	for _, object := range engine.volumes {
+		if object.name == name {
+			return object, nil
+		}
+	}
+	return nil, notFound("get %s: no such volume", name)
+}
+
+func (engine *Engine) volumeUsers(object *_Volume) []*_Container {
+	var result []*_Container
+	for _, item := range engine.containers {
+		for _, mountPoint := range item.hostConfig.Mounts {
+			if mountPoint.Type == mount.TypeVolume && mountPoint.Source == object.name {
+				result = append(result, item)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func (object *_Volume) volume() volume.Volume {
+	return volume.Volume{
+		CreatedAt:  formatTime(object.created),
+		Driver:     object.driver,
+		Labels:     copyMap(object.labels),
+		Mountpoint: "/var/lib/docker/volumes/" + object.name + "/_data",
+		Name:       object.name,
+		Options:    copyMap(object.options),
+		Scope:      "local",
+	}
+}
+
+// VolumeCreate implements `client.VolumeAPIClient` interface.
+//
+// Returns existing volume if volume with the same name exists.
+func (engine *Engine) VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	name := options.Name
+	if name == "" {
+		name = engine.generateID()
+	}
+	if object, err := engine.findVolume(name); err == nil {
+		return object.volume(), nil
+	}
+	driver := options.Driver
+	if driver == "" {
+		driver = "local"
+	}
+	object := &_Volume{
+		name:    name,
+		driver:  driver,
+		created: time.Now(),
+		labels:  copyMap(options.Labels),
+		options: copyMap(options.DriverOpts),
+	}
+	engine.volumes = append(engine.volumes, object)
+	return object.volume(), nil
+}
+
+// VolumeInspect implements `client.VolumeAPIClient` interface.
+func (engine *Engine) VolumeInspect(ctx context.Context, volumeID string) (volume.Volume, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findVolume(volumeID)
+	if err != nil {
+		return volume.Volume{}, err
+	}
+	return object.volume(), nil
+}
+
+// VolumeInspectWithRaw implements `client.VolumeAPIClient` interface.
+func (engine *Engine) VolumeInspectWithRaw(ctx context.Context, volumeID string) (volume.Volume, []byte, error) {
+	info, err := engine.VolumeInspect(ctx, volumeID)
+	if err != nil {
+		return info, nil, err
+	}
+	raw, err := json.Marshal(info)
+	return info, raw, err
+}
+
+// VolumeList implements `client.VolumeAPIClient` interface.
+//
+// Supports "name" and "label" filters.
+func (engine *Engine) VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	var result volume.ListResponse
+	for _, object := range engine.volumes {
+		if options.Filters.Contains("name") && !options.Filters.Match("name", object.name) {
+			continue
+		}
+		if options.Filters.Contains("label") && !options.Filters.MatchKVList("label", object.labels) {
+			continue
+		}
+		info := object.volume()
+		result.Volumes = append(result.Volumes, &info)
+	}
+	return result, nil
+}
+
+// VolumeRemove implements `client.VolumeAPIClient` interface.
+//
+// Fails if volume is used by containers.
+func (engine *Engine) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	object, err := engine.findVolume(volumeID)
+	if err != nil {
+		if force {
+			return nil
+		}
+		return err
+	}
+	if users := engine.volumeUsers(object); len(users) > 0 {
+		return conflict("remove %s: volume is in use - [%s]", object.name, users[0].id)
+	}
+	engine.removeVolume(object)
+	return nil
+}
+
+func (engine *Engine) removeVolume(object *_Volume) {
+	var kept []*_Volume
+	for _, item := range engine.volumes {
+		if item != object {
+			kept = append(kept, item)
+		}
+	}
+	engine.volumes = kept
+}
+
+// VolumesPrune implements `client.VolumeAPIClient` interface.
+//
+// Removes volumes that are not used by containers.
+func (engine *Engine) VolumesPrune(ctx context.Context, pruneFilter filters.Args) (types.VolumesPruneReport, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	var report types.VolumesPruneReport
+	for _, object := range append([]*_Volume(nil), engine.volumes...) {
+		if len(engine.volumeUsers(object)) == 0 {
+			engine.removeVolume(object)
+			report.VolumesDeleted = append(report.VolumesDeleted, object.name)
+		}
+	}
+	return report, nil
+}
+
+// VolumeUpdate implements `client.VolumeAPIClient` interface. Not supported.
+func (engine *Engine) VolumeUpdate(
+	ctx context.Context, volumeID string, version swarm.Version, options volume.UpdateOptions,
+) error {
+	return notImplemented("VolumeUpdate")
+}
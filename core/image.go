@@ -2,6 +2,7 @@ package core
 
 import (
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 )
@@ -19,6 +20,9 @@ type Image interface {
 	FullName() string
 	Name() string
 	Tag() string
+	RepoTags() []string
+	Size() int64
+	Created() time.Time
 }
 
 type _Image struct {
@@ -77,6 +81,27 @@ func (image *_Image) Tag() string {
 	return takeImageTag(image.FullName())
 }
 
+// RepoTags returns all repo:tag pairs of image.
+//
+//	image.RepoTags() -> []string{"my-image:1", "my-image:latest"}
+func (image *_Image) RepoTags() []string {
+	return image.object.RepoTags
+}
+
+// Size returns image size in bytes.
+//
+//	image.Size() -> 1024
+func (image *_Image) Size() int64 {
+	return image.object.Size
+}
+
+// Created returns image creation time.
+//
+//	image.Created() -> time.Time
+func (image *_Image) Created() time.Time {
+	return time.Unix(image.object.Created, 0)
+}
+
 func makeImage(object *types.ImageSummary) Image {
 	return &_Image{object}
 }
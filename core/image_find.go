@@ -4,15 +4,15 @@ import (
 	"strings"
 
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 )
 
 // FindImageByID searches image by full id.
 //
 // `id` is a full (64 characters) identifier with "sha256:" prefix.
+// Returns ErrNotFound if there is no such image.
 //
 //	FindImageByID(cli, "sha256:<guid>") -> image
-func FindImageByID(cli client.ImageAPIClient, id string) (Image, error) {
+func FindImageByID(cli ImageRuntime, id string) (Image, error) {
 	images, err := cliImageList(cli)
 	if err != nil {
 		return nil, err
@@ -22,27 +22,37 @@ func FindImageByID(cli client.ImageAPIClient, id string) (Image, error) {
 			return makeImage(&images[i]), nil
 		}
 	}
-	return nil, nil
+	return nil, notFound("image", id)
 }
 
 // FindImageByShortID searches image by short id.
 //
 // Adds "sha256:" prefix and uses `string.HasPrefix` to compare identifiers.
 // Any substring of actual identifier can be passed.
+// Returns AmbiguousIDError if several images match.
 //
 //	FindImageByShortID(cli, "1234") -> &image
-func FindImageByShortID(cli client.ImageAPIClient, id string) (Image, error) {
+func FindImageByShortID(cli ImageRuntime, id string) (Image, error) {
 	images, err := cliImageList(cli)
 	if err != nil {
 		return nil, err
 	}
 	val := imageIDPrefix + id
+	var objects []*types.ImageSummary
 	for i, image := range images {
 		if strings.HasPrefix(image.ID, val) {
-			return makeImage(&images[i]), nil
+			objects = append(objects, &images[i])
 		}
 	}
-	return nil, nil
+	if len(objects) > 1 {
+		return nil, &AmbiguousIDError{id, TransformSlice(objects, func(object *types.ImageSummary) string {
+			return object.ID
+		})}
+	}
+	if len(objects) == 0 {
+		return nil, notFound("image", id)
+	}
+	return makeImage(objects[0]), nil
 }
 
 func normalizeImageName(name string) string {
@@ -55,9 +65,10 @@ func normalizeImageName(name string) string {
 // FindImageByName searches image by repo:tag.
 //
 // If tag is not provided then ":latest" is assumed.
+// Returns ErrNotFound if there is no such image.
 //
 //	FindImageByName(cli, "my-image:1") -> image
-func FindImageByName(cli client.ImageAPIClient, name string) (Image, error) {
+func FindImageByName(cli ImageRuntime, name string) (Image, error) {
 	images, err := cliImageList(cli)
 	if err != nil {
 		return nil, err
@@ -70,7 +81,7 @@ func FindImageByName(cli client.ImageAPIClient, name string) (Image, error) {
 			}
 		}
 	}
-	return nil, nil
+	return nil, notFound("image", targetName)
 }
 
 // FindAllImagesByName searches images by repo.
@@ -78,7 +89,7 @@ func FindImageByName(cli client.ImageAPIClient, name string) (Image, error) {
 // Finds all images with matching repository name.
 //
 //	FindAllImagesByName(cli, "my-image") -> []image
-func FindAllImagesByName(cli client.ImageAPIClient, repo string) ([]Image, error) {
+func FindAllImagesByName(cli ImageRuntime, repo string) ([]Image, error) {
 	images, err := cliImageList(cli)
 	if err != nil {
 		return nil, err
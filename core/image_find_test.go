@@ -44,7 +44,7 @@ func TestFindImage(t *testing.T) {
 
 	t.Run("ByID / not found", func(t *testing.T) {
 		image, err := FindImageByID(cli, "unknown")
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, ErrNotFound)
 		assert.Nil(t, image)
 	})
 
@@ -56,7 +56,15 @@ func TestFindImage(t *testing.T) {
 
 	t.Run("ByShortID / not found", func(t *testing.T) {
 		image, err := FindImageByShortID(cli, "unknown")
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, image)
+	})
+
+	t.Run("ByShortID / ambiguous", func(t *testing.T) {
+		image, err := FindImageByShortID(cli, "")
+		var ambiguousErr *AmbiguousIDError
+		assert.ErrorAs(t, err, &ambiguousErr)
+		assert.Len(t, ambiguousErr.Candidates(), 4)
 		assert.Nil(t, image)
 	})
 
@@ -74,7 +82,7 @@ func TestFindImage(t *testing.T) {
 
 	t.Run("ByRepoTag / not found", func(t *testing.T) {
 		image, err := FindImageByName(cli, "unknown")
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, ErrNotFound)
 		assert.Nil(t, image)
 	})
 
@@ -2,13 +2,12 @@ package core
 
 import (
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 )
 
 // ListAllImageIDs returns all images ids.
 //
 //	ListAllImageIDs(cli) -> []string
-func ListAllImageIDs(cli client.ImageAPIClient) ([]string, error) {
+func ListAllImageIDs(cli ImageRuntime) ([]string, error) {
 	images, err := cliImageList(cli)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,11 @@
+package core
+
+// RemoveImage removes image tag or image.
+//
+// `ref` is repo:tag or image id. Removing repo:tag untags image; image is deleted when its last tag is removed.
+// Images used by containers are not removed (ErrConflict is returned).
+//
+//	RemoveImage(cli, "my-image:1") -> err
+func RemoveImage(cli ImageRuntime, ref string) error {
+	return cliImageRemove(cli, ref)
+}
@@ -0,0 +1,28 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DmitryBogomolov/containerator/test_mocks"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRemoveImage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cli := test_mocks.NewMockImageAPIClient(ctrl)
+	cli.EXPECT().ImageRemove(gomock.Any(), "test:1", types.ImageRemoveOptions{}).
+		Return([]image.DeleteResponse{{Untagged: "test:1"}}, nil)
+	cli.EXPECT().ImageRemove(gomock.Any(), "test:2", types.ImageRemoveOptions{}).
+		Return(nil, errdefs.Conflict(errors.New("image is being used by running container")))
+
+	assert.NoError(t, RemoveImage(cli, "test:1"))
+	assert.ErrorIs(t, RemoveImage(cli, "test:2"), ErrConflict)
+}
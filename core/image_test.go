@@ -48,3 +48,10 @@ func TestImage_Tag(t *testing.T) {
 	image2 := testImage("", "a:1", "b:2")
 	assert.Equal(t, "1", image2.Tag(), "take first repo tag")
 }
+
+func TestImage_SizeCreated(t *testing.T) {
+	image := &_Image{&types.ImageSummary{Size: 1024, Created: 1710000000, RepoTags: []string{"a:1", "a:latest"}}}
+	assert.Equal(t, int64(1024), image.Size())
+	assert.Equal(t, int64(1710000000), image.Created().Unix())
+	assert.Equal(t, []string{"a:1", "a:latest"}, image.RepoTags())
+}
@@ -1,24 +1,124 @@
 package core
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
 
 // Mapping stores key-value pair. Used for volumes, ports, environment variables.
+//
+// Can be unmarshaled from single key map ({"9001": "80"}) or from string ("9001:80", "A=1").
+// Always marshaled as single key map.
 type Mapping struct {
 	Source string
 	Target string
 }
 
+var errMultipleKeys = errors.New("mapping must contain single key-value pair")
+
+/*
+ParseMapping parses mapping from string.
+
+Source and target are separated with the first ":" or "=". String without separator is taken as source.
+Host IP of port mapping ("127.0.0.1:9001:80", "[::1]:9001:80") and Windows drive of volume source ("C:\data:/data")
+are kept in source.
+
+	ParseMapping("9001:80") -> Mapping{"9001", "80"}
+	ParseMapping("127.0.0.1:9001:80") -> Mapping{"127.0.0.1:9001", "80"}
+	ParseMapping("./html:/usr/share/nginx/html:ro") -> Mapping{"./html", "/usr/share/nginx/html:ro"}
+	ParseMapping("A=1") -> Mapping{"A", "1"}
+*/
+func ParseMapping(value string) (Mapping, error) {
+	if value == "" {
+		return Mapping{}, errors.New("empty mapping")
+	}
+	idx := findMappingSeparator(value)
+	if idx < 0 {
+		return Mapping{Source: value}, nil
+	}
+	return Mapping{Source: value[:idx], Target: value[idx+1:]}, nil
+}
+
+func isWindowsDrive(value string) bool {
+	if len(value) < 3 || value[1] != ':' || (value[2] != '\\' && value[2] != '/') {
+		return false
+	}
+	letter := value[0] | 0x20
+	return letter >= 'a' && letter <= 'z'
+}
+
+// findMappingSeparator returns index of separator between source and target or -1.
+func findMappingSeparator(value string) int {
+	start := 0
+	if isWindowsDrive(value) {
+		start = 2
+	} else if strings.HasPrefix(value, "[") {
+		if end := strings.Index(value, "]:"); end >= 0 {
+			start = end + 2
+		}
+	}
+	idx := strings.IndexAny(value[start:], ":=")
+	if idx < 0 {
+		return -1
+	}
+	idx += start
+	if value[idx] == ':' && start == 0 && net.ParseIP(value[:idx]) != nil {
+		if next := strings.IndexByte(value[idx+1:], ':'); next >= 0 {
+			return idx + 1 + next
+		}
+	}
+	return idx
+}
+
+// SplitHostIP separates host IP from host port of port mapping source.
+//
+//	SplitHostIP("9001") -> "", "9001"
+//	SplitHostIP("127.0.0.1:9001") -> "127.0.0.1", "9001"
+//	SplitHostIP("[::1]:9001") -> "::1", "9001"
+func SplitHostIP(source string) (string, string) {
+	if !strings.Contains(source, ":") {
+		return "", source
+	}
+	ip, port, err := net.SplitHostPort(source)
+	if err != nil {
+		return "", source
+	}
+	return ip, port
+}
+
 func (mapping Mapping) toMap() map[string]string {
 	ret := map[string]string{}
 	ret[mapping.Source] = mapping.Target
 	return ret
 }
 
-func (mapping *Mapping) fromMap(data map[string]string) {
+func (mapping *Mapping) fromMap(data map[string]string) error {
+	if len(data) > 1 {
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("%w, got %s", errMultipleKeys, strings.Join(keys, ", "))
+	}
 	for key, val := range data {
 		mapping.Source = key
 		mapping.Target = val
 	}
+	return nil
+}
+
+func (mapping *Mapping) fromString(value string) error {
+	result, err := ParseMapping(value)
+	if err != nil {
+		return err
+	}
+	*mapping = result
+	return nil
 }
 
 // MarshalJSON implements `json.Marshaler` interface.
@@ -28,12 +128,28 @@ func (mapping Mapping) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON implements `json.Unmarshaler` interface.
 func (mapping *Mapping) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return mapping.fromString(text)
+	}
 	var tmp map[string]string
 	if err := json.Unmarshal(data, &tmp); err != nil {
 		return err
 	}
-	mapping.fromMap(tmp)
-	return nil
+	return mapping.fromMap(tmp)
+}
+
+// MarshalTOML implements `toml.Marshaler` interface; mapping is written as inline table.
+func (mapping Mapping) MarshalTOML() ([]byte, error) {
+	key, err := json.Marshal(mapping.Source)
+	if err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(mapping.Target)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("{%s = %s}", key, value)), nil
 }
 
 // MarshalYAML implements `yaml.Marshaler` interface.
@@ -43,10 +159,13 @@ func (mapping Mapping) MarshalYAML() (interface{}, error) {
 
 // UnmarshalYAML implements `yaml.Unmarshaler` interface.
 func (mapping *Mapping) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var text string
+	if err := unmarshal(&text); err == nil {
+		return mapping.fromString(text)
+	}
 	var tmp map[string]string
 	if err := unmarshal(&tmp); err != nil {
 		return err
 	}
-	mapping.fromMap(tmp)
-	return nil
+	return mapping.fromMap(tmp)
 }
@@ -48,4 +48,104 @@ func TestMapping(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, Mapping{Source: "src", Target: "dst"}, mapping)
 	})
+	t.Run("JSONUnmarshalString", func(t *testing.T) {
+		data := `["9001:80", "./html:/usr/share/nginx/html:ro", "A=1", "B=x:y", "C"]`
+		var mappings []Mapping
+
+		err := json.Unmarshal([]byte(data), &mappings)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Mapping{
+			{Source: "9001", Target: "80"},
+			{Source: "./html", Target: "/usr/share/nginx/html:ro"},
+			{Source: "A", Target: "1"},
+			{Source: "B", Target: "x:y"},
+			{Source: "C"},
+		}, mappings)
+	})
+
+	t.Run("YAMLUnmarshalString", func(t *testing.T) {
+		data := "- 9001:80\n- ./html:/usr/share/nginx/html:ro\n- A=1\n- src: dst\n"
+		var mappings []Mapping
+
+		err := yaml.Unmarshal([]byte(data), &mappings)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Mapping{
+			{Source: "9001", Target: "80"},
+			{Source: "./html", Target: "/usr/share/nginx/html:ro"},
+			{Source: "A", Target: "1"},
+			{Source: "src", Target: "dst"},
+		}, mappings)
+	})
+
+	t.Run("Multiple keys", func(t *testing.T) {
+		var mapping Mapping
+
+		errJSON := json.Unmarshal([]byte(`{"b": "2", "a": "1"}`), &mapping)
+		errYAML := yaml.Unmarshal([]byte("b: 2\na: 1"), &mapping)
+
+		assert.EqualError(t, errJSON, "mapping must contain single key-value pair, got a, b")
+		assert.EqualError(t, errYAML, "mapping must contain single key-value pair, got a, b")
+	})
+
+	t.Run("Empty string", func(t *testing.T) {
+		var mapping Mapping
+
+		err := json.Unmarshal([]byte(`""`), &mapping)
+
+		assert.EqualError(t, err, "empty mapping")
+	})
+
+	t.Run("MarshalString", func(t *testing.T) {
+		var mappings []Mapping
+		json.Unmarshal([]byte(`["9001:80"]`), &mappings)
+
+		bytesJSON, _ := json.Marshal(mappings)
+		bytesYAML, _ := yaml.Marshal(mappings)
+
+		assert.Equal(t, `[{"9001":"80"}]`, string(bytesJSON))
+		assert.Equal(t, "- \"9001\": \"80\"\n", string(bytesYAML))
+	})
+}
+
+func TestParseMapping(t *testing.T) {
+	for _, item := range []struct {
+		value   string
+		mapping Mapping
+	}{
+		{"9001:80", Mapping{"9001", "80"}},
+		{"127.0.0.1:9001:80", Mapping{"127.0.0.1:9001", "80"}},
+		{"127.0.0.1::80/udp", Mapping{"127.0.0.1:", "80/udp"}},
+		{"[::1]:9001:80", Mapping{"[::1]:9001", "80"}},
+		{"./html:/usr/share/nginx/html:ro", Mapping{"./html", "/usr/share/nginx/html:ro"}},
+		{`C:\data:/data:ro`, Mapping{`C:\data`, "/data:ro"}},
+		{"C:/data", Mapping{"C:/data", ""}},
+		{"A=1", Mapping{"A", "1"}},
+		{"B=x:y", Mapping{"B", "x:y"}},
+	} {
+		mapping, err := ParseMapping(item.value)
+
+		assert.NoError(t, err, item.value)
+		assert.Equal(t, item.mapping, mapping, item.value)
+	}
+}
+
+func TestSplitHostIP(t *testing.T) {
+	for _, item := range []struct {
+		source string
+		ip     string
+		port   string
+	}{
+		{"9001", "", "9001"},
+		{"9001-9002", "", "9001-9002"},
+		{"127.0.0.1:9001", "127.0.0.1", "9001"},
+		{"127.0.0.1:", "127.0.0.1", ""},
+		{"[::1]:9001", "::1", "9001"},
+	} {
+		ip, port := SplitHostIP(item.source)
+
+		assert.Equal(t, item.ip, ip, item.source)
+		assert.Equal(t, item.port, port, item.source)
+	}
 }
@@ -0,0 +1,598 @@
+package podman
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type _PortMapping struct {
+	HostIP        string `json:"host_ip,omitempty"`
+	ContainerPort uint16 `json:"container_port"`
+	HostPort      uint16 `json:"host_port,omitempty"`
+	Range         uint16 `json:"range,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+type _ListContainer struct {
+	ID      string            `json:"Id"`
+	Names   []string          `json:"Names"`
+	Image   string            `json:"Image"`
+	ImageID string            `json:"ImageID"`
+	Command []string          `json:"Command"`
+	Created json.RawMessage   `json:"Created"`
+	State   string            `json:"State"`
+	Status  string            `json:"Status"`
+	Labels  map[string]string `json:"Labels"`
+	Ports   []_PortMapping    `json:"Ports"`
+}
+
+type _Mount struct {
+	Destination string   `json:"destination"`
+	Source      string   `json:"source"`
+	Type        string   `json:"type"`
+	Options     []string `json:"options,omitempty"`
+}
+
+type _Namespace struct {
+	NSMode string `json:"nsmode"`
+}
+
+type _SpecGenerator struct {
+	Name          string              `json:"name,omitempty"`
+	Image         string              `json:"image"`
+	Command       []string            `json:"command,omitempty"`
+	Env           map[string]string   `json:"env,omitempty"`
+	Labels        map[string]string   `json:"labels,omitempty"`
+	PortMappings  []_PortMapping      `json:"portmappings,omitempty"`
+	Mounts        []_Mount            `json:"mounts,omitempty"`
+	RestartPolicy string              `json:"restart_policy,omitempty"`
+	RestartTries  *uint               `json:"restart_tries,omitempty"`
+	StopSignal    *int                `json:"stop_signal,omitempty"`
+	StopTimeout   *uint               `json:"stop_timeout,omitempty"`
+	NetNS         *_Namespace         `json:"netns,omitempty"`
+	Networks      map[string]struct{} `json:"networks,omitempty"`
+}
+
+type _CreateResponse struct {
+	ID       string   `json:"Id"`
+	Warnings []string `json:"Warnings"`
+}
+
+type _InspectState struct {
+	Status     string        `json:"Status"`
+	Running    bool          `json:"Running"`
+	Paused     bool          `json:"Paused"`
+	Restarting bool          `json:"Restarting"`
+	OOMKilled  bool          `json:"OOMKilled"`
+	Dead       bool          `json:"Dead"`
+	Pid        int           `json:"Pid"`
+	ExitCode   int           `json:"ExitCode"`
+	Error      string        `json:"Error"`
+	StartedAt  string        `json:"StartedAt"`
+	FinishedAt string        `json:"FinishedAt"`
+	Health     *types.Health `json:"Health,omitempty"`
+}
+
+type _InspectHostPort struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+type _InspectContainer struct {
+	ID           string        `json:"Id"`
+	Created      string        `json:"Created"`
+	Path         string        `json:"Path"`
+	Args         []string      `json:"Args"`
+	State        _InspectState `json:"State"`
+	Image        string        `json:"Image"`
+	ImageName    string        `json:"ImageName"`
+	Name         string        `json:"Name"`
+	RestartCount int           `json:"RestartCount"`
+	Config       struct {
+		Hostname    string            `json:"Hostname"`
+		Env         []string          `json:"Env"`
+		Cmd         []string          `json:"Cmd"`
+		Labels      map[string]string `json:"Labels"`
+		StopSignal  string            `json:"StopSignal"`
+		StopTimeout uint              `json:"StopTimeout"`
+	} `json:"Config"`
+	HostConfig struct {
+		NetworkMode   string                        `json:"NetworkMode"`
+		PortBindings  map[string][]_InspectHostPort `json:"PortBindings"`
+		RestartPolicy struct {
+			Name              string `json:"Name"`
+			MaximumRetryCount int    `json:"MaximumRetryCount"`
+		} `json:"RestartPolicy"`
+	} `json:"HostConfig"`
+	Mounts []struct {
+		Type        string `json:"Type"`
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+		RW          bool   `json:"RW"`
+	} `json:"Mounts"`
+	NetworkSettings struct {
+		Ports map[string][]_InspectHostPort `json:"Ports"`
+	} `json:"NetworkSettings"`
+}
+
+func containerPath(containerID string, action string) string {
+	path := "/containers/" + url.PathEscape(containerID)
+	if action != "" {
+		path += "/" + action
+	}
+	return path
+}
+
+// encodeFilters converts filters to libpod format.
+//
+//	encodeFilters(filters.NewArgs(filters.Arg("label", "a=1"))) -> `{"label":["a=1"]}`
+func encodeFilters(args filters.Args) string {
+	if args.Len() == 0 {
+		return ""
+	}
+	result := map[string][]string{}
+	for _, key := range args.Keys() {
+		result[key] = args.Get(key)
+	}
+	data, _ := json.Marshal(result)
+	return string(data)
+}
+
+// parseCreated takes creation time either as unix timestamp or as RFC 3339 string.
+func parseCreated(raw json.RawMessage) int64 {
+	var timestamp int64
+	if json.Unmarshal(raw, &timestamp) == nil {
+		return timestamp
+	}
+	var str string
+	if json.Unmarshal(raw, &str) == nil {
+		if value, err := time.Parse(time.RFC3339Nano, str); err == nil {
+			return value.Unix()
+		}
+	}
+	return 0
+}
+
+func convertListContainer(object *_ListContainer) types.Container {
+	result := types.Container{
+		ID:      object.ID,
+		Image:   shortenReference(object.Image),
+		ImageID: normalizeID(object.ImageID),
+		Command: strings.Join(object.Command, " "),
+		Created: parseCreated(object.Created),
+		Labels:  object.Labels,
+		State:   object.State,
+		Status:  object.Status,
+	}
+	for _, name := range object.Names {
+		result.Names = append(result.Names, "/"+name)
+	}
+	for _, port := range object.Ports {
+		count := port.Range
+		if count == 0 {
+			count = 1
+		}
+		for i := uint16(0); i < count; i++ {
+			result.Ports = append(result.Ports, types.Port{
+				IP:          port.HostIP,
+				PrivatePort: port.ContainerPort + i,
+				PublicPort:  port.HostPort + i,
+				Type:        port.Protocol,
+			})
+		}
+	}
+	return result
+}
+
+// ContainerList returns containers.
+func (runtime *Runtime) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	query := url.Values{}
+	if options.All {
+		query.Set("all", "true")
+	}
+	if encoded := encodeFilters(options.Filters); encoded != "" {
+		query.Set("filters", encoded)
+	}
+	var list []_ListContainer
+	if err := runtime.do(ctx, http.MethodGet, "/containers/json", query, nil, &list); err != nil {
+		return nil, err
+	}
+	result := make([]types.Container, len(list))
+	for i := range list {
+		result[i] = convertListContainer(&list[i])
+	}
+	return result, nil
+}
+
+func buildNetwork(spec *_SpecGenerator, mode container.NetworkMode) {
+	switch {
+	case mode == "" || mode.IsDefault() || mode.IsBridge():
+	case mode.IsHost():
+		spec.NetNS = &_Namespace{NSMode: "host"}
+	case mode.IsNone():
+		spec.NetNS = &_Namespace{NSMode: "none"}
+	default:
+		spec.NetNS = &_Namespace{NSMode: "bridge"}
+		spec.Networks = map[string]struct{}{mode.NetworkName(): {}}
+	}
+}
+
+func buildPortMappings(bindings nat.PortMap) []_PortMapping {
+	var result []_PortMapping
+	for port, items := range bindings {
+		for _, item := range items {
+			hostPort, _ := strconv.ParseUint(item.HostPort, 10, 16)
+			result = append(result, _PortMapping{
+				HostIP:        item.HostIP,
+				ContainerPort: uint16(port.Int()),
+				HostPort:      uint16(hostPort),
+				Protocol:      port.Proto(),
+			})
+		}
+	}
+	return result
+}
+
+// signalNumbers maps signal names to Linux numbers; spec takes signal as number.
+var signalNumbers = map[string]int{
+	"SIGHUP":    1,
+	"SIGINT":    2,
+	"SIGQUIT":   3,
+	"SIGILL":    4,
+	"SIGTRAP":   5,
+	"SIGABRT":   6,
+	"SIGIOT":    6,
+	"SIGBUS":    7,
+	"SIGFPE":    8,
+	"SIGKILL":   9,
+	"SIGUSR1":   10,
+	"SIGSEGV":   11,
+	"SIGUSR2":   12,
+	"SIGPIPE":   13,
+	"SIGALRM":   14,
+	"SIGTERM":   15,
+	"SIGSTKFLT": 16,
+	"SIGCHLD":   17,
+	"SIGCLD":    17,
+	"SIGCONT":   18,
+	"SIGSTOP":   19,
+	"SIGTSTP":   20,
+	"SIGTTIN":   21,
+	"SIGTTOU":   22,
+	"SIGURG":    23,
+	"SIGXCPU":   24,
+	"SIGXFSZ":   25,
+	"SIGVTALRM": 26,
+	"SIGPROF":   27,
+	"SIGWINCH":  28,
+	"SIGIO":     29,
+	"SIGPOLL":   29,
+	"SIGPWR":    30,
+	"SIGSYS":    31,
+}
+
+// Range of Linux real-time signals; they are named "SIGRTMIN+n" and "SIGRTMAX-n".
+const (
+	signalRTMin = 34
+	signalRTMax = 64
+)
+
+/*
+parseSignal returns number of signal given as name or number. Unknown signal is an error, as it is for docker.
+
+	parseSignal("SIGINT") -> 2, nil
+	parseSignal("int") -> 2, nil
+	parseSignal("RTMIN+2") -> 36, nil
+	parseSignal("2") -> 2, nil
+	parseSignal("SIGFOO") -> 0, err
+*/
+func parseSignal(signal string) (int, error) {
+	if number, err := strconv.Atoi(signal); err == nil {
+		if number <= 0 || number > signalRTMax {
+			return 0, errdefs.InvalidParameter(fmt.Errorf("bad signal '%s'", signal))
+		}
+		return number, nil
+	}
+	name := strings.ToUpper(signal)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	if number, ok := signalNumbers[name]; ok {
+		return number, nil
+	}
+	if number, ok := parseRealtimeSignal(name); ok {
+		return number, nil
+	}
+	return 0, errdefs.InvalidParameter(fmt.Errorf("unknown signal '%s'", signal))
+}
+
+// parseRealtimeSignal returns number of real-time signal: "SIGRTMIN", "SIGRTMIN+n", "SIGRTMAX-n".
+func parseRealtimeSignal(name string) (int, bool) {
+	base, sign := signalRTMin, "+"
+	rest, ok := trimPrefix(name, "SIGRTMIN")
+	if !ok {
+		base, sign = signalRTMax, "-"
+		if rest, ok = trimPrefix(name, "SIGRTMAX"); !ok {
+			return 0, false
+		}
+	}
+	if rest == "" {
+		return base, true
+	}
+	offset, ok := trimPrefix(rest, sign)
+	if !ok {
+		return 0, false
+	}
+	shift, err := strconv.Atoi(offset)
+	if err != nil || shift < 0 || shift > signalRTMax-signalRTMin {
+		return 0, false
+	}
+	if sign == "-" {
+		shift = -shift
+	}
+	return base + shift, true
+}
+
+func trimPrefix(str string, prefix string) (string, bool) {
+	if !strings.HasPrefix(str, prefix) {
+		return str, false
+	}
+	return str[len(prefix):], true
+}
+
+func buildSpec(config *container.Config, hostConfig *container.HostConfig, name string) (*_SpecGenerator, error) {
+	spec := _SpecGenerator{
+		Name:    name,
+		Image:   config.Image,
+		Command: config.Cmd,
+		Labels:  config.Labels,
+	}
+	for _, item := range config.Env {
+		key, value, _ := strings.Cut(item, "=")
+		if spec.Env == nil {
+			spec.Env = map[string]string{}
+		}
+		spec.Env[key] = value
+	}
+	if config.StopSignal != "" {
+		signal, err := parseSignal(config.StopSignal)
+		if err != nil {
+			return nil, err
+		}
+		spec.StopSignal = &signal
+	}
+	if config.StopTimeout != nil && *config.StopTimeout >= 0 {
+		timeout := uint(*config.StopTimeout)
+		spec.StopTimeout = &timeout
+	}
+	if hostConfig == nil {
+		return &spec, nil
+	}
+	spec.PortMappings = buildPortMappings(hostConfig.PortBindings)
+	for _, item := range hostConfig.Mounts {
+		mountItem := _Mount{Destination: item.Target, Source: item.Source, Type: string(item.Type)}
+		if item.Type == mount.TypeBind {
+			mountItem.Options = append(mountItem.Options, "rbind")
+		}
+		if item.ReadOnly {
+			mountItem.Options = append(mountItem.Options, "ro")
+		}
+		spec.Mounts = append(spec.Mounts, mountItem)
+	}
+	spec.RestartPolicy = string(hostConfig.RestartPolicy.Name)
+	if count := hostConfig.RestartPolicy.MaximumRetryCount; count > 0 {
+		tries := uint(count)
+		spec.RestartTries = &tries
+	}
+	buildNetwork(&spec, hostConfig.NetworkMode)
+	return &spec, nil
+}
+
+// ContainerCreate creates container.
+//
+// Networking config and platform are not supported; container is connected to network defined by host config.
+func (runtime *Runtime) ContainerCreate(
+	ctx context.Context, config *container.Config, hostConfig *container.HostConfig,
+	networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, name string,
+) (container.CreateResponse, error) {
+	spec, err := buildSpec(config, hostConfig, name)
+	if err != nil {
+		return container.CreateResponse{}, err
+	}
+	var body _CreateResponse
+	err = runtime.do(ctx, http.MethodPost, "/containers/create", nil, spec, &body)
+	if err != nil {
+		return container.CreateResponse{}, err
+	}
+	return container.CreateResponse{ID: body.ID, Warnings: body.Warnings}, nil
+}
+
+// ContainerStart starts container.
+func (runtime *Runtime) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	return runtime.do(ctx, http.MethodPost, containerPath(containerID, "start"), nil, nil, nil)
+}
+
+// ContainerStop stops container.
+func (runtime *Runtime) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	query := url.Values{}
+	if options.Timeout != nil {
+		query.Set("timeout", strconv.Itoa(*options.Timeout))
+	}
+	return runtime.do(ctx, http.MethodPost, containerPath(containerID, "stop"), query, nil, nil)
+}
+
+// ContainerRename renames container.
+func (runtime *Runtime) ContainerRename(ctx context.Context, containerID string, newName string) error {
+	query := url.Values{"name": {newName}}
+	return runtime.do(ctx, http.MethodPost, containerPath(containerID, "rename"), query, nil, nil)
+}
+
+// ContainerRemove removes container.
+func (runtime *Runtime) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	query := url.Values{}
+	if options.Force {
+		query.Set("force", "true")
+	}
+	if options.RemoveVolumes {
+		query.Set("v", "true")
+	}
+	return runtime.do(ctx, http.MethodDelete, containerPath(containerID, ""), query, nil, nil)
+}
+
+func convertPorts(ports map[string][]_InspectHostPort) nat.PortMap {
+	if ports == nil {
+		return nil
+	}
+	result := nat.PortMap{}
+	for port, items := range ports {
+		bindings := make([]nat.PortBinding, len(items))
+		for i, item := range items {
+			bindings[i] = nat.PortBinding{HostIP: item.HostIP, HostPort: item.HostPort}
+		}
+		result[nat.Port(port)] = bindings
+	}
+	return result
+}
+
+func convertInspectContainer(object *_InspectContainer) types.ContainerJSON {
+	state := object.State
+	result := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:      object.ID,
+			Created: object.Created,
+			Path:    object.Path,
+			Args:    object.Args,
+			State: &types.ContainerState{
+				Status:     state.Status,
+				Running:    state.Running,
+				Paused:     state.Paused,
+				Restarting: state.Restarting,
+				OOMKilled:  state.OOMKilled,
+				Dead:       state.Dead,
+				Pid:        state.Pid,
+				ExitCode:   state.ExitCode,
+				Error:      state.Error,
+				StartedAt:  state.StartedAt,
+				FinishedAt: state.FinishedAt,
+				Health:     state.Health,
+			},
+			Image:        normalizeID(object.Image),
+			Name:         "/" + object.Name,
+			RestartCount: object.RestartCount,
+			HostConfig: &container.HostConfig{
+				NetworkMode:  container.NetworkMode(object.HostConfig.NetworkMode),
+				PortBindings: convertPorts(object.HostConfig.PortBindings),
+				RestartPolicy: container.RestartPolicy{
+					Name:              container.RestartPolicyMode(object.HostConfig.RestartPolicy.Name),
+					MaximumRetryCount: object.HostConfig.RestartPolicy.MaximumRetryCount,
+				},
+			},
+		},
+		Config: &container.Config{
+			Hostname:   object.Config.Hostname,
+			Env:        object.Config.Env,
+			Cmd:        object.Config.Cmd,
+			Image:      shortenReference(object.ImageName),
+			Labels:     object.Config.Labels,
+			StopSignal: object.Config.StopSignal,
+		},
+		NetworkSettings: &types.NetworkSettings{
+			NetworkSettingsBase: types.NetworkSettingsBase{Ports: convertPorts(object.NetworkSettings.Ports)},
+		},
+	}
+	if object.Config.StopTimeout > 0 {
+		timeout := int(object.Config.StopTimeout)
+		result.Config.StopTimeout = &timeout
+	}
+	for _, item := range object.Mounts {
+		result.Mounts = append(result.Mounts, types.MountPoint{
+			Type:        mount.Type(item.Type),
+			Source:      item.Source,
+			Destination: item.Destination,
+			RW:          item.RW,
+		})
+	}
+	return result
+}
+
+// ContainerInspect returns container details.
+func (runtime *Runtime) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	var object _InspectContainer
+	if err := runtime.do(ctx, http.MethodGet, containerPath(containerID, "json"), nil, nil, &object); err != nil {
+		return types.ContainerJSON{}, err
+	}
+	return convertInspectContainer(&object), nil
+}
+
+type _ExecConfig struct {
+	Cmd []string `json:"Cmd"`
+	Env []string `json:"Env,omitempty"`
+}
+
+type _ExecCreateResponse struct {
+	ID string `json:"Id"`
+}
+
+type _ExecStartConfig struct {
+	Detach bool `json:"Detach"`
+}
+
+type _ExecInspect struct {
+	ID          string `json:"ID"`
+	ContainerID string `json:"ContainerID"`
+	Running     bool   `json:"Running"`
+	ExitCode    int    `json:"ExitCode"`
+	Pid         int    `json:"Pid"`
+}
+
+func execPath(execID string, action string) string {
+	return "/exec/" + url.PathEscape(execID) + "/" + action
+}
+
+// ContainerExecCreate creates exec session in container.
+func (runtime *Runtime) ContainerExecCreate(
+	ctx context.Context, containerID string, config types.ExecConfig,
+) (types.IDResponse, error) {
+	var body _ExecCreateResponse
+	spec := &_ExecConfig{Cmd: config.Cmd, Env: config.Env}
+	err := runtime.do(ctx, http.MethodPost, containerPath(containerID, "exec"), nil, spec, &body)
+	if err != nil {
+		return types.IDResponse{}, err
+	}
+	return types.IDResponse{ID: body.ID}, nil
+}
+
+// ContainerExecStart starts exec session.
+func (runtime *Runtime) ContainerExecStart(ctx context.Context, execID string, config types.ExecStartCheck) error {
+	return runtime.do(ctx, http.MethodPost, execPath(execID, "start"), nil, &_ExecStartConfig{Detach: config.Detach}, nil)
+}
+
+// ContainerExecInspect returns state of exec session.
+func (runtime *Runtime) ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	var body _ExecInspect
+	if err := runtime.do(ctx, http.MethodGet, execPath(execID, "json"), nil, nil, &body); err != nil {
+		return types.ContainerExecInspect{}, err
+	}
+	return types.ContainerExecInspect{
+		ExecID:      body.ID,
+		ContainerID: body.ContainerID,
+		Running:     body.Running,
+		ExitCode:    body.ExitCode,
+		Pid:         body.Pid,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package podman
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+)
+
+type _ImageRemoveReport struct {
+	Deleted  []string
+	Untagged []string
+}
+
+// ImageList returns images.
+func (runtime *Runtime) ImageList(ctx context.Context, options types.ImageListOptions) ([]types.ImageSummary, error) {
+	query := url.Values{}
+	if options.All {
+		query.Set("all", "true")
+	}
+	if encoded := encodeFilters(options.Filters); encoded != "" {
+		query.Set("filters", encoded)
+	}
+	var list []types.ImageSummary
+	if err := runtime.do(ctx, http.MethodGet, "/images/json", query, nil, &list); err != nil {
+		return nil, err
+	}
+	for i := range list {
+		image := &list[i]
+		image.ID = normalizeID(image.ID)
+		image.ParentID = normalizeID(image.ParentID)
+		for j, tag := range image.RepoTags {
+			image.RepoTags[j] = shortenReference(tag)
+		}
+	}
+	return list, nil
+}
+
+// ImageRemove removes image or untags it when image is referenced by repo:tag and has other tags.
+func (runtime *Runtime) ImageRemove(
+	ctx context.Context, imageID string, options types.ImageRemoveOptions,
+) ([]image.DeleteResponse, error) {
+	query := url.Values{}
+	if options.Force {
+		query.Set("force", "true")
+	}
+	ref := url.PathEscape(strings.TrimPrefix(imageID, "sha256:"))
+	var report _ImageRemoveReport
+	if err := runtime.do(ctx, http.MethodDelete, "/images/"+ref, query, nil, &report); err != nil {
+		return nil, err
+	}
+	var result []image.DeleteResponse
+	for _, tag := range report.Untagged {
+		result = append(result, image.DeleteResponse{Untagged: shortenReference(tag)})
+	}
+	for _, id := range report.Deleted {
+		result = append(result, image.DeleteResponse{Deleted: normalizeID(id)})
+	}
+	return result, nil
+}
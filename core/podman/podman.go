@@ -0,0 +1,168 @@
+/*
+Package podman implements core.Runtime on top of Podman libpod REST API.
+
+Libpod objects are converted to docker types so that core and manage functions work the same way as with docker.
+Image and container references are shortened to docker form ("docker.io/library/nginx:1" -> "nginx:1").
+
+	runtime, err := podman.NewRuntime("unix:///run/user/1000/podman/podman.sock")
+	manage.RunContainer(runtime, config, &manage.Options{Postfix: "dev"})
+*/
+package podman
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/docker/docker/errdefs"
+)
+
+// APIVersion is libpod API version used in request paths.
+const APIVersion = "v4.0.0"
+
+// EnvHost is environment variable that defines Podman service address.
+const EnvHost = "CONTAINER_HOST"
+
+// Runtime sends requests to Podman service.
+type Runtime struct {
+	client  *http.Client
+	baseURL string
+}
+
+var _ core.Runtime = (*Runtime)(nil)
+
+// DefaultHost returns Podman service address.
+//
+// Takes CONTAINER_HOST environment variable; otherwise returns rootless socket address
+// or rootful socket address for root user.
+//
+//	DefaultHost() -> "unix:///run/user/1000/podman/podman.sock"
+func DefaultHost() string {
+	if host := os.Getenv(EnvHost); host != "" {
+		return host
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && os.Geteuid() != 0 {
+		return "unix://" + filepath.Join(dir, "podman", "podman.sock")
+	}
+	return "unix:///run/podman/podman.sock"
+}
+
+// NewRuntime creates Runtime for Podman service.
+//
+// Supports "unix://", "tcp://", "http://" addresses. Empty address is replaced with DefaultHost.
+//
+//	NewRuntime("unix:///run/podman/podman.sock") -> &runtime, err
+//	NewRuntime("tcp://localhost:8080") -> &runtime, err
+func NewRuntime(host string) (*Runtime, error) {
+	if host == "" {
+		host = DefaultHost()
+	}
+	address, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("bad podman host '%s': %w", host, err)
+	}
+	switch address.Scheme {
+	case "unix":
+		socketPath := address.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		}
+		return &Runtime{client: &http.Client{Transport: transport}, baseURL: "http://d"}, nil
+	case "tcp", "http":
+		return &Runtime{client: &http.Client{}, baseURL: "http://" + address.Host}, nil
+	default:
+		return nil, fmt.Errorf("bad podman host '%s': unsupported scheme", host)
+	}
+}
+
+type _ErrorResponse struct {
+	Cause    string `json:"cause"`
+	Message  string `json:"message"`
+	Response int    `json:"response"`
+}
+
+func (runtime *Runtime) do(
+	ctx context.Context, method string, path string, query url.Values, body any, result any,
+) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	target := runtime.baseURL + "/" + APIVersion + "/libpod" + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := runtime.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return readError(res)
+	}
+	if result == nil || res.StatusCode == http.StatusNoContent || res.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+// readError converts libpod error to errdefs error so that it is recognized by core.
+func readError(res *http.Response) error {
+	data, _ := io.ReadAll(res.Body)
+	var body _ErrorResponse
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Message != "" {
+		message = body.Message
+	}
+	if message == "" {
+		message = http.StatusText(res.StatusCode)
+	}
+	return errdefs.FromStatusCode(errors.New(message), res.StatusCode)
+}
+
+// shortenReference converts fully qualified image reference to docker form.
+//
+//	shortenReference("docker.io/library/nginx:1") -> "nginx:1"
+//	shortenReference("localhost/my-image:1") -> "my-image:1"
+func shortenReference(name string) string {
+	for _, prefix := range []string{"docker.io/library/", "docker.io/", "localhost/"} {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimPrefix(name, prefix)
+		}
+	}
+	return name
+}
+
+// normalizeID adds digest algorithm to libpod identifier.
+//
+//	normalizeID("0123") -> "sha256:0123"
+func normalizeID(id string) string {
+	if id == "" || strings.Contains(id, ":") {
+		return id
+	}
+	return "sha256:" + id
+}
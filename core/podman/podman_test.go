@@ -0,0 +1,404 @@
+package podman_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/DmitryBogomolov/containerator/core/podman"
+	"github.com/DmitryBogomolov/containerator/manage"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testImageID1 = strings.Repeat("1", 64)
+	testImageID2 = strings.Repeat("2", 64)
+)
+
+type testContainer struct {
+	ID      string
+	Name    string
+	Image   string
+	ImageID string
+	State   string
+	Spec    map[string]any
+}
+
+// testService is a minimal libpod service stand-in.
+type testService struct {
+	lock       sync.Mutex
+	counter    int
+	containers []*testContainer
+	images     []map[string]any
+	execs      map[string][]string
+	requests   []string
+}
+
+func (service *testService) find(ref string) *testContainer {
+	for _, item := range service.containers {
+		if item.ID == ref || item.Name == ref {
+			return item
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"cause": message, "message": message, "response": status})
+}
+
+func (service *testService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	service.lock.Lock()
+	defer service.lock.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/"+podman.APIVersion+"/libpod")
+	query, _ := url.QueryUnescape(r.URL.RawQuery)
+	service.requests = append(service.requests, r.Method+" "+path+"?"+query)
+	switch {
+	case r.Method == http.MethodGet && path == "/images/json":
+		writeJSON(w, http.StatusOK, service.images)
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/images/"):
+		ref := strings.TrimPrefix(path, "/images/")
+		for i, image := range service.images {
+			if image["Id"] != ref && image["RepoTags"].([]any)[0] != "docker.io/library/"+ref {
+				continue
+			}
+			for _, item := range service.containers {
+				if item.ImageID == image["Id"] {
+					writeError(w, http.StatusConflict, "image is in use by a container")
+					return
+				}
+			}
+			service.images = append(service.images[:i], service.images[i+1:]...)
+			writeJSON(w, http.StatusOK, map[string]any{
+				"Deleted": []string{image["Id"].(string)}, "Untagged": image["RepoTags"],
+			})
+			return
+		}
+		writeError(w, http.StatusNotFound, "no such image")
+	case r.Method == http.MethodGet && path == "/containers/json":
+		list := []map[string]any{}
+		for _, item := range service.containers {
+			if item.State != "running" && r.URL.Query().Get("all") != "true" {
+				continue
+			}
+			list = append(list, map[string]any{
+				"Id":      item.ID,
+				"Names":   []string{item.Name},
+				"Image":   item.Image,
+				"ImageID": item.ImageID,
+				"Created": "2024-06-01T10:00:00.000000000Z",
+				"State":   item.State,
+				"Ports":   item.Spec["portmappings"],
+			})
+		}
+		writeJSON(w, http.StatusOK, list)
+	case r.Method == http.MethodPost && path == "/containers/create":
+		var spec map[string]any
+		json.NewDecoder(r.Body).Decode(&spec)
+		name, _ := spec["name"].(string)
+		if service.find(name) != nil {
+			writeError(w, http.StatusConflict, "name is in use")
+			return
+		}
+		var imageID string
+		for _, image := range service.images {
+			for _, tag := range image["RepoTags"].([]any) {
+				if tag == "docker.io/library/"+spec["image"].(string) {
+					imageID = image["Id"].(string)
+				}
+			}
+		}
+		if imageID == "" {
+			writeError(w, http.StatusNotFound, "no such image")
+			return
+		}
+		service.counter++
+		item := &testContainer{
+			ID:      fmt.Sprintf("%064d", service.counter),
+			Name:    name,
+			Image:   "docker.io/library/" + spec["image"].(string),
+			ImageID: imageID,
+			State:   "created",
+			Spec:    spec,
+		}
+		service.containers = append(service.containers, item)
+		writeJSON(w, http.StatusCreated, map[string]any{"Id": item.ID, "Warnings": []string{}})
+	case strings.HasPrefix(path, "/containers/"):
+		parts := strings.SplitN(strings.TrimPrefix(path, "/containers/"), "/", 2)
+		item := service.find(parts[0])
+		if item == nil {
+			writeError(w, http.StatusNotFound, "no such container")
+			return
+		}
+		action := ""
+		if len(parts) > 1 {
+			action = parts[1]
+		}
+		switch action {
+		case "start":
+			item.State = "running"
+			w.WriteHeader(http.StatusNoContent)
+		case "stop":
+			item.State = "exited"
+			w.WriteHeader(http.StatusNoContent)
+		case "rename":
+			item.Name = r.URL.Query().Get("name")
+			w.WriteHeader(http.StatusNoContent)
+		case "exec":
+			var config map[string]any
+			json.NewDecoder(r.Body).Decode(&config)
+			service.counter++
+			id := fmt.Sprintf("exec-%d", service.counter)
+			service.execs[id] = []string{item.ID, fmt.Sprint(config["Cmd"])}
+			writeJSON(w, http.StatusCreated, map[string]any{"Id": id})
+		case "json":
+			writeJSON(w, http.StatusOK, map[string]any{
+				"Id":        item.ID,
+				"Name":      item.Name,
+				"Image":     item.ImageID,
+				"ImageName": item.Image,
+				"State":     map[string]any{"Status": item.State, "Running": item.State == "running"},
+				"Config":    map[string]any{"Env": []string{"A=1"}},
+			})
+		case "":
+			if item.State == "running" && r.URL.Query().Get("force") != "true" {
+				writeError(w, http.StatusConflict, "container is running")
+				return
+			}
+			for i, other := range service.containers {
+				if other == item {
+					service.containers = append(service.containers[:i], service.containers[i+1:]...)
+					break
+				}
+			}
+			writeJSON(w, http.StatusOK, []map[string]any{{"Id": item.ID}})
+		default:
+			writeError(w, http.StatusNotFound, "unknown action")
+		}
+	case strings.HasPrefix(path, "/exec/"):
+		parts := strings.SplitN(strings.TrimPrefix(path, "/exec/"), "/", 2)
+		exec, ok := service.execs[parts[0]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "no such exec session")
+			return
+		}
+		if parts[1] == "start" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		exitCode := 0
+		if exec[1] == "[false]" {
+			exitCode = 1
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"ID": parts[0], "ContainerID": exec[0], "Running": false, "ExitCode": exitCode,
+		})
+	default:
+		writeError(w, http.StatusNotFound, "unknown path")
+	}
+}
+
+func newTestRuntime(t *testing.T) (*testService, *podman.Runtime) {
+	service := &testService{
+		execs: map[string][]string{},
+		images: []map[string]any{
+			{"Id": testImageID2, "RepoTags": []any{"docker.io/library/test-image:2"}, "Created": 1720000000},
+			{"Id": testImageID1, "RepoTags": []any{"docker.io/library/test-image:1"}, "Created": 1710000000},
+		},
+	}
+	server := httptest.NewServer(service)
+	t.Cleanup(server.Close)
+	runtime, err := podman.NewRuntime(server.URL)
+	assert.NoError(t, err)
+	return service, runtime
+}
+
+func TestRuntime(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Images", func(t *testing.T) {
+		_, runtime := newTestRuntime(t)
+
+		images, err := core.FindAllImagesByName(runtime, "test-image")
+
+		assert.NoError(t, err)
+		assert.Len(t, images, 2)
+		assert.Equal(t, "sha256:"+testImageID2, images[0].ID())
+		assert.Equal(t, "test-image:2", images[0].FullName())
+	})
+
+	t.Run("Remove image", func(t *testing.T) {
+		service, runtime := newTestRuntime(t)
+		core.RunContainer(runtime, &core.RunContainerOptions{Image: "test-image:2", Name: "test-2"})
+
+		err := core.RemoveImage(runtime, "test-image:1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "DELETE /images/test-image:1?", service.requests[len(service.requests)-1])
+		images, _ := core.FindAllImagesByName(runtime, "test-image")
+		assert.Len(t, images, 1)
+		err = core.RemoveImage(runtime, "sha256:"+testImageID2)
+		assert.ErrorIs(t, err, core.ErrConflict)
+		assert.Equal(t, "DELETE /images/"+testImageID2+"?", service.requests[len(service.requests)-1])
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		service, runtime := newTestRuntime(t)
+		stopTimeout := 30
+
+		cont, err := core.RunContainer(runtime, &core.RunContainerOptions{
+			Image:         "test-image:1",
+			Name:          "test-1",
+			RestartPolicy: container.RestartPolicyAlways,
+			Network:       "test-net",
+			Ports:         []core.Mapping{{Source: "5001", Target: "80"}},
+			Volumes:       []core.Mapping{{Source: "/src", Target: "/dst"}},
+			Env:           []core.Mapping{{Source: "A", Target: "1"}},
+			StopSignal:    "SIGINT",
+			StopTimeout:   &stopTimeout,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "test-1", cont.Name())
+		assert.Equal(t, "sha256:"+testImageID1, cont.ImageID())
+		assert.Equal(t, "running", cont.State())
+		spec := service.containers[0].Spec
+		assert.Equal(t, map[string]any{"A": "1"}, spec["env"])
+		assert.Equal(t, "always", spec["restart_policy"])
+		assert.Equal(t, float64(2), spec["stop_signal"])
+		assert.Equal(t, float64(30), spec["stop_timeout"])
+		assert.Equal(t, map[string]any{"test-net": map[string]any{}}, spec["networks"])
+		assert.Equal(t, []any{
+			map[string]any{"host_ip": "0.0.0.0", "container_port": float64(80), "host_port": float64(5001), "protocol": "tcp"},
+		}, spec["portmappings"])
+		assert.Equal(t, []any{
+			map[string]any{"destination": "/dst", "source": "/src", "type": "bind", "options": []any{"rbind"}},
+		}, spec["mounts"])
+	})
+
+	t.Run("Create with stop signal", func(t *testing.T) {
+		for signal, number := range map[string]float64{"usr1": 10, "SIGCHLD": 17, "RTMIN+2": 36, "SIGRTMAX-1": 63, "9": 9} {
+			service, runtime := newTestRuntime(t)
+
+			config := &container.Config{Image: "test-image:1", StopSignal: signal}
+			_, err := runtime.ContainerCreate(ctx, config, nil, nil, nil, "test-1")
+
+			assert.NoError(t, err, signal)
+			assert.Equal(t, number, service.containers[0].Spec["stop_signal"], signal)
+		}
+	})
+
+	t.Run("Create with bad stop signal", func(t *testing.T) {
+		for _, signal := range []string{"SIGFOO", "RTMIN+40", "0", "65"} {
+			service, runtime := newTestRuntime(t)
+
+			config := &container.Config{Image: "test-image:1", StopSignal: signal}
+			_, err := runtime.ContainerCreate(ctx, config, nil, nil, nil, "test-1")
+
+			assert.True(t, errdefs.IsInvalidParameter(err), signal)
+			assert.Contains(t, fmt.Sprint(err), signal)
+			assert.Empty(t, service.containers, signal)
+		}
+	})
+
+	t.Run("Inspect", func(t *testing.T) {
+		_, runtime := newTestRuntime(t)
+		body, _ := runtime.ContainerCreate(ctx, &container.Config{Image: "test-image:1"}, nil, nil, nil, "test-1")
+
+		info, err := runtime.ContainerInspect(ctx, body.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "/test-1", info.Name)
+		assert.Equal(t, "sha256:"+testImageID1, info.Image)
+		assert.Equal(t, "test-image:1", info.Config.Image)
+		assert.Equal(t, "created", info.State.Status)
+	})
+
+	t.Run("Exec", func(t *testing.T) {
+		service, runtime := newTestRuntime(t)
+		cont, _ := core.RunContainer(runtime, &core.RunContainerOptions{Image: "test-image:1", Name: "test-1"})
+
+		code, err := core.ExecContainer(runtime, cont, []string{"ls"}, nil, time.Second)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, []string{
+			"POST /exec/exec-2/start?", "GET /exec/exec-2/json?",
+		}, service.requests[len(service.requests)-2:])
+		code, _ = core.ExecContainer(runtime, cont, []string{"false"}, nil, time.Second)
+		assert.Equal(t, 1, code)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		service, runtime := newTestRuntime(t)
+
+		runtime.ContainerList(ctx, container.ListOptions{
+			All: true, Filters: filters.NewArgs(filters.Arg("label", "a=1")),
+		})
+
+		assert.Equal(t, []string{`GET /containers/json?all=true&filters={"label":["a=1"]}`}, service.requests)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, runtime := newTestRuntime(t)
+		runtime.ContainerCreate(ctx, &container.Config{Image: "test-image:1"}, nil, nil, nil, "test-1")
+
+		_, err := runtime.ContainerInspect(ctx, "test-2")
+		assert.True(t, errdefs.IsNotFound(err))
+		assert.EqualError(t, err, "no such container")
+		_, err = runtime.ContainerCreate(ctx, &container.Config{Image: "test-image:1"}, nil, nil, nil, "test-1")
+		assert.True(t, errdefs.IsConflict(err))
+		runtime.ContainerStart(ctx, "test-1", container.StartOptions{})
+		err = runtime.ContainerRemove(ctx, "test-1", container.RemoveOptions{})
+		assert.True(t, errdefs.IsConflict(err))
+	})
+
+	t.Run("Manage", func(t *testing.T) {
+		service, runtime := newTestRuntime(t)
+		cfg := &manage.Config{ImageName: "test-image"}
+
+		prev, err := manage.RunContainer(runtime, cfg, &manage.Options{Tag: "1"})
+		assert.NoError(t, err)
+		cont, err := manage.RunContainer(runtime, cfg, &manage.Options{Tag: "2"})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "test-image", cont.Name())
+		assert.Equal(t, "sha256:"+testImageID2, cont.ImageID())
+		assert.Len(t, service.containers, 1)
+		_, err = core.FindContainerByID(runtime, prev.ID())
+		assert.ErrorIs(t, err, core.ErrNotFound)
+
+		_, err = manage.RunContainer(runtime, cfg, &manage.Options{Remove: true})
+		assert.NoError(t, err)
+		assert.Len(t, service.containers, 0)
+	})
+}
+
+func TestNewRuntime(t *testing.T) {
+	_, err := podman.NewRuntime("unix:///run/podman/podman.sock")
+	assert.NoError(t, err)
+	_, err = podman.NewRuntime("tcp://localhost:8080")
+	assert.NoError(t, err)
+	_, err = podman.NewRuntime("ssh://localhost")
+	assert.Error(t, err)
+
+	t.Setenv(podman.EnvHost, "tcp://localhost:8080")
+	assert.Equal(t, "tcp://localhost:8080", podman.DefaultHost())
+}
@@ -0,0 +1,430 @@
+/*
+Package replay records docker engine HTTP interactions into golden files and replays them without daemon.
+
+Transport is plugged into docker client through custom `http.Client`.
+In record mode requests are passed to the real daemon and interactions are saved on Close.
+In replay mode requests are matched against saved interactions in order and saved responses are returned.
+Docker client is pinned to the API version stored in the file, so same scenario can be kept for several API versions
+(`testdata/replay/v<version>/<scenario>.json`).
+
+	replay.Run(t, "testdata/replay", "run_container.json", func(t *testing.T, cli *client.Client, _ *replay.Transport) {
+		core.RunContainer(cli, &core.RunContainerOptions{Image: "my-image:1"})
+	})
+*/
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/client"
+)
+
+// Mode defines whether transport records or replays interactions.
+type Mode string
+
+const (
+	// ModeReplay replays interactions from the file.
+	ModeReplay Mode = "replay"
+	// ModeRecord sends requests to the daemon and saves interactions to the file.
+	ModeRecord Mode = "record"
+)
+
+// EnvMode is environment variable that selects mode for ModeFromEnv.
+const EnvMode = "CONTAINERATOR_REPLAY_MODE"
+
+const wildcard = "*"
+
+// Request contains recorded request.
+type Request struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Query  map[string]string `json:"query,omitempty"`
+	Body   json.RawMessage   `json:"body,omitempty"`
+}
+
+// Response contains recorded response.
+type Response struct {
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       json.RawMessage   `json:"body,omitempty"`
+	Text       string            `json:"text,omitempty"`
+}
+
+// Interaction contains request and response pair.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is a content of golden file.
+type Cassette struct {
+	APIVersion   string        `json:"api_version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+type _IgnoredQuery struct {
+	pathSuffix string
+	params     []string
+}
+
+// Transport implements `http.RoundTripper` that records or replays docker engine interactions.
+type Transport struct {
+	mode     Mode
+	path     string
+	inner    http.RoundTripper
+	cassette Cassette
+	position int
+	ignored  []_IgnoredQuery
+	lock     sync.Mutex
+}
+
+// recordedHeaders are response headers saved to the file; other headers are not needed by docker client.
+var recordedHeaders = []string{"Content-Type", "Api-Version"}
+
+// ModeFromEnv returns ModeRecord if CONTAINERATOR_REPLAY_MODE is "record" and ModeReplay otherwise.
+func ModeFromEnv() Mode {
+	if Mode(os.Getenv(EnvMode)) == ModeRecord {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+/*
+Files returns golden files of the scenario: one file per API version in `dir/v<version>/name`.
+
+In replay mode all recorded versions are returned. In record mode file for API version from DOCKER_API_VERSION is
+returned; the variable is required so that the version of recording is chosen explicitly.
+
+	Files("testdata/replay", "run_container.json", ModeReplay) -> ["testdata/replay/v1.41/run_container.json", ...], nil
+	Files("testdata/replay", "run_container.json", ModeRecord) -> ["testdata/replay/v1.43/run_container.json"], nil
+*/
+func Files(dir string, name string, mode Mode) ([]string, error) {
+	if mode == ModeRecord {
+		version := os.Getenv(client.EnvOverrideAPIVersion)
+		if version == "" {
+			return nil, fmt.Errorf("%s is required to record golden files", client.EnvOverrideAPIVersion)
+		}
+		return []string{filepath.Join(dir, "v"+version, name)}, nil
+	}
+	return filepath.Glob(filepath.Join(dir, "v*", name))
+}
+
+/*
+Run runs the scenario against each of its golden files (see Files) in subtests named after API version.
+
+Test is skipped if scenario is not recorded. Transport is closed after the scenario.
+
+	Run(t, "testdata/replay", "run_container.json", func(t *testing.T, cli *client.Client, transport *Transport) {
+		core.RunContainer(cli, options)
+	})
+*/
+func Run(
+	t *testing.T, dir string, name string, scenario func(t *testing.T, cli *client.Client, transport *Transport),
+) {
+	mode := ModeFromEnv()
+	files, err := Files(dir, name, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Skipf("%s is not recorded", name)
+	}
+	for _, pathToFile := range files {
+		pathToFile := pathToFile
+		t.Run(filepath.Base(filepath.Dir(pathToFile)), func(t *testing.T) {
+			cli, transport, err := NewClient(pathToFile, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scenario(t, cli, transport)
+			if err := transport.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// NewRecorder creates Transport that passes requests to `inner` and saves interactions to the file on Close.
+//
+//	NewRecorder("testdata/scenario.json", "1.43", http.DefaultTransport) -> &transport
+func NewRecorder(pathToFile string, apiVersion string, inner http.RoundTripper) *Transport {
+	return &Transport{
+		mode:     ModeRecord,
+		path:     pathToFile,
+		inner:    inner,
+		cassette: Cassette{APIVersion: apiVersion},
+	}
+}
+
+// NewPlayer creates Transport that replays interactions from the file.
+//
+//	NewPlayer("testdata/scenario.json") -> &transport, err
+func NewPlayer(pathToFile string) (*Transport, error) {
+	data, err := os.ReadFile(pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	transport := Transport{mode: ModeReplay, path: pathToFile}
+	if err := json.Unmarshal(data, &transport.cassette); err != nil {
+		return nil, fmt.Errorf("%s: %w", pathToFile, err)
+	}
+	return &transport, nil
+}
+
+/*
+NewClient creates docker client that uses Transport.
+
+In record mode client connects to the daemon defined by environment (DOCKER_HOST, DOCKER_TLS_VERIFY, DOCKER_CERT_PATH)
+and negotiates API version unless it is set by DOCKER_API_VERSION. In replay mode client uses API version from the file.
+
+	cli, transport, err := NewClient("testdata/scenario.json", ModeFromEnv())
+	defer transport.Close()
+*/
+func NewClient(pathToFile string, mode Mode) (*client.Client, *Transport, error) {
+	var transport *Transport
+	var opts []client.Opt
+	if mode == ModeRecord {
+		base, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		if err != nil {
+			return nil, nil, err
+		}
+		base.NegotiateAPIVersion(context.Background())
+		transport = NewRecorder(pathToFile, base.ClientVersion(), base.HTTPClient().Transport)
+		opts = append(opts, client.WithHost(base.DaemonHost()))
+		if os.Getenv(client.EnvTLSVerify) != "" {
+			opts = append(opts, client.WithScheme("https"))
+		}
+	} else {
+		var err error
+		if transport, err = NewPlayer(pathToFile); err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, client.WithHost(client.DefaultDockerHost))
+	}
+	opts = append(
+		opts,
+		client.WithHTTPClient(&http.Client{Transport: transport}),
+		client.WithVersion(transport.APIVersion()),
+	)
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli, transport, nil
+}
+
+// APIVersion returns docker API version of the interactions.
+func (transport *Transport) APIVersion() string {
+	return transport.cassette.APIVersion
+}
+
+// Mode returns transport mode.
+func (transport *Transport) Mode() Mode {
+	return transport.mode
+}
+
+// IgnoreQuery excludes query parameters of requests with matching path suffix from comparison.
+//
+// Used for values that differ between runs like generated container names.
+// Recorder saves such parameters as "*".
+//
+//	transport.IgnoreQuery("/rename", "name")
+func (transport *Transport) IgnoreQuery(pathSuffix string, params ...string) {
+	transport.ignored = append(transport.ignored, _IgnoredQuery{pathSuffix, params})
+}
+
+func (transport *Transport) isIgnored(path string, param string) bool {
+	for _, item := range transport.ignored {
+		if !strings.HasSuffix(path, item.pathSuffix) {
+			continue
+		}
+		for _, name := range item.params {
+			if name == param {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RoundTrip implements `http.RoundTripper` interface.
+func (transport *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	request, err := transport.makeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	transport.lock.Lock()
+	defer transport.lock.Unlock()
+	if transport.mode == ModeRecord {
+		return transport.record(req, request)
+	}
+	return transport.replay(req, request)
+}
+
+func (transport *Transport) makeRequest(req *http.Request) (Request, error) {
+	request := Request{Method: req.Method, Path: req.URL.Path}
+	for key, values := range req.URL.Query() {
+		if request.Query == nil {
+			request.Query = map[string]string{}
+		}
+		value := strings.Join(values, ",")
+		if transport.isIgnored(req.URL.Path, key) {
+			value = wildcard
+		}
+		request.Query[key] = value
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return request, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return request, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	request.Body = normalizeBody(data)
+	return request, nil
+}
+
+func normalizeBody(data []byte) json.RawMessage {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+	if !json.Valid(data) {
+		encoded, _ := json.Marshal(string(data))
+		return encoded
+	}
+	return data
+}
+
+func (transport *Transport) record(req *http.Request, request Request) (*http.Response, error) {
+	res, err := transport.inner.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	response := Response{StatusCode: res.StatusCode}
+	for _, name := range recordedHeaders {
+		if value := res.Header.Get(name); value != "" {
+			if response.Headers == nil {
+				response.Headers = map[string]string{}
+			}
+			response.Headers[name] = value
+		}
+	}
+	if trimmed := bytes.TrimSpace(data); json.Valid(trimmed) {
+		response.Body = trimmed
+	} else {
+		response.Text = string(data)
+	}
+	transport.cassette.Interactions = append(transport.cassette.Interactions, Interaction{request, response})
+	return res, nil
+}
+
+func (transport *Transport) replay(req *http.Request, request Request) (*http.Response, error) {
+	if transport.position >= len(transport.cassette.Interactions) {
+		return nil, fmt.Errorf("%s: unexpected request %s %s", transport.path, request.Method, request.Path)
+	}
+	interaction := transport.cassette.Interactions[transport.position]
+	if err := compareRequests(interaction.Request, request); err != nil {
+		return nil, fmt.Errorf("%s: interaction %d: %w", transport.path, transport.position, err)
+	}
+	transport.position++
+	response := interaction.Response
+	header := http.Header{}
+	for key, value := range response.Headers {
+		header.Set(key, value)
+	}
+	body := []byte(response.Text)
+	if len(response.Body) > 0 {
+		body = response.Body
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.StatusCode, http.StatusText(response.StatusCode)),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func compareRequests(expected Request, actual Request) error {
+	if expected.Method != actual.Method || expected.Path != actual.Path {
+		return fmt.Errorf(
+			"expected request %s %s, got %s %s",
+			expected.Method, expected.Path, actual.Method, actual.Path,
+		)
+	}
+	if len(expected.Query) != len(actual.Query) {
+		return fmt.Errorf("%s %s: expected query %v, got %v", actual.Method, actual.Path, expected.Query, actual.Query)
+	}
+	for key, value := range expected.Query {
+		if actualValue, ok := actual.Query[key]; !ok || (value != wildcard && value != actualValue) {
+			return fmt.Errorf("%s %s: expected query %v, got %v", actual.Method, actual.Path, expected.Query, actual.Query)
+		}
+	}
+	if !equalBodies(expected.Body, actual.Body) {
+		return fmt.Errorf("%s %s: expected body %s, got %s", actual.Method, actual.Path, expected.Body, actual.Body)
+	}
+	return nil
+}
+
+func equalBodies(expected json.RawMessage, actual json.RawMessage) bool {
+	if len(expected) == 0 || len(actual) == 0 {
+		return len(expected) == len(actual)
+	}
+	var expectedValue, actualValue any
+	if json.Unmarshal(expected, &expectedValue) != nil || json.Unmarshal(actual, &actualValue) != nil {
+		return bytes.Equal(expected, actual)
+	}
+	return reflect.DeepEqual(expectedValue, actualValue)
+}
+
+// Close finishes the scenario.
+//
+// Recorder saves interactions to the file.
+// Player returns error if some of interactions were not requested.
+func (transport *Transport) Close() error {
+	transport.lock.Lock()
+	defer transport.lock.Unlock()
+	if transport.mode == ModeRecord {
+		return transport.save()
+	}
+	if rest := len(transport.cassette.Interactions) - transport.position; rest > 0 {
+		next := transport.cassette.Interactions[transport.position].Request
+		return fmt.Errorf("%s: %d interaction(s) not requested, next is %s %s", transport.path, rest, next.Method, next.Path)
+	}
+	return nil
+}
+
+func (transport *Transport) save() error {
+	data, err := json.MarshalIndent(transport.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(transport.path), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(transport.path, append(data, '\n'), 0644)
+}
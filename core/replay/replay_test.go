@@ -0,0 +1,184 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, transport *Transport) *client.Client {
+	cli, err := client.NewClientWithOpts(
+		client.WithHost(client.DefaultDockerHost),
+		client.WithHTTPClient(&http.Client{Transport: transport}),
+		client.WithVersion(transport.APIVersion()),
+	)
+	assert.NoError(t, err)
+	return cli
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Api-Version", "1.43")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/json"):
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `[{"Id":"0011","Names":["/test-1"],"State":"running"}]`)
+		case strings.HasSuffix(r.URL.Path, "/rename"):
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message":"not found"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// serverTransport sends requests to test server instead of docker socket.
+type serverTransport struct {
+	server *httptest.Server
+}
+
+func (transport serverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = "http"
+	req.URL.Host = strings.TrimPrefix(transport.server.URL, "http://")
+	return transport.server.Client().Transport.RoundTrip(req)
+}
+
+func TestRecordReplay(t *testing.T) {
+	ctx := context.Background()
+	pathToFile := filepath.Join(t.TempDir(), "v1.43", "scenario.json")
+	server := newTestServer(t)
+
+	recorder := NewRecorder(pathToFile, "1.43", serverTransport{server})
+	recorder.IgnoreQuery("/rename", "name")
+	cli := newTestClient(t, recorder)
+	list, err := cli.ContainerList(ctx, container.ListOptions{All: true})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.NoError(t, cli.ContainerRename(ctx, "0011", "random-name-1"))
+	_, err = cli.ContainerInspect(ctx, "0022")
+	assert.Error(t, err)
+	assert.NoError(t, recorder.Close())
+
+	player, err := NewPlayer(pathToFile)
+	assert.NoError(t, err)
+	player.IgnoreQuery("/rename", "name")
+	assert.Equal(t, ModeReplay, player.Mode())
+	assert.Equal(t, "1.43", player.APIVersion())
+	cli = newTestClient(t, player)
+	list, err = cli.ContainerList(ctx, container.ListOptions{All: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "0011", list[0].ID)
+	assert.Equal(t, []string{"/test-1"}, list[0].Names)
+	assert.NoError(t, cli.ContainerRename(ctx, "0011", "random-name-2"))
+	_, err = cli.ContainerInspect(ctx, "0022")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+	assert.NoError(t, player.Close())
+}
+
+func TestReplayMismatch(t *testing.T) {
+	ctx := context.Background()
+	pathToFile := filepath.Join(t.TempDir(), "scenario.json")
+	recorder := NewRecorder(pathToFile, "1.43", serverTransport{newTestServer(t)})
+	cli := newTestClient(t, recorder)
+	cli.ContainerList(ctx, container.ListOptions{All: true})
+	cli.ContainerRename(ctx, "0011", "test-2")
+	assert.NoError(t, recorder.Close())
+
+	t.Run("Query", func(t *testing.T) {
+		player, _ := NewPlayer(pathToFile)
+		cli := newTestClient(t, player)
+
+		_, err := cli.ContainerList(ctx, container.ListOptions{})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "expected query")
+	})
+
+	t.Run("Path", func(t *testing.T) {
+		player, _ := NewPlayer(pathToFile)
+		cli := newTestClient(t, player)
+
+		_, err := cli.ContainerInspect(ctx, "0011")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "expected request GET /v1.43/containers/json")
+	})
+
+	t.Run("Not requested", func(t *testing.T) {
+		player, _ := NewPlayer(pathToFile)
+		cli := newTestClient(t, player)
+		cli.ContainerList(ctx, container.ListOptions{All: true})
+
+		err := player.Close()
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "1 interaction(s) not requested")
+	})
+
+	t.Run("Unexpected", func(t *testing.T) {
+		player, _ := NewPlayer(pathToFile)
+		cli := newTestClient(t, player)
+		cli.ContainerList(ctx, container.ListOptions{All: true})
+		cli.ContainerRename(ctx, "0011", "test-2")
+
+		_, err := cli.ContainerList(ctx, container.ListOptions{All: true})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected request")
+	})
+}
+
+func TestModeFromEnv(t *testing.T) {
+	t.Setenv(EnvMode, "")
+	assert.Equal(t, ModeReplay, ModeFromEnv())
+	t.Setenv(EnvMode, "record")
+	assert.Equal(t, ModeRecord, ModeFromEnv())
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, version := range []string{"v1.41", "v1.43"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, version), os.ModePerm))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, version, "scenario.json"), []byte("{}"), 0644))
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		files, err := Files(dir, "scenario.json", ModeReplay)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(dir, "v1.41", "scenario.json"),
+			filepath.Join(dir, "v1.43", "scenario.json"),
+		}, files)
+	})
+
+	t.Run("Record", func(t *testing.T) {
+		t.Setenv(client.EnvOverrideAPIVersion, "1.44")
+
+		files, err := Files(dir, "scenario.json", ModeRecord)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "v1.44", "scenario.json")}, files)
+	})
+
+	t.Run("Record without version", func(t *testing.T) {
+		t.Setenv(client.EnvOverrideAPIVersion, "")
+
+		_, err := Files(dir, "scenario.json", ModeRecord)
+
+		assert.EqualError(t, err, "DOCKER_API_VERSION is required to record golden files")
+	})
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core/replay"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+// Scenarios are replayed for each API version recorded in testdata/replay; they are skipped until recorded
+// with `make record-replay`.
+func TestReplayRunContainer(t *testing.T) {
+	replay.Run(t, filepath.Join("testdata", "replay"), "run_container.json",
+		func(t *testing.T, cli *client.Client, _ *replay.Transport) {
+			cont, err := RunContainer(cli, &RunContainerOptions{
+				Image:         "nginx:alpine",
+				Name:          "containerator-test",
+				RestartPolicy: container.RestartPolicyAlways,
+				Ports:         []Mapping{{"8080", "80"}},
+				Env:           []Mapping{{"A", "1"}},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "containerator-test", cont.Name())
+			assert.Equal(t, "running", cont.State())
+			assert.NoError(t, RemoveContainer(cli, cont))
+		},
+	)
+}
+
+func TestReplaySuspendResumeContainer(t *testing.T) {
+	replay.Run(t, filepath.Join("testdata", "replay"), "suspend_resume_container.json",
+		func(t *testing.T, cli *client.Client, transport *replay.Transport) {
+			transport.IgnoreQuery("/rename", "name")
+
+			cont, err := RunContainer(cli, &RunContainerOptions{Image: "nginx:alpine", Name: "containerator-test"})
+			assert.NoError(t, err)
+			assert.NoError(t, SuspendContainer(cli, cont))
+			assert.NoError(t, ResumeContainer(cli, cont, "containerator-test"))
+			assert.NoError(t, RemoveContainer(cli, cont))
+		},
+	)
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ContainerRuntime is a part of Runtime that works with containers.
+//
+// It is implemented by `client.ContainerAPIClient`.
+type ContainerRuntime interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ContainerCreate(
+		ctx context.Context, config *container.Config, hostConfig *container.HostConfig,
+		networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string,
+	) (container.CreateResponse, error)
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+	ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error
+	ContainerRename(ctx context.Context, containerID string, newName string) error
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerExecCreate(ctx context.Context, containerID string, config types.ExecConfig) (types.IDResponse, error)
+	ContainerExecStart(ctx context.Context, execID string, config types.ExecStartCheck) error
+	ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error)
+}
+
+// ImageRuntime is a part of Runtime that works with images.
+//
+// It is implemented by `client.ImageAPIClient`.
+type ImageRuntime interface {
+	ImageList(ctx context.Context, options types.ImageListOptions) ([]types.ImageSummary, error)
+	ImageRemove(ctx context.Context, imageID string, options types.ImageRemoveOptions) ([]image.DeleteResponse, error)
+}
+
+/*
+Runtime is a container engine backend used by core and manage functions.
+
+Methods mirror docker engine API and have the same signatures as docker client methods,
+so docker client (`*client.Client`) is Runtime and can be passed as is.
+Runtime returns errors from "github.com/docker/docker/errdefs" so that not found and conflict errors
+are recognized regardless of backend.
+
+	cli, _ := client.NewClientWithOpts(client.FromEnv)
+	RunContainer(cli, &RunContainerOptions{Image: "my-image:1"})
+*/
+type Runtime interface {
+	ContainerRuntime
+	ImageRuntime
+}
+
+var (
+	_ Runtime          = (*client.Client)(nil)
+	_ ContainerRuntime = (client.ContainerAPIClient)(nil)
+	_ ImageRuntime     = (client.ImageAPIClient)(nil)
+)
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerRuntime(t *testing.T) {
+	var requests []string
+	var createBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1.43")
+		requests = append(requests, r.Method+" "+path+"?"+r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case path == "/containers/create":
+			json.NewDecoder(r.Body).Decode(&createBody)
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"Id":"cid1","Warnings":[]}`)
+		case path == "/containers/json":
+			io.WriteString(w, `[{"Id":"cid1","Names":["/test-1"],"ImageID":"sha256:iid1","State":"running"}]`)
+		case path == "/images/json":
+			io.WriteString(w, `[{"Id":"sha256:iid1","RepoTags":["test-image:1"]}]`)
+		case path == "/containers/cid1/json":
+			io.WriteString(w, `{"Id":"cid1","Name":"/test-1","State":{"Status":"running"}}`)
+		case strings.HasPrefix(path, "/containers/cid1"):
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message":"No such container"}`)
+		}
+	}))
+	defer server.Close()
+	cli, err := client.NewClientWithOpts(
+		client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")), client.WithVersion("1.43"),
+	)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	cont, err := RunContainer(cli, &RunContainerOptions{Image: "test-image:1", Name: "test-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test-1", cont.Name())
+	assert.Equal(t, "test-image:1", createBody["Image"])
+	assert.NoError(t, SuspendContainer(cli, cont))
+	assert.NoError(t, RemoveContainer(cli, cont))
+	image, err := FindImageByName(cli, "test-image:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:iid1", image.ID())
+	info, err := cli.ContainerInspect(ctx, "cid1")
+	assert.NoError(t, err)
+	assert.Equal(t, "running", info.State.Status)
+	_, err = cli.ContainerInspect(ctx, "cid2")
+	assert.True(t, errdefs.IsNotFound(err))
+	_, err = cli.ImageList(ctx, types.ImageListOptions{})
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(requests[3], "POST /containers/cid1/rename?name="))
+	requests[3] = "POST /containers/cid1/rename"
+	assert.Equal(t, []string{
+		"POST /containers/create?name=test-1",
+		"POST /containers/cid1/start?",
+		"GET /containers/json?all=1",
+		"POST /containers/cid1/rename",
+		"GET /containers/cid1/json?",
+		"POST /containers/cid1/stop?",
+		"GET /containers/cid1/json?",
+		"DELETE /containers/cid1?force=1",
+		"GET /images/json?",
+		"GET /containers/cid1/json?",
+		"GET /containers/cid2/json?",
+		"GET /images/json?",
+	}, requests)
+}
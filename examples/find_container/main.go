@@ -2,6 +2,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -16,27 +17,27 @@ func displayContainer(container core.Container) string {
 
 func findContainerByID(cli *client.Client, id string) error {
 	container, err := core.FindContainerByShortID(cli, id)
+	if errors.Is(err, core.ErrNotFound) {
+		fmt.Println("Container not found")
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	if container == nil {
-		fmt.Println("Container not found")
-	} else {
-		fmt.Println(displayContainer(container))
-	}
+	fmt.Println(displayContainer(container))
 	return nil
 }
 
 func findContainerByName(cli *client.Client, name string) error {
 	container, err := core.FindContainerByName(cli, name)
+	if errors.Is(err, core.ErrNotFound) {
+		fmt.Println("Container not found")
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	if container == nil {
-		fmt.Println("Container not found")
-	} else {
-		fmt.Println(displayContainer(container))
-	}
+	fmt.Println(displayContainer(container))
 	return nil
 }
 
@@ -74,11 +75,10 @@ func run() error {
 
 	flag.Parse()
 
-	cli, err := client.NewEnvClient()
+	cli, err := core.NewClient(&core.ClientOptions{})
 	if err != nil {
 		return err
 	}
-
 	if id != "" {
 		return findContainerByID(cli, id)
 	} else if name != "" {
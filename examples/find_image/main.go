@@ -2,6 +2,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -16,27 +17,27 @@ func displayImage(image core.Image) string {
 
 func findImageByID(cli *client.Client, id string) error {
 	image, err := core.FindImageByShortID(cli, id)
+	if errors.Is(err, core.ErrNotFound) {
+		fmt.Println("Image not found")
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	if image == nil {
-		fmt.Println("Image not found")
-	} else {
-		fmt.Println(displayImage(image))
-	}
+	fmt.Println(displayImage(image))
 	return nil
 }
 
 func findImageByName(cli *client.Client, name string) error {
 	image, err := core.FindImageByName(cli, name)
+	if errors.Is(err, core.ErrNotFound) {
+		fmt.Println("Image not found")
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	if image == nil {
-		fmt.Println("Image not found")
-	} else {
-		fmt.Println(displayImage(image))
-	}
+	fmt.Println(displayImage(image))
 	return nil
 }
 
@@ -74,11 +75,10 @@ func run() error {
 
 	flag.Parse()
 
-	cli, err := client.NewEnvClient()
+	cli, err := core.NewClient(&core.ClientOptions{})
 	if err != nil {
 		return err
 	}
-
 	if id != "" {
 		return findImageByID(cli, id)
 	} else if name != "" {
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/user"
+	"time"
 
 	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/DmitryBogomolov/containerator/core/podman"
 	"github.com/DmitryBogomolov/containerator/manage"
-	"github.com/docker/docker/client"
 )
 
 func makeConfig(configPath string, containerName string, imageName string) (*manage.Config, error) {
@@ -34,16 +36,83 @@ func makeConfig(configPath string, containerName string, imageName string) (*man
 	return config, err
 }
 
-func makeOptions(postfix string, tag string, force bool, remove bool, configPath string) *manage.Options {
+func explainConfig(configPath string) error {
+	config, err := manage.ReadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	for _, item := range config.Explain() {
+		fmt.Printf("%s: %s\t(%s)\n", item.Path, item.Value, item.Origin)
+	}
+	return nil
+}
+
+func makeOptions(
+	postfix string, tag string, force bool, remove bool, envFilePath string, portOffset int,
+) *manage.Options {
 	options := manage.Options{
-		Postfix: postfix,
-		Tag:     tag,
-		Force:   force,
-		Remove:  remove,
+		Postfix:     postfix,
+		Tag:         tag,
+		Force:       force,
+		Remove:      remove,
+		EnvFilePath: envFilePath,
+		PortOffset:  portOffset,
 	}
 	return &options
 }
 
+func getActor() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return ""
+}
+
+func printHistory(historyDir string, config *manage.Config, options *manage.Options) error {
+	name, err := manage.ContainerName(config, options)
+	if err != nil {
+		return err
+	}
+	records, err := manage.History(&manage.HistoryQuery{Dir: historyDir, Container: name})
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\t%s\n",
+			record.Time.Format(time.RFC3339), record.Operation, record.Actor, record.Tag, record.Result, record.Error)
+	}
+	return nil
+}
+
+func printGarbage(
+	cli core.Runtime, config *manage.Config, policy *manage.GCPolicy, options *manage.Options,
+) error {
+	report, err := manage.CollectGarbage(cli, config, policy, options)
+	if err != nil {
+		return err
+	}
+	status := "removed"
+	if report.DryRun {
+		status = "would be removed"
+	}
+	for _, image := range report.Removed {
+		fmt.Printf("%s(%s)\t%s\n", image.FullName(), image.ShortID(), status)
+	}
+	fmt.Printf("reclaimed: up to %d bytes\n", report.MaxReclaimed)
+	return nil
+}
+
+func makeRuntime(contextName string, podmanHost string) (core.Runtime, error) {
+	if podmanHost != "" {
+		return podman.NewRuntime(podmanHost)
+	}
+	cli, err := core.NewClient(&core.ClientOptions{Context: contextName})
+	if err != nil {
+		return nil, err
+	}
+	return cli, nil
+}
+
 func displayContainer(container core.Container) string {
 	return fmt.Sprintf("%s(%s)", container.Name(), container.ShortID())
 }
@@ -63,10 +132,61 @@ func run() error {
 	flag.BoolVar(&remove, "remove", false, "remove container")
 	var force bool
 	flag.BoolVar(&force, "force", false, "force container creation")
+	var envFilePath string
+	flag.StringVar(&envFilePath, "env-file", "", "env file")
+	var portOffset int
+	flag.IntVar(&portOffset, "port-offset", 0, "host ports offset")
+	var contextName string
+	flag.StringVar(&contextName, "context", "", "docker context")
+	var podmanHost string
+	flag.StringVar(&podmanHost, "podman", "", "podman service address (uses docker if not set)")
+	var validate bool
+	flag.BoolVar(&validate, "validate", false, "validate configuration file and exit")
+	var explain bool
+	flag.BoolVar(&explain, "explain", false, "show configuration values with their origins and exit")
+	var dryRun bool
+	flag.BoolVar(&dryRun, "dry-run", false, "show what would be done without changing anything")
+	var rollback bool
+	flag.BoolVar(&rollback, "rollback", false, "replace container with the previous version")
+	var recoverDeploy bool
+	flag.BoolVar(&recoverDeploy, "recover", false, "resume or clean up containers left by interrupted deployment")
+	var historyDir string
+	flag.StringVar(&historyDir, "history", "", "directory where operations are recorded")
+	var showHistory bool
+	flag.BoolVar(&showHistory, "show-history", false, "show recorded operations and exit")
+	var lockDir string
+	flag.StringVar(&lockDir, "lock-dir", "", "directory with lock files (locks container across processes)")
+	var lockTimeout time.Duration
+	flag.DurationVar(&lockTimeout, "lock-timeout", 0, "how long to wait for busy container")
+	var versions bool
+	flag.BoolVar(&versions, "versions", false, "list kept container versions and exit")
+	var collectGarbage bool
+	flag.BoolVar(&collectGarbage, "collect-garbage", false, "remove old images (dry run unless --apply is set) and exit")
+	var keepLast int
+	flag.IntVar(&keepLast, "keep-last", 0, "number of newest images kept by --collect-garbage")
+	var keepYounger time.Duration
+	flag.DurationVar(&keepYounger, "keep-younger", 0, "images younger than that are kept by --collect-garbage")
+	var apply bool
+	flag.BoolVar(&apply, "apply", false, "remove images with --collect-garbage")
+	var webhookURL string
+	flag.StringVar(&webhookURL, "webhook", "", "URL that deployment events are posted to")
+	var webhookSecret string
+	flag.StringVar(&webhookSecret, "webhook-secret", "", "key that webhook payloads are signed with")
 
 	flag.Parse()
 
-	cli, err := client.NewEnvClient()
+	if validate {
+		if err := manage.ValidateConfig(configPath); err != nil {
+			return err
+		}
+		log.Printf("%s: valid\n", configPath)
+		return nil
+	}
+	if explain {
+		return explainConfig(configPath)
+	}
+
+	cli, err := makeRuntime(contextName, podmanHost)
 	if err != nil {
 		return err
 	}
@@ -75,7 +195,78 @@ func run() error {
 	if err != nil {
 		return err
 	}
-	options := makeOptions(postfix, tag, force, remove, configPath)
+	options := makeOptions(postfix, tag, force, remove, envFilePath, portOffset)
+	options.Lock = &manage.LockOptions{Dir: lockDir, Timeout: lockTimeout}
+	if historyDir != "" {
+		options.History = &manage.HistoryOptions{Dir: historyDir}
+		options.Actor = getActor()
+	}
+
+	if webhookURL != "" {
+		notifier, err := manage.NewWebhookNotifier(&manage.WebhookOptions{
+			Webhooks: []manage.Webhook{{URL: webhookURL, Secret: webhookSecret}},
+			OnError: func(webhook *manage.Webhook, event *manage.Event, err error) {
+				log.Printf("webhook: %s: %v\n", event.Type, err)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		defer notifier.Close()
+		options.Notifier = notifier
+		options.Actor = getActor()
+	}
+
+	if showHistory {
+		return printHistory(historyDir, config, options)
+	}
+
+	if dryRun {
+		plan, err := manage.Plan(cli, config, options)
+		if err != nil {
+			return err
+		}
+		fmt.Print(plan)
+		return nil
+	}
+
+	if collectGarbage {
+		policy := &manage.GCPolicy{KeepLast: keepLast, KeepYounger: keepYounger, Apply: apply}
+		return printGarbage(cli, config, policy, options)
+	}
+
+	if versions {
+		items, err := manage.Versions(cli, config, options)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			fmt.Printf("%s\t%s\n", displayContainer(item.Container), item.DeployTime.Format(time.RFC3339))
+		}
+		return nil
+	}
+	if recoverDeploy {
+		recovery, err := manage.Recover(cli, config, options)
+		if err != nil {
+			return err
+		}
+		for _, container := range recovery.Cleaned {
+			log.Printf("%s: cleaned\n", displayContainer(container))
+		}
+		if recovery.Resumed != nil {
+			log.Printf("%s: resumed\n", displayContainer(recovery.Resumed))
+		}
+		return nil
+	}
+	if rollback {
+		container, err := manage.Rollback(cli, config, options)
+		if err != nil {
+			return err
+		}
+		log.Printf("%s: rolled back\n", displayContainer(container))
+		return nil
+	}
+
 	container, err := manage.RunContainer(cli, config, options)
 
 	if options.Remove {
@@ -14,8 +14,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/DmitryBogomolov/containerator/examples/manage_container_server/logger"
+	"github.com/DmitryBogomolov/containerator/manage"
+	"github.com/DmitryBogomolov/containerator/proxy"
+	"gopkg.in/yaml.v3"
 )
 
 const defaultPort = 4001
@@ -42,6 +46,25 @@ func validateWorkspace(workspace string) (string, error) {
 	return workspace, nil
 }
 
+// makeNotifier creates notifier that posts events to webhooks listed in file (yaml or json).
+func makeNotifier(pathToFile string) (*manage.WebhookNotifier, error) {
+	data, err := os.ReadFile(pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	var webhooks []manage.Webhook
+	if err := yaml.Unmarshal(data, &webhooks); err != nil {
+		return nil, err
+	}
+	logger.Printf("webhooks: %d\n", len(webhooks))
+	return manage.NewWebhookNotifier(&manage.WebhookOptions{
+		Webhooks: webhooks,
+		OnError: func(webhook *manage.Webhook, event *manage.Event, err error) {
+			logger.Printf("webhook %s: %s %s: %v\n", webhook.URL, event.Container, event.Type, err)
+		},
+	})
+}
+
 func runServer(port int, handler http.Handler) error {
 	ch := make(chan error)
 	go func() {
@@ -56,13 +79,38 @@ func run() error {
 	var workspace string
 	flag.IntVar(&port, "port", defaultPort, "port")
 	flag.StringVar(&workspace, "workspace", "", "path to workspace")
+	var contextName string
+	flag.StringVar(&contextName, "context", "", "docker context")
+	var podmanHost string
+	flag.StringVar(&podmanHost, "podman", "", "podman service address (uses docker if not set)")
+	var proxyMode string
+	flag.StringVar(&proxyMode, "proxy", "", "proxy mode for \"proxy\" deploy strategy (http or tcp)")
+	var lockTimeout time.Duration
+	flag.DurationVar(&lockTimeout, "lock-timeout", 0, "how long to wait for busy container")
+	var webhooksPath string
+	flag.StringVar(&webhooksPath, "webhooks", "", "file with webhooks that deployment events are posted to")
 	flag.Parse()
 
 	workspace, err := validateWorkspace(workspace)
 	if err != nil {
 		return err
 	}
-	handler, err := setupServerHandler(workspace)
+	var router manage.Router
+	if proxyMode != "" {
+		proxyRouter := proxy.NewRouter(proxy.Mode(proxyMode), "", 0)
+		defer proxyRouter.Close()
+		router = proxyRouter
+	}
+	var notifier manage.Notifier
+	if webhooksPath != "" {
+		webhookNotifier, err := makeNotifier(webhooksPath)
+		if err != nil {
+			return err
+		}
+		defer webhookNotifier.Close()
+		notifier = webhookNotifier
+	}
+	handler, err := setupServerHandler(workspace, contextName, podmanHost, router, lockTimeout, notifier)
 	if err != nil {
 		return err
 	}
@@ -2,14 +2,15 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/DmitryBogomolov/containerator/core"
 	"github.com/DmitryBogomolov/containerator/manage"
-	"github.com/docker/docker/client"
 )
 
 func parseBool(value string) bool {
@@ -17,8 +18,11 @@ func parseBool(value string) bool {
 	return ret
 }
 
-func getTag(cli client.ImageAPIClient, container core.Container) string {
+func getTag(cli core.Runtime, container core.Container) string {
 	image, err := core.FindImageByID(cli, container.ImageID())
+	if errors.Is(err, core.ErrNotFound) {
+		return ""
+	}
 	if err != nil {
 		return fmt.Sprintf("error(%+v)", err)
 	}
@@ -55,8 +59,32 @@ func parseRequestBody(body io.ReadCloser) *manage.Options {
 	return &options
 }
 
-func invokeManage(cli any, configPath string, r *http.Request) (map[string]any, error) {
+// getActor returns who sends request: basic auth user, "X-Actor" header or remote address.
+//
+// Header is taken only if request has no credentials, so it cannot override authenticated user.
+func getActor(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	if r.Header.Get("Authorization") != "" {
+		return r.RemoteAddr
+	}
+	if actor := r.Header.Get("X-Actor"); actor != "" {
+		return actor
+	}
+	return r.RemoteAddr
+}
+
+func invokeManage(
+	cli core.Runtime, configPath string, r *http.Request, router manage.Router,
+	history *manage.HistoryOptions, lock *manage.LockOptions, notifier manage.Notifier,
+) (map[string]any, error) {
 	options := parseRequestBody(r.Body)
+	options.Lock = lock
+	options.Router = router
+	options.History = history
+	options.Notifier = notifier
+	options.Actor = getActor(r)
 	config, err := manage.ReadConfig(configPath)
 	if err != nil {
 		return nil, err
@@ -68,16 +96,155 @@ func invokeManage(cli any, configPath string, r *http.Request) (map[string]any,
 	return map[string]any{
 		"name":  container.Name(),
 		"image": config.ImageName,
-		"tag":   getTag(cli.(client.ImageAPIClient), container),
+		"tag":   getTag(cli, container),
+	}, nil
+}
+
+func invokeRollback(
+	cli core.Runtime, configPath string, r *http.Request, router manage.Router,
+	history *manage.HistoryOptions, lock *manage.LockOptions, notifier manage.Notifier,
+) (map[string]any, error) {
+	options := parseRequestBody(r.Body)
+	options.Lock = lock
+	options.Router = router
+	options.History = history
+	options.Notifier = notifier
+	options.Actor = getActor(r)
+	config, err := manage.ReadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	container, err := manage.Rollback(cli, config, options)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"name":  container.Name(),
+		"image": config.ImageName,
+		"tag":   getTag(cli, container),
+	}, nil
+}
+
+func invokeRecover(
+	cli core.Runtime, configPath string, r *http.Request, lock *manage.LockOptions, notifier manage.Notifier,
+) (map[string]any, error) {
+	options := parseRequestBody(r.Body)
+	options.Lock = lock
+	options.Notifier = notifier
+	options.Actor = getActor(r)
+	config, err := manage.ReadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	recovery, err := manage.Recover(cli, config, options)
+	if err != nil {
+		return nil, err
+	}
+	resumed := ""
+	if recovery.Resumed != nil {
+		resumed = recovery.Resumed.Name()
+	}
+	return map[string]any{
+		"resumed": resumed,
+		"cleaned": core.TransformSlice(recovery.Cleaned, core.Container.Name),
+	}, nil
+}
+
+func getHistory(configPath string, r *http.Request, history *manage.HistoryOptions) (map[string]any, error) {
+	config, err := manage.ReadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	name, err := manage.ContainerName(config, &manage.Options{Postfix: r.URL.Query().Get("postfix")})
+	if err != nil {
+		return nil, err
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	records, err := manage.History(&manage.HistoryQuery{Dir: history.Dir, Container: name, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []manage.HistoryRecord{}
+	}
+	return map[string]any{
+		"records": records,
+	}, nil
+}
+
+func getVersions(cli core.Runtime, configPath string, r *http.Request) (map[string]any, error) {
+	config, err := manage.ReadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := manage.Versions(cli, config, &manage.Options{Postfix: r.URL.Query().Get("postfix")})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"versions": core.TransformSlice(versions, func(version manage.Version) map[string]any {
+			return map[string]any{
+				"name":       version.Container.Name(),
+				"tag":        getTag(cli, version.Container),
+				"deployTime": version.DeployTime,
+			}
+		}),
+	}, nil
+}
+
+// parseGCPolicy reads garbage collection policy from request body: "keepLast", "keepDays", "apply".
+func parseGCPolicy(body io.ReadCloser) *manage.GCPolicy {
+	policy := manage.GCPolicy{}
+	var data map[string]any
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(&data); err != nil {
+		return &policy
+	}
+	if val, ok := data["keepLast"]; ok {
+		if keepLast, ok := val.(float64); ok {
+			policy.KeepLast = int(keepLast)
+		}
+	}
+	if val, ok := data["keepDays"]; ok {
+		if keepDays, ok := val.(float64); ok {
+			policy.KeepYounger = time.Duration(keepDays * float64(24*time.Hour))
+		}
+	}
+	if val, ok := data["apply"]; ok {
+		if apply, ok := val.(bool); ok {
+			policy.Apply = apply
+		}
+	}
+	return &policy
+}
+
+func invokeCollectGarbage(
+	cli core.Runtime, configPath string, r *http.Request, lock *manage.LockOptions,
+) (map[string]any, error) {
+	policy := parseGCPolicy(r.Body)
+	config, err := manage.ReadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	options := &manage.Options{Postfix: r.URL.Query().Get("postfix"), Lock: lock}
+	report, err := manage.CollectGarbage(cli, config, policy, options)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"dryRun":       report.DryRun,
+		"removed":      core.TransformSlice(report.Removed, core.Image.Tag),
+		"kept":         core.TransformSlice(report.Kept, core.Image.Tag),
+		"maxReclaimed": report.MaxReclaimed,
 	}, nil
 }
 
-func getImageInfo(cli any, configPath string) (map[string]any, error) {
+func getImageInfo(cli core.Runtime, configPath string) (map[string]any, error) {
 	config, err := manage.ReadConfig(configPath)
 	if err != nil {
 		return nil, err
 	}
-	images, err := core.FindAllImagesByName(cli.(client.ImageAPIClient), config.ImageName)
+	images, err := core.FindAllImagesByName(cli, config.ImageName)
 	if err != nil {
 		return nil, err
 	}
@@ -5,16 +5,20 @@ import (
 	"encoding/hex"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/DmitryBogomolov/containerator/examples/manage_container_server/logger"
+	"github.com/DmitryBogomolov/containerator/manage"
 )
 
 const (
 	registryRefreshInterval   = 10 * time.Second
 	collectProjectsRetryCount = 3
+	// Project config is "sandbox-config" file with one of config extensions.
+	configFileName = "sandbox-config"
 )
 
 type Item struct {
@@ -44,21 +48,41 @@ func collectConfigFiles(pattern string) []string {
 }
 
 func collectItems(workspace string) []Item {
-	configFiles := collectConfigFiles(filepath.Join(workspace, "*", "*.yaml"))
-	items := make([]Item, len(configFiles))
-	names := make([]string, len(configFiles))
-	for i, configPath := range configFiles {
+	var configFiles []string
+	for _, ext := range manage.ConfigExtensions {
+		configFiles = append(configFiles, collectConfigFiles(filepath.Join(workspace, "*", configFileName+ext))...)
+	}
+	items := make([]Item, 0, len(configFiles))
+	names := make([]string, 0, len(configFiles))
+	for _, configPath := range configFiles {
 		name := filepath.Base(filepath.Dir(configPath))
-		items[i] = Item{
+		// Project is defined by the first config file (by extension order).
+		if findItem(items, name) >= 0 {
+			continue
+		}
+		items = append(items, Item{
 			Name:       name,
 			ConfigPath: configPath,
-		}
-		names[i] = name
+		})
+		names = append(names, name)
 	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	sort.Strings(names)
 	logger.Printf("refresh\n  %s\n", strings.Join(names, ", "))
 	return items
 }
 
+func findItem(items []Item, name string) int {
+	for i, item := range items {
+		if item.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
 func invokeRegistryRefresh(registry *Registry) {
 	registry.refreshLock.Lock()
 	defer registry.refreshLock.Unlock()
@@ -92,10 +116,8 @@ func (registry *Registry) Items() []Item {
 }
 
 func (registry *Registry) GetItem(name string) (Item, error) {
-	for _, item := range registry.items {
-		if item.Name == name {
-			return item, nil
-		}
+	if idx := findItem(registry.items, name); idx >= 0 {
+		return registry.items[idx], nil
 	}
 	return Item{}, fmt.Errorf("'%s' not found", name)
 }
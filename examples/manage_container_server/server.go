@@ -3,15 +3,26 @@ package main
 import (
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"html/template"
 	"net/http"
+	"path/filepath"
+	"time"
 
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/DmitryBogomolov/containerator/core/podman"
 	"github.com/DmitryBogomolov/containerator/examples/manage_container_server/logger"
 	"github.com/DmitryBogomolov/containerator/examples/manage_container_server/registry"
-	"github.com/docker/docker/client"
+	"github.com/DmitryBogomolov/containerator/manage"
 	"github.com/gorilla/mux"
 )
 
+// historyDirName defines workspace directory where operations are recorded.
+const historyDirName = ".history"
+
+// lockDirName defines workspace directory with lock files.
+const lockDirName = ".locks"
+
 //go:embed static/index.js
 var indexContent string
 
@@ -37,7 +48,35 @@ func sendJSON(value any, w http.ResponseWriter) {
 	w.Write([]byte("\n"))
 }
 
-func makeAPIManageContainerHandler(registry *registry.Registry, cli any) http.Handler {
+func makeAPIManageContainerHandler(
+	registry *registry.Registry, cli core.Runtime, router manage.Router,
+	history *manage.HistoryOptions, lock *manage.LockOptions, notifier manage.Notifier,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targetName := mux.Vars(r)["name"]
+		registry.Refresh()
+		item, err := registry.GetItem(targetName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		data, err := invokeManage(cli, item.ConfigPath, r, router, history, lock, notifier)
+		if errors.Is(err, core.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(data, w)
+	})
+}
+
+func makeAPIRollbackHandler(
+	registry *registry.Registry, cli core.Runtime, router manage.Router,
+	history *manage.HistoryOptions, lock *manage.LockOptions, notifier manage.Notifier,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		targetName := mux.Vars(r)["name"]
 		registry.Refresh()
@@ -46,7 +85,15 @@ func makeAPIManageContainerHandler(registry *registry.Registry, cli any) http.Ha
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
-		data, err := invokeManage(cli, item.ConfigPath, r)
+		data, err := invokeRollback(cli, item.ConfigPath, r, router, history, lock, notifier)
+		if errors.Is(err, core.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, core.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -55,7 +102,89 @@ func makeAPIManageContainerHandler(registry *registry.Registry, cli any) http.Ha
 	})
 }
 
-func makeAPIImageInfoHandler(registry *registry.Registry, cli any) http.Handler {
+func makeAPIHistoryHandler(registry *registry.Registry, history *manage.HistoryOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targetName := mux.Vars(r)["name"]
+		registry.Refresh()
+		item, err := registry.GetItem(targetName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		data, err := getHistory(item.ConfigPath, r, history)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(data, w)
+	})
+}
+
+func makeAPIRecoverHandler(
+	registry *registry.Registry, cli core.Runtime, lock *manage.LockOptions, notifier manage.Notifier,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targetName := mux.Vars(r)["name"]
+		registry.Refresh()
+		item, err := registry.GetItem(targetName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		data, err := invokeRecover(cli, item.ConfigPath, r, lock, notifier)
+		if errors.Is(err, core.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(data, w)
+	})
+}
+
+func makeAPIVersionsHandler(registry *registry.Registry, cli core.Runtime) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targetName := mux.Vars(r)["name"]
+		registry.Refresh()
+		item, err := registry.GetItem(targetName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		data, err := getVersions(cli, item.ConfigPath, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(data, w)
+	})
+}
+
+func makeAPICollectGarbageHandler(registry *registry.Registry, cli core.Runtime, lock *manage.LockOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targetName := mux.Vars(r)["name"]
+		registry.Refresh()
+		item, err := registry.GetItem(targetName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		data, err := invokeCollectGarbage(cli, item.ConfigPath, r, lock)
+		if errors.Is(err, core.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(data, w)
+	})
+}
+
+func makeAPIImageInfoHandler(registry *registry.Registry, cli core.Runtime) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		targetName := mux.Vars(r)["name"]
 		registry.Refresh()
@@ -90,10 +219,26 @@ func attachLoggerToHandler(h http.Handler) http.Handler {
 	})
 }
 
-func setupServerHandler(pathToWorkspace string) (http.Handler, error) {
+func makeRuntime(contextName string, podmanHost string) (core.Runtime, error) {
+	if podmanHost != "" {
+		return podman.NewRuntime(podmanHost)
+	}
+	cli, err := core.NewClient(&core.ClientOptions{Context: contextName})
+	if err != nil {
+		return nil, err
+	}
+	return cli, nil
+}
+
+func setupServerHandler(
+	pathToWorkspace string, contextName string, podmanHost string, router manage.Router, lockTimeout time.Duration,
+	notifier manage.Notifier,
+) (http.Handler, error) {
 	registry := registry.New(pathToWorkspace)
+	history := &manage.HistoryOptions{Dir: filepath.Join(pathToWorkspace, historyDirName)}
+	lock := &manage.LockOptions{Dir: filepath.Join(pathToWorkspace, lockDirName), Timeout: lockTimeout}
 
-	cli, err := client.NewEnvClient()
+	cli, err := makeRuntime(contextName, podmanHost)
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +252,27 @@ func setupServerHandler(pathToWorkspace string) (http.Handler, error) {
 	server.NewRoute().
 		Path("/api/manage-container/{name}").
 		Methods(http.MethodPost).
-		Handler(makeAPIManageContainerHandler(registry, cli))
+		Handler(makeAPIManageContainerHandler(registry, cli, router, history, lock, notifier))
+	server.NewRoute().
+		Path("/api/rollback/{name}").
+		Methods(http.MethodPost).
+		Handler(makeAPIRollbackHandler(registry, cli, router, history, lock, notifier))
+	server.NewRoute().
+		Path("/api/history/{name}").
+		Methods(http.MethodGet).
+		Handler(makeAPIHistoryHandler(registry, history))
+	server.NewRoute().
+		Path("/api/recover/{name}").
+		Methods(http.MethodPost).
+		Handler(makeAPIRecoverHandler(registry, cli, lock, notifier))
+	server.NewRoute().
+		Path("/api/versions/{name}").
+		Methods(http.MethodGet).
+		Handler(makeAPIVersionsHandler(registry, cli))
+	server.NewRoute().
+		Path("/api/collect-garbage/{name}").
+		Methods(http.MethodPost).
+		Handler(makeAPICollectGarbageHandler(registry, cli, lock))
 	server.NewRoute().
 		Path("/api/image-info/{name}").
 		Methods(http.MethodGet).
@@ -8,7 +8,6 @@ import (
 
 	"github.com/DmitryBogomolov/containerator/core"
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
 )
 
 func run() error {
@@ -34,11 +33,10 @@ func run() error {
 		return nil
 	}
 
-	cli, err := client.NewEnvClient()
+	cli, err := core.NewClient(&core.ClientOptions{})
 	if err != nil {
 		return err
 	}
-
 	options := core.RunContainerOptions{
 		Image:         imageName,
 		Name:          containerName,
@@ -3,6 +3,7 @@ module github.com/DmitryBogomolov/containerator
 go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/docker/docker v25.0.3+incompatible
 	github.com/docker/go-connections v0.4.0
 	github.com/golang/mock v1.6.0
@@ -10,6 +11,7 @@ require (
 	github.com/opencontainers/image-spec v1.1.0
 	github.com/stretchr/testify v1.8.4
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -37,6 +39,5 @@ require (
 	golang.org/x/sys v0.17.0 // indirect
 	golang.org/x/time v0.0.0-20220411224347-583f2d630306 // indirect
 	golang.org/x/tools v0.8.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	gotest.tools/v3 v3.2.0 // indirect
 )
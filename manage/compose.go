@@ -0,0 +1,435 @@
+package manage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"gopkg.in/yaml.v3"
+)
+
+// Origin describes where config value is defined.
+type Origin struct {
+	File string // Path to config file
+	Line int    // Line in config file; 0 if unknown (toml)
+}
+
+func (origin Origin) String() string {
+	if origin.Line == 0 {
+		return origin.File
+	}
+	return fmt.Sprintf("%s:%d", origin.File, origin.Line)
+}
+
+// ValueOrigin contains config value and its origin.
+type ValueOrigin struct {
+	Path   string // Path to value, e.g. "image_name", "env[A]", "profiles.dev.ports[9001]"
+	Value  string // Value
+	Origin Origin // Where value is defined
+}
+
+// ConfigCycleError is returned when configs extend or include each other.
+type ConfigCycleError struct {
+	files []string
+}
+
+func (err ConfigCycleError) Error() string {
+	return fmt.Sprintf("config cycle: %s", strings.Join(err.files, " -> "))
+}
+
+// Files returns chain of files that forms the cycle.
+func (err ConfigCycleError) Files() []string {
+	return err.files
+}
+
+// getListKey returns function that makes key of list item; items with the same key are merged.
+func getListKey(field string) func(core.Mapping) string {
+	if field == "volumes" {
+		return getVolumePath
+	}
+	return getMappingSource
+}
+
+func findField(node *yaml.Node, name string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == name {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// getFieldName returns name of config field as it is written in file.
+func getFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name
+}
+
+/*
+walkConfigValue calls function for each non empty value of config.
+
+List items are identified by their keys: "env[A]", "ports[9001]", "volumes[/data]", "env_file[/path/to/env.list]",
+structure items - by their indexes: "hooks.post_deploy[0].command";
+mappings are shown in docker style: "A=1", "9001:80", "/path/to/data:/data".
+Node that value is decoded from is passed if it is known.
+*/
+func walkConfigValue(value reflect.Value, node *yaml.Node, path string, fn func(string, string, *yaml.Node)) {
+	if node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	itemNode := func(i int) *yaml.Node {
+		if node != nil && node.Kind == yaml.SequenceNode && i < len(node.Content) {
+			return node.Content[i]
+		}
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.Ptr:
+		if !value.IsNil() {
+			walkConfigValue(value.Elem(), node, path, fn)
+		}
+	case reflect.Struct:
+		typ := value.Type()
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := getFieldName(field)
+			walkConfigValue(value.Field(i), findField(node, name), joinPath(path, name), fn)
+		}
+	case reflect.Slice:
+		if mappings, ok := value.Interface().([]core.Mapping); ok {
+			field := path[strings.LastIndex(path, ".")+1:]
+			getKey := getListKey(field)
+			separator := ":"
+			if field == "env" {
+				separator = "="
+			}
+			for i, mapping := range mappings {
+				fn(fmt.Sprintf("%s[%s]", path, getKey(mapping)), mapping.Source+separator+mapping.Target, itemNode(i))
+			}
+			return
+		}
+		for i := 0; i < value.Len(); i++ {
+			if value.Index(i).Kind() == reflect.Struct {
+				walkConfigValue(value.Index(i), itemNode(i), fmt.Sprintf("%s[%d]", path, i), fn)
+				continue
+			}
+			item := fmt.Sprint(value.Index(i).Interface())
+			fn(fmt.Sprintf("%s[%s]", path, item), item, itemNode(i))
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		for _, key := range keys {
+			walkConfigValue(value.MapIndex(key), findField(node, key.String()), joinPath(path, key.String()), fn)
+		}
+	default:
+		if !value.IsZero() {
+			fn(path, fmt.Sprint(value.Interface()), node)
+		}
+	}
+}
+
+// _ValueKey identifies config string value; part is "source" or "target" for mappings.
+type _ValueKey struct {
+	path string
+	part string
+}
+
+/*
+mapConfigStrings makes copy of config value with all strings replaced by function.
+
+Values are identified by the same paths that are used for config origins.
+
+	mapConfigStrings(HookStep{Command: "echo ${TAG}"}, "hooks.post_deploy[0]", interpolate) -> HookStep{Command: "echo 2"}
+*/
+func mapConfigStrings(
+	value reflect.Value, path string, fn func(_ValueKey, string) (string, error),
+) (reflect.Value, error) {
+	switch value.Kind() {
+	case reflect.String:
+		text, err := fn(_ValueKey{path: path}, value.String())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		result := reflect.New(value.Type()).Elem()
+		result.SetString(text)
+		return result, nil
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value, nil
+		}
+		elem, err := mapConfigStrings(value.Elem(), path, fn)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		result := reflect.New(value.Type().Elem())
+		result.Elem().Set(elem)
+		return result, nil
+	case reflect.Struct:
+		result := reflect.New(value.Type()).Elem()
+		result.Set(value)
+		typ := value.Type()
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			fieldValue, err := mapConfigStrings(value.Field(i), joinPath(path, getFieldName(field)), fn)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.Field(i).Set(fieldValue)
+		}
+		return result, nil
+	case reflect.Slice:
+		if value.IsNil() {
+			return value, nil
+		}
+		result := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		if mappings, ok := value.Interface().([]core.Mapping); ok {
+			getKey := getListKey(path[strings.LastIndex(path, ".")+1:])
+			for i, mapping := range mappings {
+				itemPath := fmt.Sprintf("%s[%s]", path, getKey(mapping))
+				source, err := fn(_ValueKey{itemPath, "source"}, mapping.Source)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				target, err := fn(_ValueKey{itemPath, "target"}, mapping.Target)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				result.Index(i).Set(reflect.ValueOf(core.Mapping{Source: source, Target: target}))
+			}
+			return result, nil
+		}
+		for i := 0; i < value.Len(); i++ {
+			item := value.Index(i)
+			itemPath := fmt.Sprintf("%s[%v]", path, item.Interface())
+			if item.Kind() == reflect.Struct {
+				itemPath = fmt.Sprintf("%s[%d]", path, i)
+			}
+			itemValue, err := mapConfigStrings(item, itemPath, fn)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.Index(i).Set(itemValue)
+		}
+		return result, nil
+	case reflect.Map:
+		if value.IsNil() {
+			return value, nil
+		}
+		result := reflect.MakeMapWithSize(value.Type(), value.Len())
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		for _, key := range keys {
+			itemValue, err := mapConfigStrings(value.MapIndex(key), joinPath(path, key.String()), fn)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.SetMapIndex(key, itemValue)
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+func collectOrigins(cfg *Config, node *yaml.Node, pathToFile string) map[string]Origin {
+	origins := map[string]Origin{}
+	walkConfigValue(reflect.ValueOf(cfg).Elem(), node, "", func(path string, _ string, valueNode *yaml.Node) {
+		origin := Origin{File: pathToFile}
+		if valueNode != nil {
+			origin.Line = valueNode.Line
+		}
+		origins[path] = origin
+	})
+	return origins
+}
+
+func mergeProfiles(base *Profile, other *Profile) *Profile {
+	if base == nil {
+		return other
+	}
+	if other == nil {
+		return base
+	}
+	result := *base
+	if other.Merge != "" {
+		result.Merge = other.Merge
+	}
+	if other.Network != "" {
+		result.Network = other.Network
+	}
+	result.Ports = mergeMappings(base.Ports, other.Ports, MergeAppend, getContainerPort)
+	result.Volumes = mergeMappings(base.Volumes, other.Volumes, MergeAppend, getVolumePath)
+	result.Env = mergeMappings(base.Env, other.Env, MergeAppend, getMappingSource)
+	result.EnvFile = mergeStrings(base.EnvFile, other.EnvFile, MergeAppend)
+	return &result
+}
+
+// mergeConfigs combines configs; values of the other config have priority.
+//
+// List items with the same key (variable name, container port, container path) are overridden, other items are appended.
+func mergeConfigs(base *Config, other *Config) *Config {
+	result := *base
+	for _, field := range []struct{ target, source *string }{
+		{&result.ImageName, &other.ImageName},
+		{&result.ContainerName, &other.ContainerName},
+		{&result.Network, &other.Network},
+		{&result.Restart, &other.Restart},
+		{&result.StopSignal, &other.StopSignal},
+		{&result.StopTimeout, &other.StopTimeout},
+	} {
+		if *field.source != "" {
+			*field.target = *field.source
+		}
+	}
+	if other.RestartMaxRetries != 0 {
+		result.RestartMaxRetries = other.RestartMaxRetries
+	}
+	result.Ports = mergeMappings(base.Ports, other.Ports, MergeAppend, getContainerPort)
+	result.Volumes = mergeMappings(base.Volumes, other.Volumes, MergeAppend, getVolumePath)
+	result.Env = mergeMappings(base.Env, other.Env, MergeAppend, getMappingSource)
+	result.EnvFile = mergeStrings(base.EnvFile, other.EnvFile, MergeAppend)
+	if other.PortOffsets != nil {
+		result.PortOffsets = map[string]int{}
+		for key, value := range base.PortOffsets {
+			result.PortOffsets[key] = value
+		}
+		for key, value := range other.PortOffsets {
+			result.PortOffsets[key] = value
+		}
+	}
+	if other.Deploy != nil {
+		result.Deploy = other.Deploy
+	}
+	if other.Hooks != nil {
+		result.Hooks = other.Hooks
+	}
+	if other.Webhooks != nil {
+		result.Webhooks = other.Webhooks
+	}
+	if other.Profiles != nil {
+		result.Profiles = map[string]*Profile{}
+		for key, value := range base.Profiles {
+			result.Profiles[key] = value
+		}
+		for key, value := range other.Profiles {
+			result.Profiles[key] = mergeProfiles(base.Profiles[key], value)
+		}
+	}
+	result.origins = map[string]Origin{}
+	for _, origins := range []map[string]Origin{base.origins, other.origins} {
+		for key, value := range origins {
+			result.origins[key] = value
+		}
+	}
+	return &result
+}
+
+// loadConfigFile reads single config file; relative paths are resolved against directory of the file.
+func loadConfigFile(pathToFile string) (*Config, error) {
+	bytes, err := ioutil.ReadFile(pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	dir, _ := filepath.Abs(filepath.Dir(pathToFile))
+	root, err := parseConfigNode(bytes, GetConfigFormat(pathToFile))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pathToFile, err)
+	}
+	var cfg Config
+	var node *yaml.Node
+	if root.Kind != 0 {
+		if err := interpolateConfigNode(root, pathToFile, dir); err != nil {
+			return nil, err
+		}
+		if err := root.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", pathToFile, err)
+		}
+		node = root.Content[0]
+	}
+	processConfig(&cfg, dir)
+	if cfg.Extends != "" {
+		cfg.Extends = resolvePath(cfg.Extends, dir)
+	}
+	for i, item := range cfg.Include {
+		cfg.Include[i] = resolvePath(item, dir)
+	}
+	cfg.origins = collectOrigins(&cfg, node, pathToFile)
+	cfg.raw = collectRawValues(&cfg, bytes, GetConfigFormat(pathToFile))
+	return &cfg, nil
+}
+
+// loadConfig reads config file with its parent config and included fragments.
+//
+// Parent config is applied first, then fragments in order, then the file itself.
+func loadConfig(pathToFile string, chain []string) (*Config, error) {
+	absPath, _ := filepath.Abs(pathToFile)
+	for i, item := range chain {
+		if item == absPath {
+			return nil, &ConfigCycleError{append(append([]string{}, chain[i:]...), absPath)}
+		}
+	}
+	chain = append(chain, absPath)
+	cfg, err := loadConfigFile(pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	var parts []*Config
+	if cfg.Extends != "" {
+		parent, err := loadConfig(cfg.Extends, chain)
+		if err != nil {
+			return nil, fmt.Errorf("%s: extends: %w", pathToFile, err)
+		}
+		parts = append(parts, parent)
+	}
+	for _, item := range cfg.Include {
+		fragment, err := loadConfig(item, chain)
+		if err != nil {
+			return nil, fmt.Errorf("%s: include: %w", pathToFile, err)
+		}
+		parts = append(parts, fragment)
+	}
+	if len(parts) == 0 {
+		return cfg, nil
+	}
+	result := parts[0]
+	for _, part := range append(parts[1:], cfg) {
+		result = mergeConfigs(result, part)
+	}
+	result.Extends = ""
+	result.Include = nil
+	// Values of other files are kept resolved - their raw values are relative to those files.
+	result.raw = cfg.raw
+	return result, nil
+}
+
+// Explain returns final config values with their origins.
+//
+//	cfg.Explain() -> []ValueOrigin{{"image_name", "nginx", Origin{"base.yaml", 1}}, {"env[A]", "A=1", Origin{"config.yaml", 5}}}
+func (cfg *Config) Explain() []ValueOrigin {
+	var result []ValueOrigin
+	walkConfigValue(reflect.ValueOf(cfg).Elem(), nil, "", func(path string, value string, _ *yaml.Node) {
+		result = append(result, ValueOrigin{Path: path, Value: value, Origin: cfg.origins[path]})
+	})
+	return result
+}
@@ -0,0 +1,139 @@
+package manage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFiles(t *testing.T, files map[string][]string) string {
+	dir := t.TempDir()
+	for name, lines := range files {
+		pathToFile := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(pathToFile), os.ModePerm))
+		assert.NoError(t, os.WriteFile(pathToFile, []byte(strings.Join(lines, "\n")), os.ModePerm))
+	}
+	return dir
+}
+
+func TestReadConfigComposition(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]string{
+		"shared/base.yaml": {
+			"image_name: test-image",
+			"network: base-net",
+			"ports:",
+			"- 5001: 80",
+			"volumes:",
+			"- ./data: /data",
+			"env:",
+			"- A: 1",
+			"- B: 2",
+			"profiles:",
+			"  prod:",
+			"    env:",
+			"    - P: 1",
+		},
+		"shared/logging.yaml": {
+			"env:",
+			"- LOG: info",
+			"env_file:",
+			"- ./logging.list",
+		},
+		"project/config.yaml": {
+			"extends: ../shared/base.yaml",
+			"include:",
+			"- ../shared/logging.yaml",
+			"container_name: app",
+			"volumes:",
+			"- ./html:/data",
+			"env:",
+			"- B: 3",
+			"profiles:",
+			"  prod:",
+			"    network: prod-net",
+		},
+	})
+
+	config, err := ReadConfig(filepath.Join(dir, "project/config.yaml"))
+	explanation := config.Explain()
+
+	assert.NoError(t, err)
+	base := filepath.Join(dir, "shared/base.yaml")
+	logging := filepath.Join(dir, "shared/logging.yaml")
+	project := filepath.Join(dir, "project/config.yaml")
+	assert.Equal(t, &Config{
+		ImageName:     "test-image",
+		ContainerName: "app",
+		Network:       "base-net",
+		Ports:         []core.Mapping{{Source: "5001", Target: "80"}},
+		Volumes:       []core.Mapping{{Source: filepath.Join(dir, "project/html"), Target: "/data"}},
+		Env: []core.Mapping{
+			{Source: "A", Target: "1"}, {Source: "B", Target: "3"}, {Source: "LOG", Target: "info"},
+		},
+		EnvFile: []string{filepath.Join(dir, "shared/logging.list")},
+		Profiles: map[string]*Profile{
+			"prod": {Network: "prod-net", Env: []core.Mapping{{Source: "P", Target: "1"}}},
+		},
+	}, stripOrigins(config))
+	assert.Equal(t, []ValueOrigin{
+		{"image_name", "test-image", Origin{base, 1}},
+		{"container_name", "app", Origin{project, 4}},
+		{"network", "base-net", Origin{base, 2}},
+		{"ports[5001]", "5001:80", Origin{base, 4}},
+		{"volumes[/data]", filepath.Join(dir, "project/html") + ":/data", Origin{project, 6}},
+		{"env[A]", "A=1", Origin{base, 8}},
+		{"env[B]", "B=3", Origin{project, 8}},
+		{"env[LOG]", "LOG=info", Origin{logging, 2}},
+		{"env_file[" + filepath.Join(dir, "shared/logging.list") + "]", filepath.Join(dir, "shared/logging.list"), Origin{logging, 4}},
+		{"profiles.prod.network", "prod-net", Origin{project, 11}},
+		{"profiles.prod.env[P]", "P=1", Origin{base, 13}},
+	}, explanation)
+}
+
+func TestReadConfigCycle(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]string{
+		"a.yaml": {"extends: ./b.yaml"},
+		"b.yaml": {"include:", "- ./c.yaml"},
+		"c.yaml": {"extends: a.yaml"},
+	})
+
+	_, err := ReadConfig(filepath.Join(dir, "a.yaml"))
+
+	var target *ConfigCycleError
+	assert.True(t, errors.As(err, &target))
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml"), filepath.Join(dir, "c.yaml"), filepath.Join(dir, "a.yaml"),
+	}, target.Files())
+}
+
+func TestReadConfigMissingParent(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]string{
+		"config.yaml": {"extends: ./missing.yaml"},
+	})
+
+	_, err := ReadConfig(filepath.Join(dir, "config.yaml"))
+
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.False(t, os.IsNotExist(err), "missing parent is not confused with missing config")
+}
+
+func TestExplainSingleFile(t *testing.T) {
+	pathToFile := writeTestFile(t, "config.yaml",
+		"image_name: test-image",
+		"ports:",
+		"- 9001:80",
+	)
+
+	config, err := ReadConfig(pathToFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []ValueOrigin{
+		{"image_name", "test-image", Origin{pathToFile, 1}},
+		{"ports[9001]", "9001:80", Origin{pathToFile, 3}},
+	}, config.Explain())
+}
@@ -1,46 +1,76 @@
 package manage
 
 import (
-	"io/ioutil"
 	"path/filepath"
 
 	"github.com/DmitryBogomolov/containerator/core"
-	"gopkg.in/yaml.v2"
 )
 
 // Config contains options for container management.
 type Config struct {
-	ImageName     string         `yaml:"image_name"`               // Image name; required
-	ContainerName string         `yaml:"container_name,omitempty"` // Container name
-	Network       string         `yaml:",omitempty"`               // Container network
-	Ports         []core.Mapping `yaml:",omitempty"`               // Ports mapping
-	Volumes       []core.Mapping `yaml:",omitempty"`               // Volumes mapping
-	Env           []core.Mapping `yaml:",omitempty"`               // Environment variables
+	ImageName     string         `yaml:"image_name" json:"image_name" toml:"image_name"`                                           // Image name; required
+	ContainerName string         `yaml:"container_name,omitempty" json:"container_name,omitempty" toml:"container_name,omitempty"` // Container name
+	Network       string         `yaml:",omitempty" json:"network,omitempty" toml:"network,omitempty"`                             // Container network
+	Ports         []core.Mapping `yaml:",omitempty" json:"ports,omitempty" toml:"ports,omitempty"`                                 // Ports mapping
+	Volumes       []core.Mapping `yaml:",omitempty" json:"volumes,omitempty" toml:"volumes,omitempty"`                             // Volumes mapping
+	Env           []core.Mapping `yaml:",omitempty" json:"env,omitempty" toml:"env,omitempty"`                                     // Environment variables
+	EnvFile       []string       `yaml:"env_file,omitempty" json:"env_file,omitempty" toml:"env_file,omitempty"`                   // Env files; relative paths are resolved against config file
+	PortOffsets   map[string]int `yaml:"port_offsets,omitempty" json:"port_offsets,omitempty" toml:"port_offsets,omitempty"`       // Host ports offsets by postfix
+
+	Restart           string `yaml:",omitempty" json:"restart,omitempty" toml:"restart,omitempty"`                                           // Restart policy: "no", "always", "unless-stopped" or "on-failure"; "always" by default
+	RestartMaxRetries int    `yaml:"restart_max_retries,omitempty" json:"restart_max_retries,omitempty" toml:"restart_max_retries,omitzero"` // Maximum number of restarts for "on-failure" policy; not limited if not set
+	StopSignal        string `yaml:"stop_signal,omitempty" json:"stop_signal,omitempty" toml:"stop_signal,omitempty"`                        // Signal that stops container, e.g. "SIGINT"; image default if not set
+	StopTimeout       string `yaml:"stop_timeout,omitempty" json:"stop_timeout,omitempty" toml:"stop_timeout,omitempty"`                     // How long to wait for container to stop before it is killed, e.g. "30s"; "10s" by default
+
+	Profiles map[string]*Profile `yaml:",omitempty" json:"profiles,omitempty" toml:"profiles,omitempty"` // Overrides by postfix
+
+	Deploy *DeployConfig `yaml:",omitempty" json:"deploy,omitempty" toml:"deploy,omitempty"` // Deployment strategy
+	Hooks  *HooksConfig  `yaml:",omitempty" json:"hooks,omitempty" toml:"hooks,omitempty"`   // Steps run around deployment
+
+	Webhooks []Webhook `yaml:",omitempty" json:"webhooks,omitempty" toml:"webhooks,omitempty"` // Webhooks that events are posted to if Options.Notifier is not set
+
+	Extends string   `yaml:",omitempty" json:"extends,omitempty" toml:"extends,omitempty"` // Parent config; relative path is resolved against config file
+	Include []string `yaml:",omitempty" json:"include,omitempty" toml:"include,omitempty"` // Config fragments; relative paths are resolved against config file
+
+	origins map[string]Origin
+	raw     map[_ValueKey]_RawValue
 }
 
-// ReadConfig reads config from yaml file.
+// ReadConfig reads config from yaml, json or toml file; format is detected by file extension.
+//
+// String values are interpolated with environment variables ("${VAR}", "${VAR:-default}", "${VAR:?error}").
+// "${CONFIG_DIR}" is directory of config file; "${POSTFIX}" and "${TAG}" are resolved when container is run.
+//
+// Config can extend parent config ("extends") and include fragments ("include").
+// Parent config is applied first, then fragments in order, then the file itself. Origin of each value is kept.
 //
 //	ReadConfig("/path/to/config,yaml") -> &config, err
 func ReadConfig(pathToFile string) (*Config, error) {
-	bytes, err := ioutil.ReadFile(pathToFile)
-	if err != nil {
-		return nil, err
+	return loadConfig(pathToFile, nil)
+}
+
+func resolvePath(pathToFile string, dir string) string {
+	result := filepath.Clean(pathToFile)
+	if !filepath.IsAbs(result) {
+		result = filepath.Join(dir, result)
+	}
+	return result
+}
+
+func resolvePaths(volumes []core.Mapping, envFiles []string, dir string) {
+	for i, mapping := range volumes {
+		volumes[i].Source = resolvePath(mapping.Source, dir)
 	}
-	var cfg Config
-	err = yaml.Unmarshal(bytes, &cfg)
-	if err == nil {
-		curDir, _ := filepath.Abs(filepath.Dir(pathToFile))
-		processConfig(&cfg, curDir)
+	for i, pathToFile := range envFiles {
+		envFiles[i] = resolvePath(pathToFile, dir)
 	}
-	return &cfg, err
 }
 
 func processConfig(cfg *Config, dir string) {
-	for i, mapping := range cfg.Volumes {
-		hostPath := filepath.Clean(mapping.Source)
-		if !filepath.IsAbs(hostPath) {
-			hostPath = filepath.Join(dir, hostPath)
+	resolvePaths(cfg.Volumes, cfg.EnvFile, dir)
+	for _, profile := range cfg.Profiles {
+		if profile != nil {
+			resolvePaths(profile.Volumes, profile.EnvFile, dir)
 		}
-		cfg.Volumes[i].Source = hostPath
 	}
 }
@@ -76,6 +76,20 @@ func TestConfig(t *testing.T) {
 	})
 }
 
+// stripOrigins removes value origins and raw values from config; they are checked separately.
+func stripOrigins(config *Config) *Config {
+	if config != nil {
+		config.origins = nil
+		config.raw = nil
+	}
+	return config
+}
+
+func readTestConfig(pathToFile string) (*Config, error) {
+	config, err := ReadConfig(pathToFile)
+	return stripOrigins(config), err
+}
+
 func TestReadConfig(t *testing.T) {
 	t.Run("Read file", func(t *testing.T) {
 		testContent := strings.Join([]string{
@@ -86,7 +100,7 @@ func TestReadConfig(t *testing.T) {
 		ioutil.WriteFile(testFile, []byte(testContent), os.ModePerm)
 		defer os.Remove(testFile)
 
-		config, err := ReadConfig(testFile)
+		config, err := readTestConfig(testFile)
 
 		assert.NoError(t, err, "error")
 		assert.Equal(t, &Config{
@@ -108,12 +122,14 @@ func TestReadConfig(t *testing.T) {
 			"volumes:",
 			"- /a/b: /dir1",
 			"- ./a/b: /dir2",
+			"env_file:",
+			"- ./env.list",
 		}, "\n")
 		testFile := "test.yaml"
 		ioutil.WriteFile(testFile, []byte(testContent), os.ModePerm)
 		defer os.Remove(testFile)
 
-		config, err := ReadConfig(testFile)
+		config, err := readTestConfig(testFile)
 
 		assert.NoError(t, err, "error")
 		curDir, _ := filepath.Abs(".")
@@ -122,6 +138,45 @@ func TestReadConfig(t *testing.T) {
 				{Source: "/a/b", Target: "/dir1"},
 				{Source: filepath.Join(curDir, "./a/b"), Target: "/dir2"},
 			},
+			EnvFile: []string{filepath.Join(curDir, "env.list")},
+		}, config, "config")
+	})
+	t.Run("String mappings", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "test.yaml",
+			"image_name: test-image",
+			"ports:",
+			"- 9001:80",
+			"volumes:",
+			"- ./html:/usr/share/nginx/html:ro",
+			"env:",
+			"- A=1",
+			"- B: 2",
+		)
+
+		config, err := readTestConfig(pathToFile)
+
+		assert.NoError(t, err, "error")
+		assert.Equal(t, &Config{
+			ImageName: "test-image",
+			Ports:     []core.Mapping{{Source: "9001", Target: "80"}},
+			Volumes: []core.Mapping{
+				{Source: filepath.Join(filepath.Dir(pathToFile), "html"), Target: "/usr/share/nginx/html:ro"},
+			},
+			Env: []core.Mapping{{Source: "A", Target: "1"}, {Source: "B", Target: "2"}},
 		}, config, "config")
+		assert.NoError(t, ValidateConfig(pathToFile), "validation")
+	})
+
+	t.Run("Multiple keys", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "test.yaml",
+			"image_name: test-image",
+			"env:",
+			"- A: 1",
+			"  B: 2",
+		)
+
+		_, err := ReadConfig(pathToFile)
+
+		assert.ErrorContains(t, err, "mapping must contain single key-value pair, got A, B")
 	})
 }
@@ -0,0 +1,379 @@
+package manage
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
+)
+
+// DeployStrategy defines how running container is replaced.
+type DeployStrategy string
+
+// Deploy strategies.
+const (
+	// StrategyRecreate stops running container and then starts new one.
+	StrategyRecreate DeployStrategy = "recreate"
+	// StrategyBlueGreen starts new container next to running one and switches to it when it is healthy.
+	StrategyBlueGreen DeployStrategy = "blue-green"
+	// StrategyProxy is like StrategyBlueGreen but container ports are not published on configured host ports;
+	// Options.Router forwards configured host ports to active container.
+	StrategyProxy DeployStrategy = "proxy"
+)
+
+// Router forwards public host ports to container; it is implemented by proxy.Router.
+type Router interface {
+	// Switch forwards public ports to addresses and waits until connections to previous addresses are drained.
+	Switch(targets map[int]string) error
+}
+
+// Health gate defaults.
+const (
+	DefaultHealthTimeout  = 60 * time.Second
+	DefaultHealthInterval = time.Second
+	DefaultHealthHost     = "127.0.0.1"
+)
+
+// candidateSuffix is added to container name while new container is checked.
+const candidateSuffix = "-next"
+
+// DeployConfig contains deployment options.
+type DeployConfig struct {
+	Strategy DeployStrategy `yaml:",omitempty" json:"strategy,omitempty" toml:"strategy,omitempty"` // Deploy strategy; "recreate" by default
+	Health   *HealthGate    `yaml:",omitempty" json:"health,omitempty" toml:"health,omitempty"`     // Health gate for "blue-green" and "proxy" strategies
+	Keep     int            `yaml:",omitempty" json:"keep,omitempty" toml:"keep,omitempty"`         // Number of replaced containers kept stopped for rollback
+}
+
+/*
+HealthGate defines how new container is checked before it replaces running container.
+
+Container healthcheck (from image or engine) is used when container has it.
+Otherwise HTTP probe is used if path is set, TCP probe is used if container has ports.
+Container without healthcheck and ports passes when it keeps running.
+*/
+type HealthGate struct {
+	HTTP     string `yaml:",omitempty" json:"http,omitempty" toml:"http,omitempty"`         // HTTP probe path; 2xx and 3xx responses pass
+	Port     string `yaml:",omitempty" json:"port,omitempty" toml:"port,omitempty"`         // Container port to probe; first port by default
+	Host     string `yaml:",omitempty" json:"host,omitempty" toml:"host,omitempty"`         // Host that ports are published on; "127.0.0.1" by default
+	Timeout  string `yaml:",omitempty" json:"timeout,omitempty" toml:"timeout,omitempty"`   // How long to wait, e.g. "30s"; "60s" by default
+	Interval string `yaml:",omitempty" json:"interval,omitempty" toml:"interval,omitempty"` // Delay between checks, e.g. "500ms"; "1s" by default
+}
+
+func parseDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func getStrategy(deploy *DeployConfig) DeployStrategy {
+	if deploy == nil || deploy.Strategy == "" {
+		return StrategyRecreate
+	}
+	return deploy.Strategy
+}
+
+func getHealthGate(deploy *DeployConfig) *HealthGate {
+	if deploy == nil {
+		return nil
+	}
+	return deploy.Health
+}
+
+// findHostPort returns address that container port is published on.
+func findHostPort(info *types.ContainerJSON, port string) string {
+	if info.NetworkSettings == nil {
+		return ""
+	}
+	if port != "" {
+		proto, containerPort := nat.SplitProtoPort(port)
+		port = containerPort + "/" + proto
+	}
+	for containerPort, bindings := range info.NetworkSettings.Ports {
+		if (port == "" || string(containerPort) == port) && len(bindings) > 0 {
+			return bindings[0].HostPort
+		}
+	}
+	return ""
+}
+
+func probeTCP(address string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func probeHTTP(url string, timeout time.Duration) error {
+	client := http.Client{Timeout: timeout}
+	response, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s: status %d", url, response.StatusCode)
+	}
+	return nil
+}
+
+// checkHealth makes single check of container; returns true if container has passed the gate.
+//
+// Error is returned when container has definitely failed (exited or unhealthy).
+func checkHealth(info *types.ContainerJSON, gate *HealthGate, timeout time.Duration) (bool, string, error) {
+	if info.State == nil || !info.State.Running {
+		return false, "", fmt.Errorf("container is not running")
+	}
+	if info.State.Health != nil {
+		switch info.State.Health.Status {
+		case types.Healthy:
+			return true, "", nil
+		case types.Unhealthy:
+			return false, "", fmt.Errorf("container is unhealthy")
+		default:
+			return false, "container is " + info.State.Health.Status, nil
+		}
+	}
+	hostPort := findHostPort(info, gate.Port)
+	if hostPort == "" {
+		return true, "", nil
+	}
+	host := gate.Host
+	if host == "" {
+		host = DefaultHealthHost
+	}
+	address := net.JoinHostPort(host, hostPort)
+	var err error
+	if gate.HTTP != "" {
+		err = probeHTTP("http://"+address+"/"+strings.TrimPrefix(gate.HTTP, "/"), timeout)
+	} else {
+		err = probeTCP(address, timeout)
+	}
+	if err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// waitHealthy checks container until it passes the gate or timeout expires.
+func waitHealthy(cli core.Runtime, container core.Container, gate *HealthGate) error {
+	if gate == nil {
+		gate = &HealthGate{}
+	}
+	timeout, err := parseDuration(gate.Timeout, DefaultHealthTimeout)
+	if err != nil {
+		return err
+	}
+	interval, err := parseDuration(gate.Interval, DefaultHealthInterval)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		info, err := core.InspectContainer(cli, container)
+		if err != nil {
+			return err
+		}
+		ok, reason, err := checkHealth(info, gate, interval)
+		if err != nil {
+			return &HealthCheckError{container.Name(), err.Error()}
+		}
+		if ok {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return &HealthCheckError{container.Name(), fmt.Sprintf("timeout (%s)", reason)}
+		}
+		time.Sleep(interval)
+	}
+}
+
+// withDynamicPorts returns options where host ports are selected by engine.
+func withDynamicPorts(options *core.RunContainerOptions) *core.RunContainerOptions {
+	result := *options
+	result.Ports = make([]core.Mapping, len(options.Ports))
+	for i, mapping := range options.Ports {
+		result.Ports[i] = core.Mapping{Source: "", Target: mapping.Target}
+	}
+	return &result
+}
+
+func makeCandidateOptions(options *core.RunContainerOptions) *core.RunContainerOptions {
+	candidate := withDynamicPorts(options)
+	candidate.Name = options.Name + candidateSuffix
+	return candidate
+}
+
+// startCandidate starts new container with temporary name and host ports and waits until it passes health gate
+// and verify (if set).
+func startCandidate(
+	cli core.Runtime, options *core.RunContainerOptions, gate *HealthGate, verify func(core.Container) error,
+) (core.Container, error) {
+	candidate, err := core.RunContainer(cli, makeCandidateOptions(options))
+	if err != nil {
+		return nil, err
+	}
+	err = waitHealthy(cli, candidate, gate)
+	if err == nil && verify != nil {
+		err = verify(candidate)
+	}
+	if err != nil {
+		if otherErr := core.RemoveContainer(cli, candidate); otherErr != nil {
+			err = fmt.Errorf("%w (%v)", err, otherErr)
+		}
+		return nil, err
+	}
+	return candidate, nil
+}
+
+/*
+swapContainers gives name to new container and removes (or keeps) current container.
+
+If any step fails, new container is removed and current container is resumed.
+*/
+func swapContainers(
+	cli core.Runtime, candidate core.Container, currentContainer core.Container, name string, keep int,
+) (core.Container, error) {
+	rollback := func(err error, resume bool) error {
+		if otherErr := core.RemoveContainer(cli, candidate); otherErr != nil {
+			err = fmt.Errorf("%w (%v)", err, otherErr)
+		}
+		if resume && currentContainer != nil {
+			if otherErr := core.ResumeContainer(cli, currentContainer, name); otherErr != nil {
+				err = fmt.Errorf("%w (%v)", err, otherErr)
+			}
+		}
+		return err
+	}
+	if currentContainer != nil {
+		if err := core.SuspendContainer(cli, currentContainer); err != nil {
+			return nil, rollback(err, false)
+		}
+	}
+	if err := core.RenameContainer(cli, candidate, name); err != nil {
+		return nil, rollback(err, true)
+	}
+	if currentContainer != nil {
+		if err := retireContainer(cli, currentContainer, name, keep); err != nil {
+			return nil, rollback(err, true)
+		}
+	}
+	return core.FindContainerByID(cli, candidate.ID())
+}
+
+/*
+updateContainerBlueGreen replaces running container only after new container passes health gate.
+
+New container is started under temporary name and with dynamic host ports and checked with health gate.
+If it fails, it is removed and running container is left intact.
+Otherwise new container is renamed and running container is removed, so there is no downtime.
+Engine cannot change host ports of a container, so configured host ports are rejected by Plan.
+*/
+func updateContainerBlueGreen(
+	cli core.Runtime, options *core.RunContainerOptions, currentContainer core.Container,
+	gate *HealthGate, verify func(core.Container) error, keep int,
+) (core.Container, error) {
+	candidate, err := startCandidate(cli, options, gate, verify)
+	if err != nil {
+		return nil, err
+	}
+	return swapContainers(cli, candidate, currentContainer, options.Name, keep)
+}
+
+// getProxyTargets maps configured host ports to addresses that container ports are published on.
+func getProxyTargets(
+	info *types.ContainerJSON, ports []core.Mapping, host string,
+) (map[int]string, error) {
+	if host == "" {
+		host = DefaultHealthHost
+	}
+	targets := map[int]string{}
+	for _, mapping := range ports {
+		proto, containerPort := nat.SplitProtoPort(mapping.Target)
+		_, hostPort := core.SplitHostIP(mapping.Source)
+		hostStart, hostEnd, err := nat.ParsePortRange(hostPort)
+		if err != nil {
+			return nil, err
+		}
+		start, _, err := nat.ParsePortRange(containerPort)
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i <= hostEnd-hostStart; i++ {
+			port := fmt.Sprintf("%d/%s", start+i, proto)
+			hostPort := findHostPort(info, port)
+			if hostPort == "" {
+				return nil, fmt.Errorf("port %s is not published", port)
+			}
+			targets[int(hostStart+i)] = net.JoinHostPort(host, hostPort)
+		}
+	}
+	return targets, nil
+}
+
+/*
+updateContainerProxy starts new container like updateContainerBlueGreen and switches router to it.
+
+Container ports are published on host ports selected by engine; router forwards configured host ports to them.
+Running container is removed after router has drained its connections.
+If switch or replacement fails, router is switched back to running container and new container is removed.
+*/
+func updateContainerProxy(
+	cli core.Runtime, options *core.RunContainerOptions, currentContainer core.Container,
+	gate *HealthGate, verify func(core.Container) error, router Router, keep int,
+) (core.Container, error) {
+	if router == nil {
+		return nil, fmt.Errorf("strategy '%s' requires router", StrategyProxy)
+	}
+	host := ""
+	if gate != nil {
+		host = gate.Host
+	}
+	// Targets of running container; router is not switched back if they are not known.
+	var previousTargets map[int]string
+	if currentContainer != nil {
+		if info, err := core.InspectContainer(cli, currentContainer); err == nil {
+			previousTargets, _ = getProxyTargets(info, options.Ports, host)
+		}
+	}
+	switchBack := func(err error) error {
+		if previousTargets != nil {
+			if otherErr := router.Switch(previousTargets); otherErr != nil {
+				err = fmt.Errorf("%w (%v)", err, otherErr)
+			}
+		}
+		return err
+	}
+	candidate, err := startCandidate(cli, options, gate, verify)
+	if err != nil {
+		return nil, err
+	}
+	info, err := core.InspectContainer(cli, candidate)
+	if err == nil {
+		var targets map[int]string
+		targets, err = getProxyTargets(info, options.Ports, host)
+		if err == nil {
+			if err = router.Switch(targets); err != nil {
+				// Some ports could be switched before failure.
+				err = switchBack(err)
+			}
+		}
+	}
+	if err != nil {
+		if otherErr := core.RemoveContainer(cli, candidate); otherErr != nil {
+			err = fmt.Errorf("%w (%v)", err, otherErr)
+		}
+		return nil, err
+	}
+	container, err := swapContainers(cli, candidate, currentContainer, options.Name, keep)
+	if err != nil {
+		return nil, switchBack(err)
+	}
+	return container, nil
+}
@@ -0,0 +1,320 @@
+package manage
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/DmitryBogomolov/containerator/core/fake"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	assert.NoError(t, probeHTTP(server.URL+"/health", time.Second))
+	assert.EqualError(t, probeHTTP(server.URL+"/other", time.Second), server.URL+"/other: status 503")
+}
+
+func TestProbeTCP(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	address := listener.Addr().String()
+
+	assert.NoError(t, probeTCP(address, time.Second))
+	listener.Close()
+	assert.Error(t, probeTCP(address, time.Second))
+}
+
+func TestCheckHealth(t *testing.T) {
+	makeInfo := func(running bool, health string, port string) *types.ContainerJSON {
+		info := &types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{State: &types.ContainerState{Running: running}},
+			NetworkSettings:   &types.NetworkSettings{},
+		}
+		if health != "" {
+			info.State.Health = &types.Health{Status: health}
+		}
+		if port != "" {
+			info.NetworkSettings.Ports = nat.PortMap{"80/tcp": []nat.PortBinding{{HostPort: port}}}
+		}
+		return info
+	}
+
+	t.Run("Not running", func(t *testing.T) {
+		_, _, err := checkHealth(makeInfo(false, "", ""), &HealthGate{}, time.Second)
+		assert.EqualError(t, err, "container is not running")
+	})
+
+	t.Run("Healthcheck", func(t *testing.T) {
+		ok, _, err := checkHealth(makeInfo(true, types.Healthy, ""), &HealthGate{}, time.Second)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		ok, reason, err := checkHealth(makeInfo(true, types.Starting, ""), &HealthGate{}, time.Second)
+		assert.False(t, ok)
+		assert.Equal(t, "container is starting", reason)
+		assert.NoError(t, err)
+		_, _, err = checkHealth(makeInfo(true, types.Unhealthy, ""), &HealthGate{}, time.Second)
+		assert.EqualError(t, err, "container is unhealthy")
+	})
+
+	t.Run("No ports", func(t *testing.T) {
+		ok, _, err := checkHealth(makeInfo(true, "", ""), &HealthGate{}, time.Second)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+		_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+		ok, _, err := checkHealth(makeInfo(true, "", port), &HealthGate{HTTP: "/health", Port: "80"}, time.Second)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		ok, _, err = checkHealth(makeInfo(true, "", port), &HealthGate{HTTP: "/health", Port: "81"}, time.Second)
+		assert.True(t, ok, "port is not published")
+		assert.NoError(t, err)
+	})
+
+	t.Run("TCP", func(t *testing.T) {
+		listener, _ := net.Listen("tcp", "127.0.0.1:0")
+		_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+		ok, _, err := checkHealth(makeInfo(true, "", port), &HealthGate{}, time.Second)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		listener.Close()
+		ok, reason, err := checkHealth(makeInfo(true, "", port), &HealthGate{}, time.Second)
+		assert.False(t, ok)
+		assert.NotEmpty(t, reason)
+		assert.NoError(t, err)
+	})
+}
+
+// setHealthWhenCreated sets health status of container as soon as it is created.
+func setHealthWhenCreated(engine *fake.Engine, name string, status string) {
+	go func() {
+		for engine.SetHealth(name, status) != nil {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+}
+
+func TestRunContainerBlueGreen(t *testing.T) {
+	gate := &HealthGate{Timeout: "200ms", Interval: "10ms"}
+
+	t.Run("Swap names", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		imageID := engine.AddImage("test-image:2")
+		cfg := &Config{ImageName: "test-image", Deploy: &DeployConfig{Strategy: StrategyBlueGreen, Health: gate}}
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		cont, err := RunContainer(cli, cfg, &Options{Tag: "2"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "test-image", cont.Name())
+		assert.Equal(t, imageID, cont.ImageID())
+		assert.Equal(t, "running", cont.State())
+		_, err = core.FindContainerByID(cli, prev.ID())
+		assert.ErrorIs(t, err, core.ErrNotFound)
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Equal(t, []string{cont.ID()}, ids)
+	})
+
+	t.Run("Host ports", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		cfg := &Config{
+			ImageName: "test-image",
+			Ports:     []core.Mapping{{Source: "5001", Target: "80"}},
+			Deploy:    &DeployConfig{Strategy: StrategyBlueGreen, Health: gate},
+		}
+		prev, _ := RunContainer(cli, &Config{ImageName: "test-image", Ports: cfg.Ports}, &Options{Tag: "1"})
+
+		_, err := RunContainer(cli, cfg, &Options{Tag: "2"})
+
+		assert.Equal(t, errBlueGreenHostPorts, err)
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Equal(t, []string{prev.ID()}, ids)
+	})
+
+	t.Run("Dynamic host ports", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		imageID := engine.AddImage("test-image:2")
+		cfg := &Config{
+			ImageName: "test-image",
+			Ports:     []core.Mapping{{Source: "", Target: "80"}},
+			Deploy:    &DeployConfig{Strategy: StrategyBlueGreen, Health: gate},
+		}
+		prev, _ := RunContainer(cli, &Config{ImageName: "test-image", Ports: cfg.Ports}, &Options{Tag: "1"})
+		setHealthWhenCreated(engine, "test-image"+candidateSuffix, types.Healthy)
+
+		cont, err := RunContainer(cli, cfg, &Options{Tag: "2"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "test-image", cont.Name())
+		assert.Equal(t, imageID, cont.ImageID())
+		_, err = core.FindContainerByID(cli, prev.ID())
+		assert.ErrorIs(t, err, core.ErrNotFound)
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Equal(t, []string{cont.ID()}, ids)
+	})
+
+	t.Run("Gate failure", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		cfg := &Config{
+			ImageName: "test-image",
+			Ports:     []core.Mapping{{Source: "", Target: "80"}},
+			Deploy:    &DeployConfig{Strategy: StrategyBlueGreen, Health: gate},
+		}
+		prev, _ := RunContainer(cli, &Config{ImageName: "test-image", Ports: cfg.Ports}, &Options{Tag: "1"})
+		setHealthWhenCreated(engine, "test-image"+candidateSuffix, types.Unhealthy)
+
+		_, err := RunContainer(cli, cfg, &Options{Tag: "2"})
+
+		var healthErr *HealthCheckError
+		assert.ErrorAs(t, err, &healthErr)
+		assert.Equal(t, "test-image"+candidateSuffix, healthErr.Container())
+		assert.Equal(t, "container is unhealthy", healthErr.Reason())
+		cont, _ := core.FindContainerByName(cli, "test-image")
+		assert.Equal(t, prev.ID(), cont.ID())
+		assert.Equal(t, "running", cont.State())
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Equal(t, []string{prev.ID()}, ids)
+	})
+}
+
+type _TestRouter struct {
+	targets  []map[int]string
+	err      error  // Returned by the next switch
+	onSwitch func() // Called on each switch
+}
+
+func (router *_TestRouter) Switch(targets map[int]string) error {
+	router.targets = append(router.targets, targets)
+	if router.onSwitch != nil {
+		router.onSwitch()
+	}
+	err := router.err
+	router.err = nil
+	return err
+}
+
+func TestRunContainerProxy(t *testing.T) {
+	cfg := &Config{
+		ImageName: "test-image",
+		Ports:     []core.Mapping{{Source: "5001", Target: "80"}},
+		Deploy: &DeployConfig{
+			Strategy: StrategyProxy,
+			Health:   &HealthGate{Timeout: "200ms", Interval: "10ms"},
+		},
+	}
+	getAddress := func(cli core.Runtime, cont core.Container) string {
+		info, _ := core.InspectContainer(cli, cont)
+		return "127.0.0.1:" + findHostPort(info, "80")
+	}
+
+	t.Run("Switch", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		imageID := engine.AddImage("test-image:2")
+		router := &_TestRouter{}
+		setHealthWhenCreated(engine, "test-image"+candidateSuffix, types.Healthy)
+		prev, err := RunContainer(cli, cfg, &Options{Tag: "1", Router: router})
+		assert.NoError(t, err)
+		prevAddress := getAddress(cli, prev)
+		setHealthWhenCreated(engine, "test-image"+candidateSuffix, types.Healthy)
+
+		cont, err := RunContainer(cli, cfg, &Options{Tag: "2", Router: router})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "test-image", cont.Name())
+		assert.Equal(t, imageID, cont.ImageID())
+		assert.Equal(t, []map[int]string{
+			{5001: prevAddress},
+			{5001: getAddress(cli, cont)},
+		}, router.targets)
+		_, err = core.FindContainerByID(cli, prev.ID())
+		assert.ErrorIs(t, err, core.ErrNotFound)
+		ports, _ := core.ListHostPorts(cli)
+		assert.Nil(t, ports["5001/tcp"], "configured port is not bound by container")
+
+		_, err = RunContainer(cli, cfg, &Options{Tag: "2", Router: router})
+		var runningErr *ContainerAlreadyRunningError
+		assert.ErrorAs(t, err, &runningErr)
+	})
+
+	t.Run("Switch failure", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		router := &_TestRouter{}
+		setHealthWhenCreated(engine, "test-image"+candidateSuffix, types.Healthy)
+		prev, err := RunContainer(cli, cfg, &Options{Tag: "1", Router: router})
+		assert.NoError(t, err)
+		prevAddress := getAddress(cli, prev)
+		setHealthWhenCreated(engine, "test-image"+candidateSuffix, types.Healthy)
+		router.targets = nil
+		router.err = errors.New("test-error")
+
+		_, err = RunContainer(cli, cfg, &Options{Tag: "2", Router: router})
+
+		assert.EqualError(t, err, "test-error")
+		assert.Len(t, router.targets, 2)
+		assert.Equal(t, map[int]string{5001: prevAddress}, router.targets[1], "router is switched back")
+		cont, _ := core.FindContainerByName(cli, "test-image")
+		assert.Equal(t, prev.ID(), cont.ID())
+		assert.Equal(t, "running", cont.State())
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Equal(t, []string{prev.ID()}, ids)
+	})
+
+	t.Run("Switch failure with remove failure", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		router := &_TestRouter{}
+		setHealthWhenCreated(engine, "test-image"+candidateSuffix, types.Healthy)
+		RunContainer(cli, cfg, &Options{Tag: "1", Router: router})
+		setHealthWhenCreated(engine, "test-image"+candidateSuffix, types.Healthy)
+		router.err = errors.New("test-error")
+		// New container disappears before it is removed.
+		router.onSwitch = func() {
+			if candidate, err := core.FindContainerByName(cli, "test-image"+candidateSuffix); err == nil {
+				core.RemoveContainer(cli, candidate)
+			}
+		}
+
+		_, err := RunContainer(cli, cfg, &Options{Tag: "2", Router: router})
+
+		assert.ErrorContains(t, err, "test-error (")
+		assert.ErrorContains(t, err, "No such container")
+	})
+
+	t.Run("No router", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+
+		_, err := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		assert.EqualError(t, err, "strategy 'proxy' requires router")
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Empty(t, ids)
+	})
+}
@@ -0,0 +1,185 @@
+package manage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+)
+
+// LabelConfigHash is a container label that keeps hash of options the container is created with.
+const LabelConfigHash = "containerator.config-hash"
+
+// Difference describes container setting that differs from config.
+type Difference struct {
+	Field   string // "image", "network", "ports", "volumes", "env", "restart", "config"
+	Current string // Value of running container
+	Desired string // Value from config
+}
+
+func (diff Difference) String() string {
+	current, desired := diff.Current, diff.Desired
+	if current == "" {
+		current = "<none>"
+	}
+	if desired == "" {
+		desired = "<none>"
+	}
+	return fmt.Sprintf("%s: %s -> %s", diff.Field, current, desired)
+}
+
+// hashOptions makes hash of container options; labels are not included.
+func hashOptions(options *core.RunContainerOptions) string {
+	tmp := *options
+	tmp.Labels = nil
+	data, _ := json.Marshal(tmp)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func formatPortMap(ports nat.PortMap) []string {
+	var result []string
+	for port, bindings := range ports {
+		for _, binding := range bindings {
+			result = append(result, fmt.Sprintf("%s:%s", binding.HostPort, port))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func formatMount(source string, target string, readOnly bool) string {
+	result := source + ":" + target
+	if readOnly {
+		result += ":ro"
+	}
+	return result
+}
+
+func formatMounts(mounts []mount.Mount) []string {
+	var result []string
+	for _, item := range mounts {
+		result = append(result, formatMount(item.Source, item.Target, item.ReadOnly))
+	}
+	sort.Strings(result)
+	return result
+}
+
+// getCurrentMounts takes mounts from host config or (if engine does not report it) from mount points.
+func getCurrentMounts(info *types.ContainerJSON) []string {
+	if info.HostConfig != nil && len(info.HostConfig.Mounts) > 0 {
+		return formatMounts(info.HostConfig.Mounts)
+	}
+	var result []string
+	for _, item := range info.Mounts {
+		if item.Type == mount.TypeBind {
+			result = append(result, formatMount(item.Source, item.Destination, !item.RW))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func normalizeNetwork(mode container.NetworkMode) string {
+	if mode == "" || mode.IsDefault() || mode.IsBridge() {
+		return "default"
+	}
+	return string(mode)
+}
+
+// diffEnv compares variables from config with container variables.
+//
+// Container also has variables from image, so only variables from config are compared.
+func diffEnv(current []string, desired []string) (string, string) {
+	values := map[string]string{}
+	for _, item := range current {
+		key, _, _ := strings.Cut(item, "=")
+		values[key] = item
+	}
+	var currentDiff, desiredDiff []string
+	for _, item := range desired {
+		key, _, _ := strings.Cut(item, "=")
+		if values[key] != item {
+			currentDiff = append(currentDiff, values[key])
+			desiredDiff = append(desiredDiff, item)
+		}
+	}
+	return strings.Join(currentDiff, ", "), strings.Join(desiredDiff, ", ")
+}
+
+func appendDifference(list []Difference, field string, current []string, desired []string) []Difference {
+	currentText, desiredText := strings.Join(current, ", "), strings.Join(desired, ", ")
+	if currentText != desiredText {
+		list = append(list, Difference{field, currentText, desiredText})
+	}
+	return list
+}
+
+/*
+diffContainer compares inspected container with options it would be created with.
+
+Config hash label is compared when no other differences are found; it catches changes
+that cannot be seen in inspected container (like removed env variables).
+
+	diffContainer(&info, &options, "sha256:...") -> []Difference{{"ports", "9001:80/tcp", "9002:80/tcp"}}, nil
+*/
+func diffContainer(
+	info *types.ContainerJSON, options *core.RunContainerOptions, imageID string,
+) ([]Difference, error) {
+	config, hostConfig, err := core.BuildContainerConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	var result []Difference
+	if info.Image != imageID {
+		result = append(result, Difference{"image", info.Image, imageID})
+	}
+	var currentConfig container.Config
+	if info.Config != nil {
+		currentConfig = *info.Config
+	}
+	var currentHostConfig container.HostConfig
+	if info.HostConfig != nil {
+		currentHostConfig = *info.HostConfig
+	}
+	currentNetwork, desiredNetwork := normalizeNetwork(currentHostConfig.NetworkMode), normalizeNetwork(hostConfig.NetworkMode)
+	if currentNetwork != desiredNetwork {
+		result = append(result, Difference{"network", currentNetwork, desiredNetwork})
+	}
+	result = appendDifference(
+		result, "ports", formatPortMap(currentHostConfig.PortBindings), formatPortMap(hostConfig.PortBindings),
+	)
+	result = appendDifference(result, "volumes", getCurrentMounts(info), formatMounts(hostConfig.Mounts))
+	if current, desired := diffEnv(currentConfig.Env, config.Env); desired != "" {
+		result = append(result, Difference{"env", current, desired})
+	}
+	if current, desired := currentHostConfig.RestartPolicy.Name, hostConfig.RestartPolicy.Name; desired != "" && current != desired {
+		result = append(result, Difference{"restart", string(current), string(desired)})
+	}
+	if len(result) == 0 {
+		current, desired := currentConfig.Labels[LabelConfigHash], options.Labels[LabelConfigHash]
+		if current != "" && desired != "" && current != desired {
+			result = append(result, Difference{"config", current, desired})
+		}
+	}
+	return result, nil
+}
+
+// detectDrift returns differences between running container and options.
+func detectDrift(
+	cli core.Runtime, currentContainer core.Container, options *core.RunContainerOptions, imageID string,
+) ([]Difference, error) {
+	info, err := core.InspectContainer(cli, currentContainer)
+	if err != nil {
+		return nil, err
+	}
+	return diffContainer(info, options, imageID)
+}
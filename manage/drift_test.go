@@ -0,0 +1,112 @@
+package manage
+
+import (
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffContainer(t *testing.T) {
+	options := &core.RunContainerOptions{
+		Image:   "test-image:1",
+		Name:    "test",
+		Network: "test-net",
+		Ports:   []core.Mapping{{Source: "9001", Target: "80"}},
+		Volumes: []core.Mapping{{Source: "/data", Target: "/app/data:ro"}},
+		Env:     []core.Mapping{{Source: "A", Target: "1"}},
+		Labels:  map[string]string{LabelConfigHash: "hash-1"},
+	}
+	makeInfo := func() *types.ContainerJSON {
+		return &types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				Image: "sha256:1",
+				HostConfig: &container.HostConfig{
+					NetworkMode: "test-net",
+					PortBindings: nat.PortMap{
+						"80/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "9001"}},
+					},
+					RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyAlways},
+					Mounts: []mount.Mount{
+						{Type: mount.TypeBind, Source: "/data", Target: "/app/data", ReadOnly: true},
+					},
+				},
+			},
+			Config: &container.Config{
+				Env:    []string{"PATH=/bin", "A=1"},
+				Labels: map[string]string{LabelConfigHash: "hash-1"},
+			},
+		}
+	}
+
+	t.Run("No differences", func(t *testing.T) {
+		differences, err := diffContainer(makeInfo(), options, "sha256:1")
+
+		assert.NoError(t, err)
+		assert.Empty(t, differences)
+	})
+
+	t.Run("Image", func(t *testing.T) {
+		differences, err := diffContainer(makeInfo(), options, "sha256:2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Difference{{"image", "sha256:1", "sha256:2"}}, differences)
+	})
+
+	t.Run("Fields", func(t *testing.T) {
+		info := makeInfo()
+		info.HostConfig.NetworkMode = "bridge"
+		info.HostConfig.PortBindings = nat.PortMap{
+			"80/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "9002"}},
+		}
+		info.HostConfig.Mounts = nil
+		info.Mounts = []types.MountPoint{{Type: mount.TypeBind, Source: "/data", Destination: "/app/data", RW: true}}
+		info.Config.Env = []string{"PATH=/bin", "A=2"}
+
+		differences, err := diffContainer(info, options, "sha256:1")
+
+		assert.NoError(t, err)
+		assert.Equal(t,
+			[]Difference{
+				{"network", "default", "test-net"},
+				{"ports", "9002:80/tcp", "9001:80/tcp"},
+				{"volumes", "/data:/app/data", "/data:/app/data:ro"},
+				{"env", "A=2", "A=1"},
+			},
+			differences,
+		)
+	})
+
+	t.Run("Config hash", func(t *testing.T) {
+		info := makeInfo()
+		info.Config.Labels[LabelConfigHash] = "hash-0"
+
+		differences, err := diffContainer(info, options, "sha256:1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Difference{{"config", "hash-0", "hash-1"}}, differences)
+	})
+
+	t.Run("Bad port", func(t *testing.T) {
+		badOptions := *options
+		badOptions.Ports = []core.Mapping{{Source: "9001", Target: "http"}}
+
+		_, err := diffContainer(makeInfo(), &badOptions, "sha256:1")
+
+		assert.EqualError(t, err, "Invalid containerPort: http")
+	})
+}
+
+func TestHashOptions(t *testing.T) {
+	options := &core.RunContainerOptions{Image: "test-image:1", Name: "test"}
+	hash := hashOptions(options)
+
+	options.Labels = map[string]string{"a": "b"}
+	assert.Equal(t, hash, hashOptions(options))
+	options.Env = []core.Mapping{{Source: "A", Target: "1"}}
+	assert.NotEqual(t, hash, hashOptions(options))
+}
@@ -0,0 +1,158 @@
+package manage
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/DmitryBogomolov/containerator/core"
+)
+
+/*
+readEnvFile reads docker style env file.
+
+Each line contains "KEY=VALUE" pair. Empty lines and lines starting with "#" are skipped.
+"KEY=" sets empty value. "KEY" without "=" takes value from host environment; if host does not have such variable
+it is skipped.
+Unlike docker "--env-file", which takes quotes literally, value can be enclosed in double quotes
+(supports \n, \t, \", \\ escapes) or single quotes (taken as is).
+
+	readEnvFile("/path/to/env.list") -> []core.Mapping{{"A", "1"}}, err
+*/
+func readEnvFile(pathToFile string) ([]core.Mapping, error) {
+	file, err := os.Open(pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var result []core.Mapping
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		mapping, ok, err := parseEnvLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", pathToFile, lineNumber, err)
+		}
+		if ok {
+			result = append(result, mapping)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func parseEnvLine(line string) (core.Mapping, bool, error) {
+	key, value, hasValue := strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " \t\"'") {
+		return core.Mapping{}, false, fmt.Errorf("invalid variable name '%s'", key)
+	}
+	if !hasValue {
+		hostValue, ok := os.LookupEnv(key)
+		return core.Mapping{Source: key, Target: hostValue}, ok, nil
+	}
+	value, err := unquoteEnvValue(value)
+	if err != nil {
+		return core.Mapping{}, false, fmt.Errorf("variable '%s': %w", key, err)
+	}
+	return core.Mapping{Source: key, Target: value}, true, nil
+}
+
+func unquoteEnvValue(value string) (string, error) {
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		return value, nil
+	}
+	quote := value[0]
+	if len(value) < 2 || value[len(value)-1] != quote {
+		return "", fmt.Errorf("unterminated quoted value %s", value)
+	}
+	content := value[1 : len(value)-1]
+	if quote == '\'' {
+		return content, nil
+	}
+	var builder strings.Builder
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		if ch != '\\' || i == len(content)-1 {
+			builder.WriteByte(ch)
+			continue
+		}
+		i++
+		switch content[i] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 't':
+			builder.WriteByte('\t')
+		case 'r':
+			builder.WriteByte('\r')
+		default:
+			builder.WriteByte(content[i])
+		}
+	}
+	return builder.String(), nil
+}
+
+// mergeEnv combines lists of variables; later lists override values of earlier ones.
+//
+// Variables keep position of the first occurrence.
+//
+//	mergeEnv([]core.Mapping{{"A", "1"}, {"B", "2"}}, []core.Mapping{{"A", "3"}}) -> []core.Mapping{{"A", "3"}, {"B", "2"}}
+func mergeEnv(lists ...[]core.Mapping) []core.Mapping {
+	var result []core.Mapping
+	indexes := map[string]int{}
+	for _, list := range lists {
+		for _, mapping := range list {
+			if idx, ok := indexes[mapping.Source]; ok {
+				result[idx] = mapping
+				continue
+			}
+			indexes[mapping.Source] = len(result)
+			result = append(result, mapping)
+		}
+	}
+	return result
+}
+
+// getHostEnv takes empty values of config env variables from host environment.
+func getHostEnv(env []core.Mapping) []core.Mapping {
+	result := make([]core.Mapping, len(env))
+	for i, mapping := range env {
+		if mapping.Target == "" {
+			mapping.Target = os.Getenv(mapping.Source)
+		}
+		result[i] = mapping
+	}
+	return result
+}
+
+// buildEnv collects environment variables.
+//
+// Precedence (from lowest): config env files (in order), config env, options env file.
+// Empty values of config env are taken from host environment; empty values of env files are kept.
+func buildEnv(cfg *Config, options *Options) ([]core.Mapping, error) {
+	lists := make([][]core.Mapping, 0, len(cfg.EnvFile)+2)
+	for _, pathToFile := range cfg.EnvFile {
+		list, err := readEnvFile(pathToFile)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	lists = append(lists, getHostEnv(cfg.Env))
+	if options.EnvFilePath != "" {
+		list, err := readEnvFile(options.EnvFilePath)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return mergeEnv(lists...), nil
+}
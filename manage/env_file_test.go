@@ -0,0 +1,109 @@
+package manage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFile(t *testing.T, name string, lines ...string) string {
+	pathToFile := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(pathToFile, []byte(strings.Join(lines, "\n")), os.ModePerm))
+	return pathToFile
+}
+
+func TestReadEnvFile(t *testing.T) {
+	t.Run("Parse", func(t *testing.T) {
+		t.Setenv("TEST_HOST_VAR", "host-value")
+		pathToFile := writeTestFile(t, "env.list",
+			"# comment",
+			"",
+			"A=1",
+			"  B = 2 # not a comment",
+			`C="quoted \"value\"\n"`,
+			`D='single \n quoted'`,
+			"E=",
+			"TEST_HOST_VAR",
+			"TEST_MISSING_VAR",
+			"F=a=b",
+		)
+
+		list, err := readEnvFile(pathToFile)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []core.Mapping{
+			{Source: "A", Target: "1"},
+			{Source: "B", Target: " 2 # not a comment"},
+			{Source: "C", Target: "quoted \"value\"\n"},
+			{Source: "D", Target: `single \n quoted`},
+			{Source: "E", Target: ""},
+			{Source: "TEST_HOST_VAR", Target: "host-value"},
+			{Source: "F", Target: "a=b"},
+		}, list)
+	})
+
+	t.Run("Invalid name", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "env.list", "A=1", "B C=2")
+
+		_, err := readEnvFile(pathToFile)
+
+		assert.EqualError(t, err, pathToFile+":2: invalid variable name 'B C'")
+	})
+
+	t.Run("Unterminated quote", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "env.list", `A="1`)
+
+		_, err := readEnvFile(pathToFile)
+
+		assert.EqualError(t, err, pathToFile+`:1: variable 'A': unterminated quoted value "1`)
+	})
+
+	t.Run("No file", func(t *testing.T) {
+		_, err := readEnvFile("unknown.list")
+
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestBuildEnv(t *testing.T) {
+	configFile := writeTestFile(t, "config.env", "A=config-file", "B=config-file", "C=config-file")
+	optionsFile := writeTestFile(t, "options.env", "C=options-file", "D=options-file")
+	cfg := &Config{
+		Env:     []core.Mapping{{Source: "B", Target: "config"}, {Source: "C", Target: "config"}},
+		EnvFile: []string{configFile},
+	}
+
+	env, err := buildEnv(cfg, &Options{EnvFilePath: optionsFile})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Mapping{
+		{Source: "A", Target: "config-file"},
+		{Source: "B", Target: "config"},
+		{Source: "C", Target: "options-file"},
+		{Source: "D", Target: "options-file"},
+	}, env)
+}
+
+func TestBuildEnv_EmptyValues(t *testing.T) {
+	t.Setenv("TEST_EMPTY_VAR", "host-value")
+	t.Setenv("TEST_CONFIG_VAR", "host-value")
+	envFile := writeTestFile(t, "empty.env", "TEST_EMPTY_VAR=")
+	cfg := &Config{
+		ImageName: "test-image",
+		Env:       []core.Mapping{{Source: "TEST_CONFIG_VAR", Target: ""}},
+		EnvFile:   []string{envFile},
+	}
+	engine, cli := newTestEngine()
+	engine.AddImage("test-image:1")
+
+	cont, err := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+	assert.NoError(t, err)
+	info, _ := core.InspectContainer(cli, cont)
+	assert.Contains(t, info.Config.Env, "TEST_EMPTY_VAR=")
+	assert.Contains(t, info.Config.Env, "TEST_CONFIG_VAR=host-value")
+}
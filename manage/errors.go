@@ -2,6 +2,8 @@ package manage
 
 import (
 	"fmt"
+
+	"github.com/DmitryBogomolov/containerator/core"
 )
 
 // NoContainerError is returned on attempt to remove container when it is not found.
@@ -18,6 +20,11 @@ func (err NoContainerError) Container() string {
 	return err.container
 }
 
+// Is makes error match core.ErrNotFound.
+func (err NoContainerError) Is(target error) bool {
+	return target == core.ErrNotFound
+}
+
 // NoImageError is returned when image is not found.
 type NoImageError struct {
 	image string
@@ -32,6 +39,36 @@ func (err NoImageError) Image() string {
 	return err.image
 }
 
+// Is makes error match core.ErrNotFound.
+func (err NoImageError) Is(target error) bool {
+	return target == core.ErrNotFound
+}
+
+// PortConflictError is returned when host port is already bound by another container.
+type PortConflictError struct {
+	port      int
+	container string
+}
+
+func (err PortConflictError) Error() string {
+	return fmt.Sprintf("port %d is already used by container '%s'", err.port, err.container)
+}
+
+// Port returns host port.
+func (err PortConflictError) Port() int {
+	return err.port
+}
+
+// Container returns name of container that uses port.
+func (err PortConflictError) Container() string {
+	return err.container
+}
+
+// Is makes error match core.ErrConflict.
+func (err PortConflictError) Is(target error) bool {
+	return target == core.ErrConflict
+}
+
 // ContainerAlreadyRunningError is returned on attempt to run container when similar container is already running.
 type ContainerAlreadyRunningError struct {
 	container string
@@ -45,3 +82,93 @@ func (err ContainerAlreadyRunningError) Error() string {
 func (err ContainerAlreadyRunningError) Container() string {
 	return err.container
 }
+
+// HealthCheckError is returned when new container does not pass health gate.
+type HealthCheckError struct {
+	container string
+	reason    string
+}
+
+func (err HealthCheckError) Error() string {
+	return fmt.Sprintf("container '%s' has failed health check: %s", err.container, err.reason)
+}
+
+// Container returns name of checked container.
+func (err HealthCheckError) Container() string {
+	return err.container
+}
+
+// Reason returns why check has failed.
+func (err HealthCheckError) Reason() string {
+	return err.reason
+}
+
+// NoPreviousVersionError is returned on rollback when there is no kept container to roll back to.
+type NoPreviousVersionError struct {
+	container string
+}
+
+func (err NoPreviousVersionError) Error() string {
+	return fmt.Sprintf("container '%s' has no previous version", err.container)
+}
+
+// Container returns container name.
+func (err NoPreviousVersionError) Container() string {
+	return err.container
+}
+
+// Is makes error match core.ErrNotFound.
+func (err NoPreviousVersionError) Is(target error) bool {
+	return target == core.ErrNotFound
+}
+
+// ContainerBusyError is returned when container is locked by another operation.
+type ContainerBusyError struct {
+	container string
+	holder    string
+}
+
+func (err ContainerBusyError) Error() string {
+	return fmt.Sprintf("container '%s' is busy: locked by %s", err.container, err.holder)
+}
+
+// Container returns container name.
+func (err ContainerBusyError) Container() string {
+	return err.container
+}
+
+// Holder returns description of operation that holds the lock.
+func (err ContainerBusyError) Holder() string {
+	return err.holder
+}
+
+// Is makes error match core.ErrConflict.
+func (err ContainerBusyError) Is(target error) bool {
+	return target == core.ErrConflict
+}
+
+// HookError is returned when hook step fails.
+type HookError struct {
+	hook   string
+	step   int
+	reason string
+}
+
+func (err HookError) Error() string {
+	return fmt.Sprintf("hook '%s' step %d has failed: %s", err.hook, err.step, err.reason)
+}
+
+// Hook returns hook name.
+func (err HookError) Hook() string {
+	return err.hook
+}
+
+// Step returns index of failed step.
+func (err HookError) Step() int {
+	return err.step
+}
+
+// Reason returns why step has failed.
+func (err HookError) Reason() string {
+	return err.reason
+}
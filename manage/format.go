@@ -0,0 +1,197 @@
+package manage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat defines config file format.
+type ConfigFormat string
+
+// Config file formats.
+const (
+	FormatYAML ConfigFormat = "yaml"
+	FormatJSON ConfigFormat = "json"
+	FormatTOML ConfigFormat = "toml"
+)
+
+// ConfigExtensions contains extensions of config files.
+var ConfigExtensions = []string{".yaml", ".yml", ".json", ".toml"}
+
+// GetConfigFormat detects config format by file extension; yaml is used for unknown extensions.
+//
+//	GetConfigFormat("/path/to/config.toml") -> FormatTOML
+func GetConfigFormat(pathToFile string) ConfigFormat {
+	switch strings.ToLower(filepath.Ext(pathToFile)) {
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatYAML
+	}
+}
+
+// parseConfigNode parses config file content into yaml document, so all formats are processed the same way.
+//
+// Yaml parser handles json as is (and keeps line numbers). Toml is converted and has no line numbers.
+func parseConfigNode(data []byte, format ConfigFormat) (*yaml.Node, error) {
+	var root yaml.Node
+	switch format {
+	case FormatJSON:
+		var tmp interface{}
+		if err := json.Unmarshal(data, &tmp); err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, err
+		}
+	case FormatTOML:
+		var tmp map[string]interface{}
+		if _, err := toml.Decode(string(data), &tmp); err != nil {
+			return nil, err
+		}
+		var node yaml.Node
+		if err := node.Encode(tmp); err != nil {
+			return nil, err
+		}
+		root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&node}}
+	default:
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, err
+		}
+	}
+	return &root, nil
+}
+
+func encodeConfig(cfg *Config, format ConfigFormat) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case FormatTOML:
+		var buffer bytes.Buffer
+		encoder := toml.NewEncoder(&buffer)
+		encoder.Indent = ""
+		if err := encoder.Encode(cfg); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	default:
+		return yamlv2.Marshal(cfg)
+	}
+}
+
+// _RawValue is config value as it is written in file and as it is read.
+type _RawValue struct {
+	raw   string
+	value string
+}
+
+func listConfigStrings(value reflect.Value) ([]_ValueKey, []string) {
+	var keys []_ValueKey
+	var texts []string
+	_, _ = mapConfigStrings(value, "", func(key _ValueKey, text string) (string, error) {
+		keys = append(keys, key)
+		texts = append(texts, text)
+		return text, nil
+	})
+	return keys, texts
+}
+
+// skippedRawValue replaces raw values that are not restored.
+const skippedRawValue = "\x00"
+
+/*
+skipStringMappings replaces string form mappings with variables ("${PORT:-9001}:80") with skipped values.
+
+Such mappings cannot be split into source and target before interpolation, so their values are not restored.
+*/
+func skipStringMappings(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.SequenceNode && (key.Value == "ports" || key.Value == "volumes" || key.Value == "env") {
+				for _, item := range value.Content {
+					if item.Kind == yaml.ScalarNode && strings.Contains(item.Value, "${") {
+						item.Value = skippedRawValue + ":" + skippedRawValue
+					}
+				}
+			}
+		}
+	}
+	for _, child := range node.Content {
+		skipStringMappings(child)
+	}
+}
+
+/*
+collectRawValues finds config values that differ from the file content - interpolated values and resolved paths.
+
+File content is decoded once more without processing and its values are matched to config values by position.
+Nothing is collected if structure of config is changed by interpolation.
+*/
+func collectRawValues(cfg *Config, data []byte, format ConfigFormat) map[_ValueKey]_RawValue {
+	root, err := parseConfigNode(data, format)
+	if err != nil || root.Kind == 0 {
+		return nil
+	}
+	skipStringMappings(root)
+	var rawCfg Config
+	// Raw values of non string fields (e.g. "${RETRIES}") cannot be decoded; such fields are not needed.
+	_ = root.Decode(&rawCfg)
+	keys, values := listConfigStrings(reflect.ValueOf(*cfg))
+	_, raws := listConfigStrings(reflect.ValueOf(rawCfg))
+	if len(values) != len(raws) {
+		return nil
+	}
+	result := map[_ValueKey]_RawValue{}
+	for i, key := range keys {
+		if values[i] != raws[i] && raws[i] != skippedRawValue {
+			result[key] = _RawValue{raw: raws[i], value: values[i]}
+		}
+	}
+	return result
+}
+
+// restoreRawValues makes copy of config with values that are not changed since reading written as they are in file.
+func restoreRawValues(cfg *Config) *Config {
+	if len(cfg.raw) == 0 {
+		return cfg
+	}
+	value, _ := mapConfigStrings(reflect.ValueOf(*cfg), "", func(key _ValueKey, text string) (string, error) {
+		if item, ok := cfg.raw[key]; ok && item.value == text {
+			return item.raw, nil
+		}
+		return text, nil
+	})
+	result := value.Interface().(Config)
+	return &result
+}
+
+// WriteConfig writes config to file; format is detected by file extension.
+//
+// Values that are read from file and not changed are written as they are in file (not interpolated, relative paths),
+// so config that is read and written back is not changed. String form mappings with variables ("${PORT:-9001}:80")
+// are written interpolated.
+//
+//	WriteConfig("/path/to/config.json", &config) -> err
+func WriteConfig(pathToFile string, cfg *Config) error {
+	data, err := encodeConfig(restoreRawValues(cfg), GetConfigFormat(pathToFile))
+	if err != nil {
+		return fmt.Errorf("%s: %w", pathToFile, err)
+	}
+	return ioutil.WriteFile(pathToFile, data, 0644)
+}
@@ -0,0 +1,233 @@
+package manage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetConfigFormat(t *testing.T) {
+	assert.Equal(t, FormatYAML, GetConfigFormat("/a/config.yaml"))
+	assert.Equal(t, FormatYAML, GetConfigFormat("/a/config.yml"))
+	assert.Equal(t, FormatJSON, GetConfigFormat("/a/config.JSON"))
+	assert.Equal(t, FormatTOML, GetConfigFormat("/a/config.toml"))
+	assert.Equal(t, FormatYAML, GetConfigFormat("/a/config"))
+}
+
+func TestReadConfigFormats(t *testing.T) {
+	t.Setenv("TEST_PORT", "5001")
+	dir := writeTestFiles(t, map[string][]string{
+		"config.yaml": {
+			"image_name: test-image",
+			"ports:",
+			"- ${TEST_PORT}: 80",
+			"- 6000:90",
+			"volumes:",
+			"- ./data: /data",
+			"env:",
+			"- A: 1",
+			"port_offsets:",
+			"  test: 10",
+			"profiles:",
+			"  prod:",
+			"    network: prod-net",
+		},
+		"config.json": {
+			"{",
+			"\t\"image_name\": \"test-image\",",
+			"\t\"ports\": [{\"${TEST_PORT}\": 80}, \"6000:90\"],",
+			"\t\"volumes\": [{\"./data\": \"/data\"}],",
+			"\t\"env\": [{\"A\": \"1\"}],",
+			"\t\"port_offsets\": {\"test\": 10},",
+			"\t\"profiles\": {\"prod\": {\"network\": \"prod-net\"}}",
+			"}",
+		},
+		"config.toml": {
+			"image_name = \"test-image\"",
+			"ports = [{\"${TEST_PORT}\" = 80}, \"6000:90\"]",
+			"volumes = [{\"./data\" = \"/data\"}]",
+			"env = [{A = \"1\"}]",
+			"[port_offsets]",
+			"test = 10",
+			"[profiles.prod]",
+			"network = \"prod-net\"",
+		},
+	})
+	expected := &Config{
+		ImageName:   "test-image",
+		Ports:       []core.Mapping{{Source: "5001", Target: "80"}, {Source: "6000", Target: "90"}},
+		Volumes:     []core.Mapping{{Source: filepath.Join(dir, "data"), Target: "/data"}},
+		Env:         []core.Mapping{{Source: "A", Target: "1"}},
+		PortOffsets: map[string]int{"test": 10},
+		Profiles:    map[string]*Profile{"prod": {Network: "prod-net"}},
+	}
+
+	for _, name := range []string{"config.yaml", "config.json", "config.toml"} {
+		config, err := readTestConfig(filepath.Join(dir, name))
+
+		assert.NoError(t, err, name)
+		assert.Equal(t, expected, config, name)
+		assert.NoError(t, ValidateConfig(filepath.Join(dir, name)), name)
+	}
+}
+
+func TestReadConfigFormatErrors(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]string{
+		"config.json": {"image_name: test-image"},
+		"config.toml": {"image_name: test-image"},
+	})
+
+	_, errJSON := ReadConfig(filepath.Join(dir, "config.json"))
+	_, errTOML := ReadConfig(filepath.Join(dir, "config.toml"))
+
+	assert.Error(t, errJSON)
+	assert.Error(t, errTOML)
+}
+
+func TestWriteConfig(t *testing.T) {
+	config := &Config{
+		ImageName: "test-image",
+		Ports:     []core.Mapping{{Source: "5001", Target: "80"}},
+		Env:       []core.Mapping{{Source: "A", Target: "1"}, {Source: "B", Target: "x \"y\""}},
+		Profiles:  map[string]*Profile{"prod": {Merge: MergeReplace, Env: []core.Mapping{{Source: "A", Target: "2"}}}},
+	}
+	dir := t.TempDir()
+	expected := map[string]string{
+		"config.yaml": strings.Join([]string{
+			"image_name: test-image",
+			"ports:",
+			`- "5001": "80"`,
+			"env:",
+			`- A: "1"`,
+			`- B: x "y"`,
+			"profiles:",
+			"  prod:",
+			"    merge: replace",
+			"    env:",
+			`    - A: "2"`,
+			"",
+		}, "\n"),
+		"config.json": strings.Join([]string{
+			"{",
+			`  "image_name": "test-image",`,
+			`  "ports": [`,
+			"    {",
+			`      "5001": "80"`,
+			"    }",
+			"  ],",
+			`  "env": [`,
+			"    {",
+			`      "A": "1"`,
+			"    },",
+			"    {",
+			`      "B": "x \"y\""`,
+			"    }",
+			"  ],",
+			`  "profiles": {`,
+			`    "prod": {`,
+			`      "merge": "replace",`,
+			`      "env": [`,
+			"        {",
+			`          "A": "2"`,
+			"        }",
+			"      ]",
+			"    }",
+			"  }",
+			"}",
+			"",
+		}, "\n"),
+		"config.toml": strings.Join([]string{
+			`image_name = "test-image"`,
+			`ports = [{"5001" = "80"}]`,
+			`env = [{"A" = "1"}, {"B" = "x \"y\""}]`,
+			"",
+			"[profiles]",
+			"[profiles.prod]",
+			`merge = "replace"`,
+			`env = [{"A" = "2"}]`,
+			"",
+		}, "\n"),
+	}
+
+	for name, content := range expected {
+		pathToFile := filepath.Join(dir, name)
+
+		err := WriteConfig(pathToFile, config)
+		data, _ := os.ReadFile(pathToFile)
+		readConfig, readErr := readTestConfig(pathToFile)
+
+		assert.NoError(t, err, name)
+		assert.Equal(t, content, string(data), name)
+		assert.NoError(t, readErr, name)
+		assert.Equal(t, config, readConfig, name)
+	}
+}
+
+func TestWriteConfigRawValues(t *testing.T) {
+	t.Setenv("TEST_REGISTRY", "example.com")
+	for _, name := range []string{"config.yaml", "config.json", "config.toml"} {
+		t.Run(name, func(t *testing.T) {
+			pathToFile := filepath.Join(t.TempDir(), name)
+			assert.NoError(t, WriteConfig(pathToFile, &Config{
+				ImageName:     "${TEST_REGISTRY:-docker.io}/test-image",
+				ContainerName: "app-${POSTFIX}",
+				Volumes:       []core.Mapping{{Source: "./data", Target: "/data"}},
+				Env:           []core.Mapping{{Source: "PRICE", Target: "$$5"}, {Source: "DIR", Target: "${CONFIG_DIR}"}},
+				Hooks:         &HooksConfig{PostDeploy: []HookStep{{Command: "echo $${TAG}"}}},
+			}))
+			content, _ := os.ReadFile(pathToFile)
+
+			config, err := ReadConfig(pathToFile)
+			assert.NoError(t, err)
+			assert.Equal(t, "example.com/test-image", config.ImageName)
+			assert.NoError(t, WriteConfig(pathToFile, config))
+			data, _ := os.ReadFile(pathToFile)
+
+			assert.Equal(t, string(content), string(data), "file is not changed")
+
+			config.ImageName = "other-image"
+			assert.NoError(t, WriteConfig(pathToFile, config))
+			changed, err := ReadConfig(pathToFile)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "other-image", changed.ImageName, "changed value is written")
+			assert.Equal(t, config.Volumes, changed.Volumes)
+			assert.Equal(t, config.Env, changed.Env)
+		})
+	}
+
+	t.Run("String mappings with variables", func(t *testing.T) {
+		t.Setenv("TEST_PORT", "9002")
+		files := map[string][]string{
+			"config.yaml": {
+				"image_name: test-image",
+				"ports:",
+				"- ${TEST_PORT:-9001}:80",
+				"env:",
+				"- A=${TEST_PORT}",
+			},
+			"config.json": {`{"image_name": "test-image", "ports": ["${TEST_PORT:-9001}:80"], "env": ["A=${TEST_PORT}"]}`},
+			"config.toml": {
+				`image_name = "test-image"`,
+				`ports = ["${TEST_PORT:-9001}:80"]`,
+				`env = ["A=${TEST_PORT}"]`,
+			},
+		}
+		for name, lines := range files {
+			pathToFile := writeTestFile(t, name, lines...)
+			config, err := ReadConfig(pathToFile)
+			assert.NoError(t, err, name)
+
+			assert.NoError(t, WriteConfig(pathToFile, config), name)
+			written, err := ReadConfig(pathToFile)
+
+			assert.NoError(t, err, name)
+			assert.Equal(t, []core.Mapping{{Source: "9002", Target: "80"}}, written.Ports, name)
+			assert.Equal(t, []core.Mapping{{Source: "A", Target: "9002"}}, written.Env, name)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package manage
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+)
+
+// GCPolicy defines which images CollectGarbage keeps.
+//
+// Image is kept if it matches any condition; images used by containers (including stopped ones) are always kept.
+type GCPolicy struct {
+	KeepLast    int           // Number of newest images that are kept
+	KeepYounger time.Duration // Images created less than that ago are kept
+	Apply       bool          // If set images are removed; otherwise they are only reported (dry run)
+}
+
+// GCReport describes images that CollectGarbage has removed or, in dry run, would remove.
+type GCReport struct {
+	DryRun  bool         // Nothing is removed
+	Removed []core.Image // Removed images, newest first
+	Kept    []core.Image // Kept images, newest first
+	// Upper bound of reclaimed space in bytes: sum of sizes of deleted images. Size of image includes layers
+	// shared with other images, which are not deleted.
+	MaxReclaimed int64
+}
+
+// getOwnTags returns repo:tag pairs of image that belong to repo; all tags belong to repo if the second result is set.
+func getOwnTags(image core.Image, repo string) ([]string, bool) {
+	var tags []string
+	for _, repoTag := range image.RepoTags() {
+		if idx := strings.LastIndex(repoTag, ":"); idx >= 0 && repoTag[:idx] == repo {
+			tags = append(tags, repoTag)
+		}
+	}
+	return tags, len(tags) == len(image.RepoTags())
+}
+
+func isImageKept(cli core.Runtime, image core.Image, idx int, policy *GCPolicy) (bool, error) {
+	if idx < policy.KeepLast {
+		return true, nil
+	}
+	if policy.KeepYounger > 0 && now().Sub(image.Created()) < policy.KeepYounger {
+		return true, nil
+	}
+	containers, err := core.FindContainersByImageID(cli, image.ID())
+	if err != nil {
+		return false, err
+	}
+	return len(containers) > 0, nil
+}
+
+/*
+CollectGarbage removes old images of config image.
+
+Images are ordered by creation time; images that match policy or are used by containers are kept.
+Only tags of config image are removed, so image that also has tags of other repositories is untagged rather than deleted.
+Nothing is removed unless GCPolicy.Apply is set.
+Container that is managed with config and options is locked (see Options.Lock), so images are not collected
+while it is deployed; ContainerBusyError is returned if it is busy.
+
+	CollectGarbage(cli, cfg, &GCPolicy{KeepLast: 3}, &Options{Postfix: "prod"}) -> &GCReport{DryRun: true, ...}, err
+*/
+func CollectGarbage(cli core.Runtime, cfg *Config, policy *GCPolicy, options *Options) (*GCReport, error) {
+	name, err := ContainerName(cfg, options)
+	if err != nil {
+		return nil, err
+	}
+	release, err := lockContainer(name, OperationCollectGarbage, options)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	cfg, err = prepareConfig(cfg, options)
+	if err != nil {
+		return nil, err
+	}
+	images, err := core.FindAllImagesByName(cli, cfg.ImageName)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].Created().After(images[j].Created())
+	})
+	report := &GCReport{DryRun: !policy.Apply}
+	for i, image := range images {
+		kept, err := isImageKept(cli, image, i, policy)
+		if err != nil {
+			return report, err
+		}
+		if kept {
+			report.Kept = append(report.Kept, image)
+			continue
+		}
+		tags, deleted := getOwnTags(image, cfg.ImageName)
+		if policy.Apply {
+			for _, tag := range tags {
+				if err := core.RemoveImage(cli, tag); err != nil {
+					return report, err
+				}
+			}
+		}
+		report.Removed = append(report.Removed, image)
+		if deleted {
+			report.MaxReclaimed += image.Size()
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,99 @@
+package manage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/DmitryBogomolov/containerator/core/fake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	cfg := &Config{ImageName: "test-image"}
+	day := 24 * time.Hour
+
+	// Images 1..5 are created 5..1 days ago, so image 5 is the newest.
+	setup := func(t *testing.T) (*fake.Engine, core.Runtime, []string) {
+		engine, cli := newTestEngine()
+		base := time.Now().Add(-5 * day)
+		var ids []string
+		for i, tag := range []string{"1", "2", "3", "4", "5"} {
+			ids = append(ids, engine.PutImage(fake.Image{
+				RepoTags: []string{"test-image:" + tag},
+				Created:  base.Add(time.Duration(i) * day),
+				Size:     int64(1000 * (i + 1)),
+			}))
+		}
+		engine.AddImage("other-image:1")
+		return engine, cli, ids
+	}
+	getIDs := func(images []core.Image) []string {
+		return core.TransformSlice(images, core.Image.ID)
+	}
+
+	t.Run("Dry run", func(t *testing.T) {
+		_, cli, ids := setup(t)
+
+		report, err := CollectGarbage(cli, cfg, &GCPolicy{KeepLast: 2}, &Options{})
+
+		assert.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, []string{ids[4], ids[3]}, getIDs(report.Kept))
+		assert.Equal(t, []string{ids[2], ids[1], ids[0]}, getIDs(report.Removed))
+		assert.Equal(t, int64(6000), report.MaxReclaimed)
+		all, _ := core.ListAllImageIDs(cli)
+		assert.Len(t, all, 6)
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		_, cli, ids := setup(t)
+		RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		report, err := CollectGarbage(cli, cfg, &GCPolicy{KeepLast: 1, KeepYounger: 3*day + time.Hour, Apply: true}, &Options{})
+
+		assert.NoError(t, err)
+		assert.False(t, report.DryRun)
+		assert.Equal(t, []string{ids[4], ids[3], ids[2], ids[0]}, getIDs(report.Kept))
+		assert.Equal(t, []string{ids[1]}, getIDs(report.Removed))
+		assert.Equal(t, int64(2000), report.MaxReclaimed)
+		images, _ := core.FindAllImagesByName(cli, "test-image")
+		assert.Equal(t, []string{ids[4], ids[3], ids[2], ids[0]}, getIDs(images))
+		_, err = core.FindImageByName(cli, "other-image:1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Shared image", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		sharedID := engine.PutImage(fake.Image{
+			RepoTags: []string{"test-image:1", "mirror/test-image:1"},
+			Created:  time.Now().Add(-day),
+			Size:     1000,
+		})
+		engine.AddImage("test-image:2")
+
+		report, err := CollectGarbage(cli, cfg, &GCPolicy{KeepLast: 1, Apply: true}, &Options{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{sharedID}, getIDs(report.Removed))
+		assert.Equal(t, int64(0), report.MaxReclaimed)
+		image, err := core.FindImageByID(cli, sharedID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"mirror/test-image:1"}, image.RepoTags())
+	})
+
+	t.Run("Busy container", func(t *testing.T) {
+		_, cli, ids := setup(t)
+		release, err := lockContainer("test-image", OperationRun, &Options{Actor: "tester"})
+		assert.NoError(t, err)
+		defer release()
+
+		_, err = CollectGarbage(cli, cfg, &GCPolicy{KeepLast: 1, Apply: true}, &Options{})
+
+		var busyErr *ContainerBusyError
+		assert.ErrorAs(t, err, &busyErr)
+		assert.Contains(t, busyErr.Holder(), "tester (run, pid")
+		images, _ := core.FindAllImagesByName(cli, "test-image")
+		assert.Len(t, images, len(ids))
+	})
+}
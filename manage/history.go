@@ -0,0 +1,282 @@
+package manage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+)
+
+// DefaultHistoryKeep defines how many records are kept for container by default.
+const DefaultHistoryKeep = 100
+
+// historyExt is extension of history files; there is a file (JSON lines) for each container.
+const historyExt = ".history.jsonl"
+
+// HistoryOptions defines where and how long deployment history is kept.
+type HistoryOptions struct {
+	Dir    string        // Directory with history files
+	Keep   int           // Number of records kept for container; DefaultHistoryKeep if not set
+	MaxAge time.Duration // Records older than that are removed; not limited if not set
+}
+
+// HistoryOperation defines recorded operation.
+type HistoryOperation string
+
+// Recorded operations.
+const (
+	OperationRun      HistoryOperation = "run"      // RunContainer
+	OperationRemove   HistoryOperation = "remove"   // RunContainer with Options.Remove
+	OperationRollback HistoryOperation = "rollback" // Rollback
+	OperationRecover  HistoryOperation = "recover"  // Recover
+	// CollectGarbage; it is not recorded but is shown as holder of container lock
+	OperationCollectGarbage HistoryOperation = "collect-garbage"
+)
+
+// HistoryResult defines result of recorded operation.
+type HistoryResult string
+
+// Operation results.
+const (
+	ResultSuccess   HistoryResult = "success"   // Operation is done
+	ResultUnchanged HistoryResult = "unchanged" // Running container matches config
+	ResultFailure   HistoryResult = "failure"   // Operation has failed
+)
+
+// HistoryRecord describes single operation.
+type HistoryRecord struct {
+	Time       time.Time        `json:"time"`
+	Container  string           `json:"container"`
+	Operation  HistoryOperation `json:"operation"`
+	Actor      string           `json:"actor,omitempty"`
+	ImageID    string           `json:"image_id,omitempty"`
+	Tag        string           `json:"tag,omitempty"`
+	ConfigHash string           `json:"config_hash,omitempty"`
+	Result     HistoryResult    `json:"result"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// HistoryQuery defines which records History returns.
+type HistoryQuery struct {
+	Dir       string    // Directory with history files
+	Container string    // Container name; all containers if not set
+	Since     time.Time // Only records made after that time; all records if not set
+	Limit     int       // Maximum number of (latest) records; not limited if not set
+}
+
+// historyLockTimeout defines how long history file lock is waited for.
+const historyLockTimeout = 10 * time.Second
+
+// historyLock serializes access to history files within process; lockHistoryFile serializes writers across processes.
+var historyLock sync.Mutex
+
+func getHistoryPath(dir string, container string) string {
+	return filepath.Join(dir, container+historyExt)
+}
+
+func readHistoryFile(path string) ([]HistoryRecord, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var records []HistoryRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var record HistoryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+func writeHistoryFile(path string, records []HistoryRecord) error {
+	var builder strings.Builder
+	for i := range records {
+		data, err := json.Marshal(&records[i])
+		if err != nil {
+			return err
+		}
+		builder.Write(data)
+		builder.WriteByte('\n')
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(builder.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// applyRetention returns records (oldest first) that are kept.
+func applyRetention(records []HistoryRecord, options *HistoryOptions) []HistoryRecord {
+	keep := options.Keep
+	if keep <= 0 {
+		keep = DefaultHistoryKeep
+	}
+	if len(records) > keep {
+		records = records[len(records)-keep:]
+	}
+	if options.MaxAge > 0 {
+		deadline := now().Add(-options.MaxAge)
+		idx := sort.Search(len(records), func(i int) bool {
+			return records[i].Time.After(deadline)
+		})
+		records = records[idx:]
+	}
+	return records
+}
+
+// lockHistoryFile locks history file with lock file next to it; only in-process lock is used
+// on systems without file locks.
+func lockHistoryFile(path string) (func(), error) {
+	holder := fmt.Sprintf("history (pid %d)", os.Getpid())
+	deadline := time.Now().Add(historyLockTimeout)
+	for {
+		release, busyHolder, err := lockFile(path+lockExt, holder)
+		if errors.Is(err, errLockNotSupported) {
+			return func() {}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if release != nil {
+			return release, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s: locked by %s", path, busyHolder)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// appendHistory adds record to container history file and removes records that are not kept.
+//
+// File is read, changed and replaced under lock, so records of concurrent processes are not lost.
+func appendHistory(options *HistoryOptions, record *HistoryRecord) error {
+	historyLock.Lock()
+	defer historyLock.Unlock()
+	if err := os.MkdirAll(options.Dir, 0755); err != nil {
+		return err
+	}
+	path := getHistoryPath(options.Dir, record.Container)
+	release, err := lockHistoryFile(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+	records, err := readHistoryFile(path)
+	if err != nil {
+		return err
+	}
+	records = applyRetention(append(records, *record), options)
+	return writeHistoryFile(path, records)
+}
+
+/*
+History returns recorded operations, newest first.
+
+Operations are recorded when Options.History is set.
+
+	History(&HistoryQuery{Dir: "/path/to/history", Container: "my-container", Limit: 10}) -> []HistoryRecord, err
+*/
+func History(query *HistoryQuery) ([]HistoryRecord, error) {
+	historyLock.Lock()
+	defer historyLock.Unlock()
+	var paths []string
+	if query.Container != "" {
+		paths = []string{getHistoryPath(query.Dir, query.Container)}
+	} else {
+		var err error
+		paths, err = filepath.Glob(filepath.Join(query.Dir, "*"+historyExt))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var result []HistoryRecord
+	for _, path := range paths {
+		records, err := readHistoryFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			if record.Time.After(query.Since) {
+				result = append(result, record)
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.After(result[j].Time)
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+func getImageTag(cli core.Runtime, imageID string) string {
+	image, err := core.FindImageByID(cli, imageID)
+	if err != nil {
+		return ""
+	}
+	return image.Tag()
+}
+
+/*
+recordOperation adds operation to history if Options.History is set and reports it to Options.Notifier if it is set.
+
+Record fields that are not set are taken from container. Error of recording is returned only if operation has succeeded.
+*/
+func recordOperation(
+	cli core.Runtime, options *Options, record *HistoryRecord, container core.Container, err error,
+) error {
+	if (options.History == nil && options.Notifier == nil) || record.Container == "" {
+		return err
+	}
+	record.Time = now().UTC()
+	record.Actor = options.Actor
+	record.Result = ResultSuccess
+	if container != nil {
+		if record.ImageID == "" {
+			record.ImageID = container.ImageID()
+			record.Tag = getImageTag(cli, record.ImageID)
+		}
+		if record.ConfigHash == "" {
+			record.ConfigHash = container.Labels()[LabelConfigHash]
+		}
+	}
+	var runningErr *ContainerAlreadyRunningError
+	if errors.As(err, &runningErr) {
+		record.Result = ResultUnchanged
+	} else if err != nil {
+		record.Result = ResultFailure
+		record.Error = err.Error()
+	}
+	containerID := ""
+	if container != nil {
+		containerID = container.ID()
+	}
+	notify(options, getEventType(record), record, containerID)
+	if options.History == nil {
+		return err
+	}
+	if historyErr := appendHistory(options.History, record); historyErr != nil && err == nil {
+		return historyErr
+	}
+	return err
+}
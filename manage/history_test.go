@@ -0,0 +1,113 @@
+package manage
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyRetention(t *testing.T) {
+	setTestClock(t)
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	records := []HistoryRecord{
+		{Time: base.Add(-2 * time.Hour)},
+		{Time: base.Add(-30 * time.Minute)},
+		{Time: base},
+	}
+
+	assert.Equal(t, records[1:], applyRetention(records, &HistoryOptions{Keep: 2}))
+	assert.Equal(t, records[1:], applyRetention(records, &HistoryOptions{MaxAge: time.Hour}))
+	assert.Equal(t, records, applyRetention(records, &HistoryOptions{}))
+}
+
+func TestHistory(t *testing.T) {
+	cfg := &Config{ImageName: "test-image", Ports: []core.Mapping{{Source: "5001", Target: "80"}}}
+
+	t.Run("Record operations", func(t *testing.T) {
+		setTestClock(t)
+		dir := t.TempDir()
+		engine, cli := newTestEngine()
+		imageID := engine.AddImage("test-image:1")
+		history := &HistoryOptions{Dir: dir}
+		cont, _ := RunContainer(cli, cfg, &Options{Tag: "1", Actor: "tester", History: history})
+		RunContainer(cli, cfg, &Options{Tag: "1", Actor: "tester", History: history})
+		RunContainer(cli, cfg, &Options{Tag: "2", Actor: "tester", History: history})
+		RunContainer(cli, cfg, &Options{Remove: true, Actor: "tester", History: history})
+
+		records, err := History(&HistoryQuery{Dir: dir, Container: "test-image"})
+
+		assert.NoError(t, err)
+		assert.Len(t, records, 4)
+		assert.Equal(t, OperationRemove, records[0].Operation)
+		assert.Equal(t, ResultSuccess, records[0].Result)
+		assert.Equal(t, imageID, records[0].ImageID)
+		assert.Equal(t, "1", records[0].Tag)
+		assert.Equal(t, OperationRun, records[1].Operation)
+		assert.Equal(t, ResultFailure, records[1].Result)
+		assert.Equal(t, "image 'test-image:2' is not found", records[1].Error)
+		assert.Equal(t, ResultUnchanged, records[2].Result)
+		assert.Equal(t, HistoryRecord{
+			Time:       time.Date(2024, 3, 1, 12, 2, 0, 0, time.UTC),
+			Container:  "test-image",
+			Operation:  OperationRun,
+			Actor:      "tester",
+			ImageID:    imageID,
+			Tag:        "1",
+			ConfigHash: cont.Labels()[LabelConfigHash],
+			Result:     ResultSuccess,
+		}, records[3])
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		setTestClock(t)
+		dir := t.TempDir()
+		history := &HistoryOptions{Dir: dir, Keep: 2}
+		for _, name := range []string{"a", "b", "a", "a"} {
+			assert.NoError(t, appendHistory(history, &HistoryRecord{Time: now(), Container: name}))
+		}
+
+		records, _ := History(&HistoryQuery{Dir: dir})
+		assert.Equal(t, []string{"a", "a", "b"}, core.TransformSlice(records, func(record HistoryRecord) string {
+			return record.Container
+		}))
+		records, _ = History(&HistoryQuery{Dir: dir, Limit: 1})
+		assert.Len(t, records, 1)
+		records, _ = History(&HistoryQuery{Dir: dir, Since: records[0].Time.Add(-time.Second)})
+		assert.Len(t, records, 1)
+		records, _ = History(&HistoryQuery{Dir: dir, Container: "c"})
+		assert.Empty(t, records)
+		data, _ := os.ReadFile(filepath.Join(dir, "a"+historyExt))
+		assert.Contains(t, string(data), `"container":"a"`)
+	})
+}
+
+func TestAppendHistoryFileLock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("lock files are not supported")
+	}
+	history := &HistoryOptions{Dir: t.TempDir()}
+	path := getHistoryPath(history.Dir, "test")
+	// Lock is taken by other process.
+	release, _, err := lockFile(path+lockExt, "other")
+	assert.NoError(t, err)
+	done := make(chan error)
+	go func() {
+		done <- appendHistory(history, &HistoryRecord{Time: time.Now(), Container: "test"})
+	}()
+
+	select {
+	case <-done:
+		assert.Fail(t, "record is written while file is locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	release()
+
+	assert.NoError(t, <-done)
+	records, _ := readHistoryFile(path)
+	assert.Len(t, records, 1)
+}
@@ -0,0 +1,188 @@
+package manage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+)
+
+// Hook names.
+const (
+	HookPreDeploy  = "pre_deploy"
+	HookPostDeploy = "post_deploy"
+	HookPreRemove  = "pre_remove"
+	HookOnFailure  = "on_failure"
+)
+
+// DefaultHookTimeout defines how long hook step can run by default.
+const DefaultHookTimeout = 60 * time.Second
+
+// Environment variables that describe deployment to hook steps.
+const (
+	HookVarName           = "CONTAINERATOR_NAME"             // Container name
+	HookVarImage          = "CONTAINERATOR_IMAGE"            // Image name with tag
+	HookVarTag            = "CONTAINERATOR_TAG"              // Image tag
+	HookVarPostfix        = "CONTAINERATOR_POSTFIX"          // Container name postfix
+	HookVarOldContainerID = "CONTAINERATOR_OLD_CONTAINER_ID" // Running (replaced or removed) container
+	HookVarNewContainerID = "CONTAINERATOR_NEW_CONTAINER_ID" // New container; set for post_deploy and on_failure
+	HookVarHook           = "CONTAINERATOR_HOOK"             // Hook name
+	HookVarError          = "CONTAINERATOR_ERROR"            // Error; set for on_failure
+)
+
+// maxHookOutput defines how much of host command output is reported on failure.
+const maxHookOutput = 1024
+
+/*
+HooksConfig contains steps that are run around deployment.
+
+Failure of pre_deploy or pre_remove step aborts operation. Failure of post_deploy step rolls deployment back:
+new container is removed and running container is left intact. on_failure steps are run when operation fails.
+*/
+type HooksConfig struct {
+	PreDeploy  []HookStep `yaml:"pre_deploy,omitempty" json:"pre_deploy,omitempty" toml:"pre_deploy,omitempty"`    // Run before new container is created
+	PostDeploy []HookStep `yaml:"post_deploy,omitempty" json:"post_deploy,omitempty" toml:"post_deploy,omitempty"` // Run when new container is started, before running container is replaced
+	PreRemove  []HookStep `yaml:"pre_remove,omitempty" json:"pre_remove,omitempty" toml:"pre_remove,omitempty"`    // Run before container is removed
+	OnFailure  []HookStep `yaml:"on_failure,omitempty" json:"on_failure,omitempty" toml:"on_failure,omitempty"`    // Run when deployment or removal fails
+}
+
+// HookStep is a host command or a command executed in container.
+type HookStep struct {
+	Command string            `yaml:",omitempty" json:"command,omitempty" toml:"command,omitempty"` // Host command; run with "sh -c"
+	Exec    []string          `yaml:",omitempty" json:"exec,omitempty" toml:"exec,omitempty"`       // Command executed in new container (in running container for pre_deploy, pre_remove)
+	Timeout string            `yaml:",omitempty" json:"timeout,omitempty" toml:"timeout,omitempty"` // How long step can run, e.g. "30s"; "60s" by default
+	Env     map[string]string `yaml:",omitempty" json:"env,omitempty" toml:"env,omitempty"`         // Additional environment variables
+}
+
+// _HookRunner runs hook steps with variables that describe deployment.
+type _HookRunner struct {
+	cli   core.Runtime
+	hooks *HooksConfig
+	vars  map[string]string
+}
+
+func newHookRunner(cli core.Runtime, plan *DeployPlan, options *Options) *_HookRunner {
+	runner := &_HookRunner{cli: cli, hooks: plan.hooks, vars: map[string]string{
+		HookVarName:    plan.Name,
+		HookVarPostfix: options.Postfix,
+	}}
+	if plan.Image != nil {
+		runner.vars[HookVarImage] = plan.Image.FullName()
+		runner.vars[HookVarTag] = plan.Image.Tag()
+	}
+	if plan.Current != nil {
+		runner.vars[HookVarOldContainerID] = plan.Current.ID()
+	}
+	return runner
+}
+
+func (runner *_HookRunner) getSteps(hook string) []HookStep {
+	if runner.hooks == nil {
+		return nil
+	}
+	switch hook {
+	case HookPreDeploy:
+		return runner.hooks.PreDeploy
+	case HookPostDeploy:
+		return runner.hooks.PostDeploy
+	case HookPreRemove:
+		return runner.hooks.PreRemove
+	case HookOnFailure:
+		return runner.hooks.OnFailure
+	}
+	return nil
+}
+
+// getEnv returns variables of step as "KEY=value" list.
+func (runner *_HookRunner) getEnv(hook string, step *HookStep) []string {
+	vars := map[string]string{HookVarHook: hook}
+	for _, source := range []map[string]string{runner.vars, step.Env} {
+		for key, value := range source {
+			vars[key] = value
+		}
+	}
+	env := make([]string, 0, len(vars))
+	for key, value := range vars {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+func runHostCommand(command string, env []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return fmt.Errorf("'%s' has not finished in %s", command, timeout)
+	}
+	if err != nil {
+		text := strings.TrimSpace(string(output))
+		if len(text) > maxHookOutput {
+			text = text[len(text)-maxHookOutput:]
+		}
+		if text != "" {
+			return fmt.Errorf("'%s': %v: %s", command, err, text)
+		}
+		return fmt.Errorf("'%s': %v", command, err)
+	}
+	return nil
+}
+
+func (runner *_HookRunner) runStep(hook string, step *HookStep, container core.Container) error {
+	timeout, err := parseDuration(step.Timeout, DefaultHookTimeout)
+	if err != nil {
+		return err
+	}
+	env := runner.getEnv(hook, step)
+	if step.Command != "" {
+		return runHostCommand(step.Command, env, timeout)
+	}
+	if container == nil {
+		return fmt.Errorf("%v: there is no container to execute command in", step.Exec)
+	}
+	code, err := core.ExecContainer(runner.cli, container, step.Exec, env, timeout)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("%v: exit code %d", step.Exec, code)
+	}
+	return nil
+}
+
+// run runs steps of hook in order; exec steps are executed in container.
+func (runner *_HookRunner) run(hook string, container core.Container) error {
+	steps := runner.getSteps(hook)
+	for i := range steps {
+		if err := runner.runStep(hook, &steps[i], container); err != nil {
+			return &HookError{hook, i, err.Error()}
+		}
+	}
+	return nil
+}
+
+// postDeploy runs post_deploy steps for new container.
+func (runner *_HookRunner) postDeploy(container core.Container) error {
+	runner.vars[HookVarNewContainerID] = container.ID()
+	return runner.run(HookPostDeploy, container)
+}
+
+// onFailure runs on_failure steps and adds their error to operation error.
+func (runner *_HookRunner) onFailure(err error, container core.Container) error {
+	if len(runner.getSteps(HookOnFailure)) == 0 {
+		return err
+	}
+	runner.vars[HookVarError] = err.Error()
+	if hookErr := runner.run(HookOnFailure, container); hookErr != nil {
+		return fmt.Errorf("%w (%v)", err, hookErr)
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+package manage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunContainerHooks(t *testing.T) {
+	newConfig := func(hooks *HooksConfig) *Config {
+		return &Config{
+			ImageName: "test-image",
+			Ports:     []core.Mapping{{Source: "5001", Target: "80"}},
+			Hooks:     hooks,
+		}
+	}
+
+	t.Run("Host commands", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		current, _ := RunContainer(cli, newConfig(nil), &Options{Tag: "1"})
+		output := filepath.Join(t.TempDir(), "output.txt")
+		cfg := newConfig(&HooksConfig{
+			PreDeploy: []HookStep{{
+				Command: `echo "$CONTAINERATOR_HOOK $CONTAINERATOR_TAG $CONTAINERATOR_OLD_CONTAINER_ID" >> ` + output,
+			}},
+			PostDeploy: []HookStep{{
+				Command: `echo "$CONTAINERATOR_HOOK $CONTAINERATOR_NAME $CONTAINERATOR_NEW_CONTAINER_ID $A" >> ` + output,
+				Env:     map[string]string{"A": "1"},
+			}},
+		})
+
+		cont, err := RunContainer(cli, cfg, &Options{Tag: "2"})
+
+		assert.NoError(t, err)
+		data, _ := os.ReadFile(output)
+		assert.Equal(t, []string{
+			"pre_deploy 2 " + current.ID(),
+			"post_deploy test-image " + cont.ID() + " 1",
+		}, strings.Split(strings.TrimSpace(string(data)), "\n"))
+	})
+
+	t.Run("Pre-deploy failure", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		cfg := newConfig(&HooksConfig{PreDeploy: []HookStep{{Command: "echo 'no migrations'; exit 3"}}})
+
+		_, err := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		var hookErr *HookError
+		assert.ErrorAs(t, err, &hookErr)
+		assert.Equal(t, HookPreDeploy, hookErr.Hook())
+		assert.Equal(t, 0, hookErr.Step())
+		assert.Contains(t, hookErr.Reason(), "no migrations")
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Empty(t, ids)
+	})
+
+	t.Run("Post-deploy failure", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		current, _ := RunContainer(cli, newConfig(nil), &Options{Tag: "1"})
+		var execs [][]string
+		engine.SetExecHandler(func(containerID string, cmd []string, env []string) int {
+			execs = append(execs, cmd)
+			if containerID == current.ID() {
+				return 0
+			}
+			return 1
+		})
+		cfg := newConfig(&HooksConfig{
+			PostDeploy: []HookStep{{Exec: []string{"./smoke-test"}}},
+			OnFailure:  []HookStep{{Exec: []string{"./notify"}}},
+		})
+
+		_, err := RunContainer(cli, cfg, &Options{Tag: "2"})
+
+		var hookErr *HookError
+		assert.ErrorAs(t, err, &hookErr)
+		assert.Equal(t, HookPostDeploy, hookErr.Hook())
+		assert.Equal(t, [][]string{{"./smoke-test"}, {"./notify"}}, execs)
+		cont, _ := core.FindContainerByName(cli, "test-image")
+		assert.Equal(t, current.ID(), cont.ID())
+		assert.Equal(t, "running", cont.State())
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Len(t, ids, 1)
+	})
+
+	t.Run("Blue-green", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		cfg := &Config{
+			ImageName: "test-image",
+			Deploy:    &DeployConfig{Strategy: StrategyBlueGreen},
+		}
+		current, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+		var env []string
+		engine.SetExecHandler(func(containerID string, cmd []string, cmdEnv []string) int {
+			env = cmdEnv
+			return 2
+		})
+		cfg.Hooks = &HooksConfig{PostDeploy: []HookStep{{Exec: []string{"./smoke-test"}}}}
+
+		_, err := RunContainer(cli, cfg, &Options{Tag: "2"})
+
+		assert.ErrorContains(t, err, "hook 'post_deploy' step 0 has failed: [./smoke-test]: exit code 2")
+		assert.Contains(t, env, HookVarOldContainerID+"="+current.ID())
+		cont, _ := core.FindContainerByName(cli, "test-image")
+		assert.Equal(t, current.ID(), cont.ID())
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Len(t, ids, 1)
+	})
+
+	t.Run("Pre-remove", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		current, _ := RunContainer(cli, newConfig(nil), &Options{Tag: "1"})
+		var executed []string
+		engine.SetExecHandler(func(containerID string, cmd []string, env []string) int {
+			executed = append(executed, containerID)
+			return 0
+		})
+		cfg := newConfig(&HooksConfig{PreRemove: []HookStep{{Exec: []string{"./drain"}}}})
+
+		_, err := RunContainer(cli, cfg, &Options{Remove: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{current.ID()}, executed)
+		_, err = core.FindContainerByID(cli, current.ID())
+		assert.ErrorIs(t, err, core.ErrNotFound)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		cfg := newConfig(&HooksConfig{PreDeploy: []HookStep{{Command: "exec sleep 5", Timeout: "50ms"}}})
+
+		_, err := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		assert.ErrorContains(t, err, "'exec sleep 5' has not finished in 50ms")
+	})
+}
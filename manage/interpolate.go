@@ -0,0 +1,257 @@
+package manage
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in variables.
+const (
+	VarPostfix   = "POSTFIX"    // Options.Postfix
+	VarTag       = "TAG"        // Options.Tag
+	VarConfigDir = "CONFIG_DIR" // Absolute path to directory of config file
+)
+
+// InterpolationError is returned when config variable cannot be resolved.
+type InterpolationError struct {
+	file     string
+	line     int
+	variable string
+	message  string
+}
+
+func (err InterpolationError) Error() string {
+	message := fmt.Sprintf("variable '%s': %s", err.variable, err.message)
+	if err.file != "" {
+		return fmt.Sprintf("%s: %s", Origin{File: err.file, Line: err.line}, message)
+	}
+	return message
+}
+
+// File returns path to config file.
+func (err InterpolationError) File() string {
+	return err.file
+}
+
+// Line returns line in config file; 0 if format has no line numbers (TOML).
+func (err InterpolationError) Line() int {
+	return err.line
+}
+
+// Variable returns variable name.
+func (err InterpolationError) Variable() string {
+	return err.variable
+}
+
+type _Interpolator struct {
+	lookup func(name string) (string, bool)
+	// deferred marks variables that are resolved later; nil for the final pass.
+	// Before the final pass "$$" and "$" from variable values are kept escaped.
+	deferred func(name string) bool
+}
+
+func isVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, ch := range name {
+		isLetter := ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+		if !isLetter && (i == 0 || ch < '0' || ch > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// splitExpression splits "NAME:-default" into name, operator and argument.
+func splitExpression(expr string) (string, string, string) {
+	idx := strings.IndexAny(expr, ":-?")
+	if idx < 0 {
+		return expr, "", ""
+	}
+	name, rest := expr[:idx], expr[idx:]
+	for _, op := range []string{":-", ":?", "-", "?"} {
+		if strings.HasPrefix(rest, op) {
+			return name, op, rest[len(op):]
+		}
+	}
+	return expr, "", ""
+}
+
+func (interpolator *_Interpolator) evaluate(expr string, raw string) (string, error) {
+	name, op, arg := splitExpression(expr)
+	if !isVariableName(name) {
+		return "", &InterpolationError{variable: expr, message: "invalid variable name"}
+	}
+	if interpolator.deferred != nil && interpolator.deferred(name) {
+		return raw, nil
+	}
+	value, ok := interpolator.lookup(name)
+	if ok && interpolator.deferred != nil {
+		value = strings.ReplaceAll(value, "$", "$$")
+	}
+	switch op {
+	case ":-":
+		if !ok || value == "" {
+			value = arg
+		}
+	case "-":
+		if !ok {
+			value = arg
+		}
+	case ":?", "?":
+		if !ok || (op == ":?" && value == "") {
+			message := arg
+			if message == "" {
+				message = "required variable is not set"
+			}
+			return "", &InterpolationError{variable: name, message: message}
+		}
+	}
+	return value, nil
+}
+
+/*
+interpolate replaces variables in text.
+
+Supports "${VAR}", "${VAR:-default}" (default if unset or empty), "${VAR-default}" (default if unset),
+"${VAR:?error}" (error if unset or empty), "${VAR?error}" (error if unset). "$$" is replaced with "$".
+
+	interpolate("${A:-1}-${B}") -> "1-2"
+*/
+func (interpolator *_Interpolator) interpolate(text string) (string, error) {
+	if !strings.Contains(text, "$") {
+		return text, nil
+	}
+	var builder strings.Builder
+	for i := 0; i < len(text); {
+		if text[i] != '$' || i+1 == len(text) {
+			builder.WriteByte(text[i])
+			i++
+			continue
+		}
+		switch text[i+1] {
+		case '$':
+			if interpolator.deferred != nil {
+				builder.WriteString("$$")
+			} else {
+				builder.WriteByte('$')
+			}
+			i += 2
+		case '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return "", &InterpolationError{variable: text[i:], message: "missing closing brace"}
+			}
+			value, err := interpolator.evaluate(text[i+2:i+end], text[i:i+end+1])
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString(value)
+			i += end + 1
+		default:
+			builder.WriteByte(text[i])
+			i++
+		}
+	}
+	return builder.String(), nil
+}
+
+func setScalarValue(node *yaml.Node, value string) {
+	if value != node.Value {
+		node.Value = value
+		if node.Style == 0 {
+			// Let plain scalar be resolved again - "${PORT}" is a string and "5001" is a number.
+			node.Tag = ""
+		}
+	}
+}
+
+func (interpolator *_Interpolator) interpolateNode(node *yaml.Node, file string) error {
+	if node.Kind == yaml.ScalarNode {
+		value, err := interpolator.interpolate(node.Value)
+		if err != nil {
+			interpolationErr := err.(*InterpolationError)
+			interpolationErr.file = file
+			interpolationErr.line = node.Line
+			return interpolationErr
+		}
+		setScalarValue(node, value)
+		return nil
+	}
+	for _, child := range node.Content {
+		if err := interpolator.interpolateNode(child, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isLateBound(name string) bool {
+	return name == VarPostfix || name == VarTag
+}
+
+// newConfigInterpolator creates interpolator that resolves environment variables and ${CONFIG_DIR}.
+//
+// ${POSTFIX} and ${TAG} are kept until container is run.
+func newConfigInterpolator(dir string) *_Interpolator {
+	return &_Interpolator{
+		lookup: func(name string) (string, bool) {
+			if name == VarConfigDir {
+				return dir, true
+			}
+			return os.LookupEnv(name)
+		},
+		deferred: isLateBound,
+	}
+}
+
+func interpolateConfigNode(node *yaml.Node, pathToFile string, dir string) error {
+	return newConfigInterpolator(dir).interpolateNode(node, pathToFile)
+}
+
+// interpolateAt interpolates config value; error is reported at line that value is read from.
+func (interpolator *_Interpolator) interpolateAt(text string, path string, origins map[string]Origin) (string, error) {
+	result, err := interpolator.interpolate(text)
+	if err != nil {
+		interpolationErr := err.(*InterpolationError)
+		origin := origins[path]
+		interpolationErr.file = origin.File
+		interpolationErr.line = origin.Line
+		return "", interpolationErr
+	}
+	return result, nil
+}
+
+// resolveConfig makes copy of config with ${POSTFIX} and ${TAG} resolved and "$$" replaced with "$" in all values.
+//
+// Other variables are taken from environment, so config that is not read from file is interpolated as well.
+// Profiles are left as is - selected profile is already applied.
+func resolveConfig(cfg *Config, postfix string, tag string) (*Config, error) {
+	interpolator := _Interpolator{
+		lookup: func(name string) (string, bool) {
+			switch name {
+			case VarPostfix:
+				return postfix, true
+			case VarTag:
+				return tag, true
+			}
+			return os.LookupEnv(name)
+		},
+	}
+	source := *cfg
+	source.Profiles = nil
+	value, err := mapConfigStrings(reflect.ValueOf(source), "", func(key _ValueKey, text string) (string, error) {
+		return interpolator.interpolateAt(text, key.path, cfg.origins)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := value.Interface().(Config)
+	result.Profiles = cfg.Profiles
+	return &result, nil
+}
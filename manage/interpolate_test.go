@@ -0,0 +1,246 @@
+package manage
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	variables := map[string]string{"A": "1", "B": "2", "EMPTY": "", "DOLLAR": "$x"}
+	interpolator := _Interpolator{
+		lookup: func(name string) (string, bool) {
+			value, ok := variables[name]
+			return value, ok
+		},
+	}
+
+	t.Run("Values", func(t *testing.T) {
+		for _, item := range [][2]string{
+			{"", ""},
+			{"plain", "plain"},
+			{"${A}", "1"},
+			{"${A}-${B}", "1-2"},
+			{"x${A}y", "x1y"},
+			{"${C}", ""},
+			{"${C:-3}", "3"},
+			{"${EMPTY:-3}", "3"},
+			{"${EMPTY-3}", ""},
+			{"${C-3}", "3"},
+			{"${A:-3}", "1"},
+			{"${A:?bad}", "1"},
+			{"$$A", "$A"},
+			{"$${A}", "${A}"},
+			{"$A", "$A"},
+			{"cost $", "cost $"},
+			{"${DOLLAR}", "$x"},
+		} {
+			value, err := interpolator.interpolate(item[0])
+
+			assert.NoError(t, err, item[0])
+			assert.Equal(t, item[1], value, item[0])
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, item := range [][2]string{
+			{"${C:?C is required}", "variable 'C': C is required"},
+			{"${EMPTY:?}", "variable 'EMPTY': required variable is not set"},
+			{"${C?}", "variable 'C': required variable is not set"},
+			{"${1A}", "variable '1A': invalid variable name"},
+			{"${}", "variable '': invalid variable name"},
+			{"${A", "variable '${A': missing closing brace"},
+		} {
+			_, err := interpolator.interpolate(item[0])
+
+			assert.EqualError(t, err, item[1], item[0])
+		}
+		_, err := interpolator.interpolate("${EMPTY?}")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Deferred", func(t *testing.T) {
+		interpolator := _Interpolator{
+			lookup:   interpolator.lookup,
+			deferred: func(name string) bool { return name == "B" },
+		}
+
+		value, err := interpolator.interpolate("${A}-${B}-${B:-1}-$${A}-${DOLLAR}")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1-${B}-${B:-1}-$${A}-$$x", value)
+	})
+}
+
+func TestReadConfigInterpolation(t *testing.T) {
+	t.Setenv("TEST_IMAGE", "test-image")
+	t.Setenv("TEST_PORT", "5001")
+	t.Setenv("TEST_DOLLAR", "${POSTFIX}")
+
+	t.Run("Values", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "test.yaml",
+			"image_name: ${TEST_IMAGE}",
+			"container_name: app-${POSTFIX:-none}",
+			"network: ${TEST_NETWORK:-net}",
+			"ports:",
+			"- ${TEST_PORT}: 80",
+			"volumes:",
+			"- ${CONFIG_DIR}/data-${POSTFIX}: /data",
+			"env:",
+			"- TAG: ${TAG}",
+			"- RAW: ${TEST_DOLLAR}",
+			"- PRICE: $$5",
+			"port_offsets:",
+			"  test: ${TEST_PORT}",
+		)
+
+		config, err := readTestConfig(pathToFile)
+		curDir := filepath.Dir(pathToFile)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &Config{
+			ImageName:     "test-image",
+			ContainerName: "app-${POSTFIX:-none}",
+			Network:       "net",
+			Ports:         []core.Mapping{{Source: "5001", Target: "80"}},
+			Volumes:       []core.Mapping{{Source: filepath.Join(curDir, "data-${POSTFIX}"), Target: "/data"}},
+			Env: []core.Mapping{
+				{Source: "TAG", Target: "${TAG}"},
+				{Source: "RAW", Target: "$${POSTFIX}"},
+				{Source: "PRICE", Target: "$$5"},
+			},
+			PortOffsets: map[string]int{"test": 5001},
+		}, config)
+
+		resolved, err := resolveConfig(config, "dev", "2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &Config{
+			ImageName:     "test-image",
+			ContainerName: "app-dev",
+			Network:       "net",
+			Ports:         []core.Mapping{{Source: "5001", Target: "80"}},
+			Volumes:       []core.Mapping{{Source: filepath.Join(curDir, "data-dev"), Target: "/data"}},
+			Env: []core.Mapping{
+				{Source: "TAG", Target: "2"},
+				{Source: "RAW", Target: "${POSTFIX}"},
+				{Source: "PRICE", Target: "$5"},
+			},
+			PortOffsets: map[string]int{"test": 5001},
+		}, resolved)
+		assert.Equal(t, "app-${POSTFIX:-none}", config.ContainerName, "source config is not changed")
+	})
+
+	t.Run("Required variable", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "test.yaml",
+			"image_name: test-image",
+			"env:",
+			"- A: 1",
+			"- B: ${TEST_MISSING:?B is required}",
+		)
+
+		_, err := ReadConfig(pathToFile)
+
+		var target *InterpolationError
+		assert.True(t, errors.As(err, &target))
+		assert.Equal(t, pathToFile, target.File())
+		assert.Equal(t, 4, target.Line())
+		assert.Equal(t, "TEST_MISSING", target.Variable())
+		assert.EqualError(t, err, pathToFile+":4: variable 'TEST_MISSING': B is required")
+	})
+
+	t.Run("Late required variable", func(t *testing.T) {
+		_, err := resolveConfig(&Config{ContainerName: "app-${POSTFIX:?postfix is required}"}, "", "")
+
+		assert.EqualError(t, err, "variable 'POSTFIX': postfix is required")
+	})
+
+	t.Run("All fields", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "test.yaml",
+			"image_name: test-image",
+			"restart: $${POSTFIX}",
+			"stop_signal: SIG${TAG}",
+			"stop_timeout: ${TAG}s",
+			"deploy:",
+			"  health:",
+			"    http: /health/${POSTFIX}",
+			"    timeout: $$5",
+			"hooks:",
+			"  post_deploy:",
+			"  - command: echo ${POSTFIX} $$HOME",
+			"    exec: [notify, '${TAG}']",
+			"    env:",
+			"      NAME: app-${POSTFIX}",
+		)
+		config, err := readTestConfig(pathToFile)
+		assert.NoError(t, err)
+
+		resolved, err := resolveConfig(config, "dev", "2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "${POSTFIX}", resolved.Restart)
+		assert.Equal(t, "SIG2", resolved.StopSignal)
+		assert.Equal(t, "2s", resolved.StopTimeout)
+		assert.Equal(t, &HealthGate{HTTP: "/health/dev", Timeout: "$5"}, resolved.Deploy.Health)
+		assert.Equal(t, []HookStep{{
+			Command: "echo dev $HOME",
+			Exec:    []string{"notify", "2"},
+			Env:     map[string]string{"NAME": "app-dev"},
+		}}, resolved.Hooks.PostDeploy)
+		assert.Equal(t, "echo ${POSTFIX} $$HOME", config.Hooks.PostDeploy[0].Command, "source config is not changed")
+		assert.Equal(t, "app-${POSTFIX}", config.Hooks.PostDeploy[0].Env["NAME"], "source config is not changed")
+	})
+
+	t.Run("Late required variable in file", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "test.yaml",
+			"image_name: test-image",
+			"hooks:",
+			"  post_deploy:",
+			"  - command: echo ok",
+			"  - command: echo ${POSTFIX:?postfix is required}",
+		)
+		config, err := ReadConfig(pathToFile)
+		assert.NoError(t, err)
+
+		_, err = resolveConfig(config, "", "")
+
+		var target *InterpolationError
+		assert.True(t, errors.As(err, &target))
+		assert.Equal(t, pathToFile, target.File())
+		assert.Equal(t, 5, target.Line())
+		assert.EqualError(t, err, pathToFile+":5: variable 'POSTFIX': postfix is required")
+	})
+
+	t.Run("Required variable in TOML", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "test.toml",
+			`image_name = "test-image"`,
+			`container_name = "${TEST_MISSING:?name is required}"`,
+		)
+
+		_, err := ReadConfig(pathToFile)
+
+		var target *InterpolationError
+		assert.True(t, errors.As(err, &target))
+		assert.Equal(t, 0, target.Line())
+		assert.EqualError(t, err, pathToFile+": variable 'TEST_MISSING': name is required")
+	})
+}
+
+func TestRunContainerInterpolation(t *testing.T) {
+	engine, cli := newTestEngine()
+	engine.AddImage("test-image:2")
+
+	container, err := RunContainer(cli, &Config{
+		ImageName: "test-image",
+		Env:       []core.Mapping{{Source: "NAME", Target: "app-${POSTFIX}:${TAG:-latest}"}},
+	}, &Options{Postfix: "dev", Tag: "2"})
+
+	assert.NoError(t, err)
+	info, _ := cli.ContainerInspect(nil, container.ID())
+	assert.Contains(t, info.Config.Env, "NAME=app-dev:2")
+	assert.False(t, strings.Contains(container.Name(), "$"))
+}
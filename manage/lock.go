@@ -0,0 +1,152 @@
+package manage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// lockRetryInterval defines how often busy file lock is checked.
+const lockRetryInterval = 50 * time.Millisecond
+
+// lockExt is extension of lock files; there is a file for each container.
+const lockExt = ".lock"
+
+// errLockNotSupported is returned by lockFile on systems without file locks.
+var errLockNotSupported = errors.New("lock files are not supported")
+
+// LockOptions defines how container is locked while it is managed.
+type LockOptions struct {
+	Dir     string        // Directory with lock files; only in-process lock is used if not set
+	Timeout time.Duration // How long to wait for busy container; not waited if not set
+}
+
+// _KeyedLock keeps mutex for each key (container name).
+type _KeyedLock struct {
+	lock    sync.Mutex
+	entries map[string]*_LockEntry
+}
+
+type _LockEntry struct {
+	sem    chan struct{}
+	holder string
+	refs   int
+}
+
+var containerLocks = &_KeyedLock{entries: map[string]*_LockEntry{}}
+
+func (keyed *_KeyedLock) getEntry(key string) *_LockEntry {
+	keyed.lock.Lock()
+	defer keyed.lock.Unlock()
+	entry, ok := keyed.entries[key]
+	if !ok {
+		entry = &_LockEntry{sem: make(chan struct{}, 1)}
+		keyed.entries[key] = entry
+	}
+	entry.refs++
+	return entry
+}
+
+func (keyed *_KeyedLock) putEntry(key string, entry *_LockEntry) {
+	entry.refs--
+	if entry.refs == 0 {
+		delete(keyed.entries, key)
+	}
+}
+
+// acquire locks key; returns holder of the lock if it is not acquired in timeout.
+func (keyed *_KeyedLock) acquire(key string, holder string, timeout time.Duration) (func(), string) {
+	entry := keyed.getEntry(key)
+	acquired := false
+	select {
+	case entry.sem <- struct{}{}:
+		acquired = true
+	default:
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			select {
+			case entry.sem <- struct{}{}:
+				acquired = true
+			case <-timer.C:
+			}
+		}
+	}
+	keyed.lock.Lock()
+	defer keyed.lock.Unlock()
+	if !acquired {
+		keyed.putEntry(key, entry)
+		return nil, entry.holder
+	}
+	entry.holder = holder
+	return func() {
+		keyed.lock.Lock()
+		defer keyed.lock.Unlock()
+		entry.holder = ""
+		<-entry.sem
+		keyed.putEntry(key, entry)
+	}, ""
+}
+
+func getLockPath(dir string, name string) string {
+	return filepath.Join(dir, name+lockExt)
+}
+
+// describeHolder returns description of operation that is recorded as lock holder.
+func describeHolder(operation HistoryOperation, options *Options) string {
+	actor := options.Actor
+	if actor == "" {
+		actor = "unknown"
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s (%s, pid %d on '%s', since %s)",
+		actor, operation, os.Getpid(), host, time.Now().UTC().Format(time.RFC3339))
+}
+
+/*
+lockContainer prevents concurrent operations with container.
+
+Container is locked within process and, if LockOptions.Dir is set, with lock file.
+Returns ContainerBusyError if container is not unlocked in LockOptions.Timeout.
+*/
+func lockContainer(name string, operation HistoryOperation, options *Options) (func(), error) {
+	lockOptions := options.Lock
+	if lockOptions == nil {
+		lockOptions = &LockOptions{}
+	}
+	deadline := time.Now().Add(lockOptions.Timeout)
+	holder := describeHolder(operation, options)
+	release, busyHolder := containerLocks.acquire(name, holder, lockOptions.Timeout)
+	if release == nil {
+		return nil, &ContainerBusyError{name, busyHolder}
+	}
+	if lockOptions.Dir == "" {
+		return release, nil
+	}
+	if err := os.MkdirAll(lockOptions.Dir, 0755); err != nil {
+		release()
+		return nil, err
+	}
+	path := getLockPath(lockOptions.Dir, name)
+	for {
+		releaseFile, busyHolder, err := lockFile(path, holder)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		if releaseFile != nil {
+			return func() {
+				releaseFile()
+				release()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			release()
+			return nil, &ContainerBusyError{name, busyHolder}
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package manage
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// lockFile takes exclusive lock of file and writes holder to it; returns current holder if file is locked.
+//
+// Lock is released by system when process exits.
+func lockFile(path string, holder string) (func(), string, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		data, _ := os.ReadFile(path)
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, strings.TrimSpace(string(data)), nil
+		}
+		return nil, "", err
+	}
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(holder+"\n"), 0)
+	}
+	return func() {
+		file.Truncate(0)
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, "", nil
+}
@@ -0,0 +1,5 @@
+package manage
+
+func lockFile(path string, holder string) (func(), string, error) {
+	return nil, "", errLockNotSupported
+}
@@ -0,0 +1,85 @@
+package manage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedLock(t *testing.T) {
+	keyed := &_KeyedLock{entries: map[string]*_LockEntry{}}
+
+	release, _ := keyed.acquire("a", "first", 0)
+	assert.NotNil(t, release)
+	other, holder := keyed.acquire("a", "second", 0)
+	assert.Nil(t, other)
+	assert.Equal(t, "first", holder)
+	other, _ = keyed.acquire("b", "second", 0)
+	assert.NotNil(t, other)
+	other()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		release()
+	}()
+	other, _ = keyed.acquire("a", "second", time.Second)
+	assert.NotNil(t, other)
+	other()
+	assert.Empty(t, keyed.entries)
+}
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test"+lockExt)
+
+	release, _, err := lockFile(path, "first")
+	assert.NoError(t, err)
+	other, holder, err := lockFile(path, "second")
+	assert.NoError(t, err)
+	assert.Nil(t, other)
+	assert.Equal(t, "first", holder)
+
+	release()
+	other, _, err = lockFile(path, "second")
+	assert.NoError(t, err)
+	assert.NotNil(t, other)
+	other()
+}
+
+func TestLockContainer(t *testing.T) {
+	cfg := &Config{ImageName: "test-image"}
+	dir := t.TempDir()
+
+	t.Run("Busy", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		release, err := lockContainer("test-image", OperationRun, &Options{Actor: "tester"})
+		assert.NoError(t, err)
+		defer release()
+
+		_, err = RunContainer(cli, cfg, &Options{Tag: "1", Lock: &LockOptions{Timeout: 20 * time.Millisecond}})
+
+		var busyErr *ContainerBusyError
+		assert.ErrorAs(t, err, &busyErr)
+		assert.ErrorIs(t, err, core.ErrConflict)
+		assert.Equal(t, "test-image", busyErr.Container())
+		assert.Contains(t, busyErr.Holder(), "tester (run, pid")
+	})
+
+	t.Run("Lock file", func(t *testing.T) {
+		path := getLockPath(dir, "test-image")
+		releaseFile, _, _ := lockFile(path, "other process")
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+
+		_, err := RunContainer(cli, cfg, &Options{Tag: "1", Lock: &LockOptions{Dir: dir}})
+
+		assert.EqualError(t, err, "container 'test-image' is busy: locked by other process")
+		releaseFile()
+		cont, err := RunContainer(cli, cfg, &Options{Tag: "1", Lock: &LockOptions{Dir: dir}})
+		assert.NoError(t, err)
+		assert.Equal(t, "test-image", cont.Name())
+	})
+}
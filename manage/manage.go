@@ -5,11 +5,11 @@ import (
 	"fmt"
 
 	"github.com/DmitryBogomolov/containerator/core"
-	"github.com/docker/docker/client"
 )
 
 func updateContainer(
-	cli client.ContainerAPIClient, options *core.RunContainerOptions, currentContainer core.Container,
+	cli core.Runtime, options *core.RunContainerOptions, currentContainer core.Container,
+	verify func(core.Container) error, keep int,
 ) (container core.Container, err error) {
 	if currentContainer != nil {
 		if err = core.SuspendContainer(cli, currentContainer); err != nil {
@@ -18,23 +18,40 @@ func updateContainer(
 		defer func() {
 			if err != nil {
 				if otherErr := core.ResumeContainer(cli, currentContainer, options.Name); otherErr != nil {
-					err = fmt.Errorf("%v (%v)", err, otherErr)
+					err = fmt.Errorf("%w (%v)", err, otherErr)
 				}
 			} else {
-				err = core.RemoveContainer(cli, currentContainer)
+				err = retireContainer(cli, currentContainer, options.Name, keep)
 			}
 		}()
 	}
 	container, err = core.RunContainer(cli, options)
+	if err == nil && verify != nil {
+		if err = verify(container); err != nil {
+			if otherErr := core.RemoveContainer(cli, container); otherErr != nil {
+				err = fmt.Errorf("%w (%v)", err, otherErr)
+			}
+			container = nil
+		}
+	}
 	return
 }
 
 // Options contains additional arguments for Manage function.
 type Options struct {
-	Postfix string // Container name postfix
-	Tag     string // Image tag; if not set newest image is selected
-	Force   bool   // If set running container is replaced
-	Remove  bool   // If set running container is removed
+	Postfix     string          // Container name postfix
+	Tag         string          // Image tag; if not set newest image is selected
+	Force       bool            // If set running container is replaced
+	Remove      bool            // If set running container is removed
+	EnvFilePath string          // Env file; has priority over config `env` and `env_file`
+	PortOffset  int             // Offset added to host ports; has priority over config `port_offsets`
+	Router      Router          // Forwards host ports to active container; required for "proxy" deploy strategy
+	Actor       string          // Who runs operation; recorded in history
+	History     *HistoryOptions // Where operations are recorded; not recorded if not set
+	Lock        *LockOptions    // How container is locked; only in-process lock without waiting is used if not set
+	Notifier    Notifier        // Reports deployment events; not reported if not set
+	// Delivery of events to config webhooks when Notifier is not set; its Webhooks are ignored
+	Webhooks *WebhookOptions
 }
 
 // DefaultConfigName defines default name of config file.
@@ -42,32 +59,95 @@ const DefaultConfigName = "config.yaml"
 
 // RunContainer runs container with the last tag for the specified image.
 //
+// Config profile that matches options.Postfix is applied.
+// Container is locked while it is managed (see Options.Lock); ContainerBusyError is returned if it is busy.
+// Running container is replaced only if it differs from config (image, network, ports, volumes, env);
+// otherwise ContainerAlreadyRunningError is returned along with the running container.
+//
 //	RunContainer(cli, "/path/to/config.yaml", &Options{Mode:"dev"}) -> &container, err
-func RunContainer(cli interface{}, cfg *Config, options *Options) (core.Container, error) {
-	containerName := getContainerName(cfg, options.Postfix)
-
-	containerCli := cli.(client.ContainerAPIClient)
-	currentContainer, err := core.FindContainerByName(containerCli, containerName)
+func RunContainer(cli core.Runtime, cfg *Config, options *Options) (core.Container, error) {
+	record := &HistoryRecord{Operation: OperationRun}
+	if options.Remove {
+		record.Operation = OperationRemove
+	}
+	name, err := ContainerName(cfg, options)
 	if err != nil {
 		return nil, err
 	}
-
-	if options.Remove {
-		return removeContainer(containerCli, currentContainer, containerName)
+	options, closeNotifier, err := withConfigNotifier(cfg, options)
+	if err != nil {
+		return nil, err
 	}
-
-	image, err := findImage(cli.(client.ImageAPIClient), cfg.ImageName, options.Tag)
+	defer closeNotifier()
+	release, err := lockContainer(name, record.Operation, options)
 	if err != nil {
 		return nil, err
 	}
-
-	if currentContainer != nil && currentContainer.ImageID() == image.ID() && !options.Force {
-		return nil, &ContainerAlreadyRunningError{currentContainer.Name()}
+	defer release()
+	plan, err := Plan(cli, cfg, options)
+	record.Container = name
+	if err != nil {
+		return nil, recordOperation(cli, options, record, nil, err)
+	}
+	if plan.Image != nil {
+		record.ImageID = plan.Image.ID()
+		record.Tag = plan.Image.Tag()
+	}
+	if plan.Options != nil {
+		record.ConfigHash = plan.Options.Labels[LabelConfigHash]
 	}
+	if plan.Action == ActionCreate || plan.Action == ActionReplace {
+		notify(options, EventDeployStarted, record, "")
+	}
+	container, err := runPlan(cli, plan, options)
+	return container, recordOperation(cli, options, record, container, err)
+}
 
-	runOptions, err := buildContainerOptions(cfg, image.FullName(), containerName, options)
+func runPlan(cli core.Runtime, plan *DeployPlan, options *Options) (core.Container, error) {
+	if plan.Action == ActionNone {
+		return plan.Current, &ContainerAlreadyRunningError{plan.Current.Name()}
+	}
+	hooks := newHookRunner(cli, plan, options)
+	container, err := runPlanWithHooks(cli, plan, options, hooks)
 	if err != nil {
+		err = hooks.onFailure(err, plan.Current)
+	}
+	return container, err
+}
+
+func runPlanWithHooks(
+	cli core.Runtime, plan *DeployPlan, options *Options, hooks *_HookRunner,
+) (core.Container, error) {
+	if plan.Action == ActionRemove {
+		if err := hooks.run(HookPreRemove, plan.Current); err != nil {
+			return nil, err
+		}
+		return removeContainer(cli, plan.Current, plan.Name)
+	}
+	if err := hooks.run(HookPreDeploy, plan.Current); err != nil {
 		return nil, err
 	}
-	return updateContainer(containerCli, runOptions, currentContainer)
+	var verify func(core.Container) error
+	if len(hooks.getSteps(HookPostDeploy)) > 0 {
+		verify = hooks.postDeploy
+	}
+	switch plan.Strategy {
+	case StrategyBlueGreen:
+		return updateContainerBlueGreen(cli, plan.Options, plan.Current, plan.health, verify, plan.keep)
+	case StrategyProxy:
+		return updateContainerProxy(cli, plan.Options, plan.Current, plan.health, verify, options.Router, plan.keep)
+	default:
+		return updateContainer(cli, plan.Options, plan.Current, verify, plan.keep)
+	}
+}
+
+// ContainerName returns name of container that is managed with config and options.
+//
+//	ContainerName(cfg, &Options{Postfix: "prod"}) -> "my-container-prod", err
+func ContainerName(cfg *Config, options *Options) (string, error) {
+	cfg, err := prepareConfig(cfg, options)
+	if err != nil {
+		return "", err
+	}
+	return getContainerName(cfg, options.Postfix), nil
 }
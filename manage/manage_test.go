@@ -0,0 +1,160 @@
+package manage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/DmitryBogomolov/containerator/core/fake"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEngine() (*fake.Engine, core.Runtime) {
+	engine := fake.New()
+	return engine, engine
+}
+
+func TestRunContainer(t *testing.T) {
+	cfg := &Config{
+		ImageName: "test-image",
+		Ports: []core.Mapping{
+			{Source: "5001", Target: "80"},
+		},
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		imageID := engine.AddImage("test-image:1", "test-image:latest")
+
+		cont, err := RunContainer(cli, cfg, &Options{Postfix: "dev"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "test-image-dev", cont.Name())
+		assert.Equal(t, imageID, cont.ImageID())
+		assert.Equal(t, "running", cont.State())
+	})
+
+	t.Run("Already running", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		_, err := RunContainer(cli, cfg, &Options{Tag: "1"})
+		assert.NoError(t, err)
+
+		cont, err := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		assert.Equal(t, "test-image", cont.Name())
+		var runningErr *ContainerAlreadyRunningError
+		assert.ErrorAs(t, err, &runningErr)
+		assert.Equal(t, "test-image", runningErr.Container())
+	})
+
+	t.Run("Replace on config change", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+		changed := *cfg
+		changed.Env = []core.Mapping{{Source: "A", Target: "1"}}
+
+		cont, err := RunContainer(cli, &changed, &Options{Tag: "1"})
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, prev.ID(), cont.ID())
+		_, err = core.FindContainerByID(cli, prev.ID())
+		assert.ErrorIs(t, err, core.ErrNotFound)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		imageID := engine.AddImage("test-image:2")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		cont, err := RunContainer(cli, cfg, &Options{Tag: "2"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "test-image", cont.Name())
+		assert.Equal(t, imageID, cont.ImageID())
+		_, err = core.FindContainerByID(cli, prev.ID())
+		assert.ErrorIs(t, err, core.ErrNotFound)
+	})
+
+	t.Run("Force", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		cont, err := RunContainer(cli, cfg, &Options{Tag: "1", Force: true})
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, prev.ID(), cont.ID())
+	})
+
+	t.Run("Restore on failure", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		_, err := RunContainer(cli, &Config{ImageName: "test-image", Network: "unknown"}, &Options{Tag: "1", Force: true})
+
+		assert.Error(t, err)
+		cont, err := core.FindContainerByName(cli, "test-image")
+		assert.NoError(t, err)
+		assert.Equal(t, prev.ID(), cont.ID())
+		assert.Equal(t, "running", cont.State())
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		cont, err := RunContainer(cli, cfg, &Options{Remove: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, prev.ID(), cont.ID())
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Len(t, ids, 0)
+	})
+
+	t.Run("Restart and stop settings", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		stopCfg := &Config{
+			ImageName:         "test-image",
+			Restart:           "on-failure",
+			RestartMaxRetries: 3,
+			StopSignal:        "SIGQUIT",
+			StopTimeout:       "30s",
+		}
+
+		cont, err := RunContainer(cli, stopCfg, &Options{Tag: "1"})
+
+		assert.NoError(t, err)
+		info, _ := core.InspectContainer(cli, cont)
+		assert.Equal(t, container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3}, info.HostConfig.RestartPolicy)
+		assert.Equal(t, "SIGQUIT", info.Config.StopSignal)
+		assert.Equal(t, 30, *info.Config.StopTimeout)
+	})
+
+	t.Run("Remove / no container", func(t *testing.T) {
+		_, cli := newTestEngine()
+
+		_, err := RunContainer(cli, cfg, &Options{Remove: true})
+
+		var noContainerErr *NoContainerError
+		assert.ErrorAs(t, err, &noContainerErr)
+		assert.True(t, errors.Is(err, core.ErrNotFound))
+	})
+
+	t.Run("No image", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+
+		_, err := RunContainer(cli, cfg, &Options{Tag: "2"})
+
+		var noImageErr *NoImageError
+		assert.ErrorAs(t, err, &noImageErr)
+		assert.Equal(t, "test-image:2", noImageErr.Image())
+	})
+}
@@ -0,0 +1,414 @@
+package manage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// EventType defines what has happened with container.
+type EventType string
+
+// Event types.
+const (
+	EventDeployStarted    EventType = "deploy_started"    // RunContainer is going to create or replace container
+	EventDeploySucceeded  EventType = "deploy_succeeded"  // RunContainer has created or replaced container
+	EventDeployFailed     EventType = "deploy_failed"     // Operation (see Event.Operation) has failed
+	EventContainerRemoved EventType = "container_removed" // RunContainer with Options.Remove has removed container
+	EventRollback         EventType = "rollback"          // Rollback has replaced container with the previous version
+	EventRecovered        EventType = "recovered"         // Recover has resumed or cleaned containers
+)
+
+// isEventType checks if value is known event type.
+func isEventType(value EventType) bool {
+	switch value {
+	case EventDeployStarted, EventDeploySucceeded, EventDeployFailed, EventContainerRemoved, EventRollback,
+		EventRecovered:
+		return true
+	}
+	return false
+}
+
+// Event describes what has happened with container.
+type Event struct {
+	Type        EventType        `json:"type"`
+	Time        time.Time        `json:"time"`
+	Container   string           `json:"container"`
+	Operation   HistoryOperation `json:"operation"`
+	Actor       string           `json:"actor,omitempty"`
+	ContainerID string           `json:"container_id,omitempty"`
+	ImageID     string           `json:"image_id,omitempty"`
+	Tag         string           `json:"tag,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// Notifier reports events; it is implemented by WebhookNotifier.
+type Notifier interface {
+	// Notify reports event; it should not block operation for long.
+	Notify(event *Event)
+}
+
+// Webhook delivery defaults.
+const (
+	DefaultWebhookRetries = 3
+	DefaultWebhookBackoff = time.Second
+	DefaultWebhookTimeout = 10 * time.Second
+	// Time that operation waits for delivery of events to config webhooks.
+	DefaultWebhookFlushTimeout = 5 * time.Second
+)
+
+// Webhook request headers.
+const (
+	WebhookEventHeader     = "X-Containerator-Event"
+	WebhookSignatureHeader = "X-Containerator-Signature"
+)
+
+// webhookQueueSize defines how many events can wait for delivery.
+const webhookQueueSize = 100
+
+/*
+Webhook defines where events are posted.
+
+Payload is JSON event or, if template is set, template (text/template) executed with event;
+"json" function quotes value, so chat-friendly payload can be written as
+
+	{"text": {{ printf "%s: %s" .Container .Type | json }}}
+
+If secret is set, payload is signed with HMAC-SHA256 and signature is sent as "X-Containerator-Signature: sha256=<hex>".
+*/
+type Webhook struct {
+	URL      string      `yaml:"url" json:"url" toml:"url"`                                      // Receiver URL
+	Secret   string      `yaml:",omitempty" json:"secret,omitempty" toml:"secret,omitempty"`     // HMAC key
+	Template string      `yaml:",omitempty" json:"template,omitempty" toml:"template,omitempty"` // Payload template; JSON event if not set
+	Events   []EventType `yaml:",omitempty" json:"events,omitempty" toml:"events,omitempty"`     // Posted events; all if not set
+}
+
+/*
+WebhookOptions contains webhooks and defines how events are delivered.
+
+Failed request is retried after Backoff, then the delay is doubled for each next retry.
+*/
+type WebhookOptions struct {
+	Webhooks []Webhook
+	Retries  int           // Number of retries; DefaultWebhookRetries if not set, none if negative
+	Backoff  time.Duration // Delay before the first retry; DefaultWebhookBackoff if not set
+	Timeout  time.Duration // Request timeout; DefaultWebhookTimeout if not set
+	// Time that operation waits for delivery to config webhooks (see Options.Webhooks);
+	// DefaultWebhookFlushTimeout if not set
+	FlushTimeout time.Duration
+	// OnError is called when event is not delivered to webhook.
+	OnError func(webhook *Webhook, event *Event, err error)
+}
+
+type _Webhook struct {
+	*Webhook
+	template *template.Template
+}
+
+/*
+WebhookNotifier posts events to webhooks.
+
+Events are delivered in order in background; failed requests (network errors, 429 and 5xx responses) are retried.
+Close waits until queued events are delivered.
+*/
+type WebhookNotifier struct {
+	webhooks []_Webhook
+	options  WebhookOptions
+	client   *http.Client
+	queue    chan *Event
+	lock     sync.Mutex
+	closed   bool
+	done     chan struct{}
+}
+
+var webhookFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+// NewWebhookNotifier creates notifier; templates are checked.
+//
+//	NewWebhookNotifier(&WebhookOptions{Webhooks: []Webhook{{URL: "https://example.com/hook"}}}) -> notifier, err
+func NewWebhookNotifier(options *WebhookOptions) (*WebhookNotifier, error) {
+	notifier := &WebhookNotifier{
+		options: *options,
+		queue:   make(chan *Event, webhookQueueSize),
+		done:    make(chan struct{}),
+	}
+	if notifier.options.Retries == 0 {
+		notifier.options.Retries = DefaultWebhookRetries
+	}
+	if notifier.options.Backoff <= 0 {
+		notifier.options.Backoff = DefaultWebhookBackoff
+	}
+	if notifier.options.Timeout <= 0 {
+		notifier.options.Timeout = DefaultWebhookTimeout
+	}
+	notifier.client = &http.Client{Timeout: notifier.options.Timeout}
+	notifier.options.Webhooks = append([]Webhook(nil), options.Webhooks...)
+	for i := range notifier.options.Webhooks {
+		webhook := _Webhook{Webhook: &notifier.options.Webhooks[i]}
+		if webhook.URL == "" {
+			return nil, fmt.Errorf("webhooks[%d]: url is required", i)
+		}
+		if webhook.Template != "" {
+			tmpl, err := template.New(webhook.URL).Funcs(webhookFuncs).Parse(webhook.Template)
+			if err != nil {
+				return nil, fmt.Errorf("webhooks[%d]: %w", i, err)
+			}
+			webhook.template = tmpl
+		}
+		notifier.webhooks = append(notifier.webhooks, webhook)
+	}
+	go notifier.deliver()
+	return notifier, nil
+}
+
+// Notify queues event for delivery.
+//
+// WebhookOptions.OnError is called if event cannot be queued; notifier is not locked while it is called.
+func (notifier *WebhookNotifier) Notify(event *Event) {
+	if err := notifier.enqueue(event); err != nil {
+		notifier.reportAll(event, err)
+	}
+}
+
+func (notifier *WebhookNotifier) enqueue(event *Event) error {
+	notifier.lock.Lock()
+	defer notifier.lock.Unlock()
+	if notifier.closed {
+		return errors.New("notifier is closed")
+	}
+	select {
+	case notifier.queue <- event:
+		return nil
+	default:
+		return errors.New("queue is full")
+	}
+}
+
+func (notifier *WebhookNotifier) stop() {
+	notifier.lock.Lock()
+	defer notifier.lock.Unlock()
+	if !notifier.closed {
+		notifier.closed = true
+		close(notifier.queue)
+	}
+}
+
+// Close stops notifier and waits until queued events are delivered.
+func (notifier *WebhookNotifier) Close() {
+	notifier.stop()
+	<-notifier.done
+}
+
+// CloseTimeout stops notifier and waits until queued events are delivered but not longer than timeout.
+//
+// Returns false if events are still delivered; delivery continues in background and errors are still reported.
+//
+//	CloseTimeout(5 * time.Second) -> true
+func (notifier *WebhookNotifier) CloseTimeout(timeout time.Duration) bool {
+	notifier.stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-notifier.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (notifier *WebhookNotifier) reportAll(event *Event, err error) {
+	for i := range notifier.webhooks {
+		notifier.report(&notifier.webhooks[i], event, err)
+	}
+}
+
+func (notifier *WebhookNotifier) report(webhook *_Webhook, event *Event, err error) {
+	if notifier.options.OnError != nil {
+		notifier.options.OnError(webhook.Webhook, event, err)
+	}
+}
+
+func (notifier *WebhookNotifier) deliver() {
+	defer close(notifier.done)
+	for event := range notifier.queue {
+		for i := range notifier.webhooks {
+			webhook := &notifier.webhooks[i]
+			if !webhook.accepts(event.Type) {
+				continue
+			}
+			if err := notifier.post(webhook, event); err != nil {
+				notifier.report(webhook, event, err)
+			}
+		}
+	}
+}
+
+func (webhook *_Webhook) accepts(eventType EventType) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, item := range webhook.Events {
+		if item == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (webhook *_Webhook) makePayload(event *Event) ([]byte, error) {
+	if webhook.template == nil {
+		return json.Marshal(event)
+	}
+	var buffer bytes.Buffer
+	if err := webhook.template.Execute(&buffer, event); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// SignPayload returns signature of webhook payload.
+//
+//	SignPayload([]byte(`{"type":"rollback"}`), "secret") -> "sha256=..."
+func SignPayload(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// send makes single request; returned flag tells if request can be retried.
+func (notifier *WebhookNotifier) send(webhook *_Webhook, eventType EventType, payload []byte) (bool, error) {
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(WebhookEventHeader, string(eventType))
+	if webhook.Secret != "" {
+		request.Header.Set(WebhookSignatureHeader, SignPayload(payload, webhook.Secret))
+	}
+	response, err := notifier.client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("%s: status %d", webhook.URL, response.StatusCode)
+	return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500, err
+}
+
+func (notifier *WebhookNotifier) post(webhook *_Webhook, event *Event) error {
+	payload, err := webhook.makePayload(event)
+	if err != nil {
+		return err
+	}
+	backoff := notifier.options.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := notifier.send(webhook, event.Type, payload)
+		if !retry || attempt >= notifier.options.Retries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// getEventType returns type of event that describes recorded operation; empty if there is nothing to report.
+func getEventType(record *HistoryRecord) EventType {
+	switch record.Result {
+	case ResultFailure:
+		return EventDeployFailed
+	case ResultUnchanged:
+		return ""
+	}
+	switch record.Operation {
+	case OperationRun:
+		return EventDeploySucceeded
+	case OperationRemove:
+		return EventContainerRemoved
+	case OperationRollback:
+		return EventRollback
+	case OperationRecover:
+		return EventRecovered
+	}
+	return ""
+}
+
+// logWebhookError is default WebhookOptions.OnError for config webhooks.
+func logWebhookError(webhook *Webhook, event *Event, err error) {
+	log.Printf("containerator: webhook %s: %s %s: %v", webhook.URL, event.Container, event.Type, err)
+}
+
+/*
+withConfigNotifier returns options with notifier that posts events to config webhooks if Options.Notifier is not set.
+
+Delivery is defined by Options.Webhooks; errors are logged with standard logger if OnError is not set.
+Returned function waits for delivery not longer than flush timeout; the rest is delivered in background.
+*/
+func withConfigNotifier(cfg *Config, options *Options) (*Options, func(), error) {
+	if options.Notifier != nil || len(cfg.Webhooks) == 0 {
+		return options, func() {}, nil
+	}
+	resolved, err := resolveConfig(&Config{Webhooks: cfg.Webhooks, origins: cfg.origins}, options.Postfix, options.Tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	var webhookOptions WebhookOptions
+	if options.Webhooks != nil {
+		webhookOptions = *options.Webhooks
+	}
+	webhookOptions.Webhooks = resolved.Webhooks
+	if webhookOptions.OnError == nil {
+		webhookOptions.OnError = logWebhookError
+	}
+	flushTimeout := webhookOptions.FlushTimeout
+	if flushTimeout <= 0 {
+		flushTimeout = DefaultWebhookFlushTimeout
+	}
+	notifier, err := NewWebhookNotifier(&webhookOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := *options
+	result.Notifier = notifier
+	return &result, func() { notifier.CloseTimeout(flushTimeout) }, nil
+}
+
+// notify reports event to Options.Notifier if it is set.
+func notify(options *Options, eventType EventType, record *HistoryRecord, containerID string) {
+	if options.Notifier == nil || eventType == "" {
+		return
+	}
+	eventTime := record.Time
+	if eventTime.IsZero() {
+		eventTime = now().UTC()
+	}
+	options.Notifier.Notify(&Event{
+		Type:        eventType,
+		Time:        eventTime,
+		Container:   record.Container,
+		Operation:   record.Operation,
+		Actor:       options.Actor,
+		ContainerID: containerID,
+		ImageID:     record.ImageID,
+		Tag:         record.Tag,
+		Error:       record.Error,
+	})
+}
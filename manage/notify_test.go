@@ -0,0 +1,308 @@
+package manage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+type _TestRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// _TestReceiver records webhook requests; first requests fail with configured statuses.
+type _TestReceiver struct {
+	lock     sync.Mutex
+	requests []_TestRequest
+	statuses []int
+}
+
+func (receiver *_TestReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+	receiver.requests = append(receiver.requests, _TestRequest{r.Header, body})
+	if len(receiver.statuses) > 0 {
+		w.WriteHeader(receiver.statuses[0])
+		receiver.statuses = receiver.statuses[1:]
+	}
+}
+
+func (receiver *_TestReceiver) events(t *testing.T) []Event {
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+	var events []Event
+	for _, request := range receiver.requests {
+		var event Event
+		assert.NoError(t, json.Unmarshal(request.body, &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func newTestReceiver(t *testing.T, statuses ...int) (*_TestReceiver, string) {
+	receiver := &_TestReceiver{statuses: statuses}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+	return receiver, server.URL
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	event := &Event{
+		Type:      EventDeploySucceeded,
+		Time:      time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Container: "test-image",
+		Operation: OperationRun,
+		Tag:       "2",
+	}
+
+	t.Run("Signed payload", func(t *testing.T) {
+		receiver, url := newTestReceiver(t)
+		notifier, err := NewWebhookNotifier(&WebhookOptions{Webhooks: []Webhook{{URL: url, Secret: "secret"}}})
+		assert.NoError(t, err)
+
+		notifier.Notify(event)
+		notifier.Close()
+
+		assert.Len(t, receiver.requests, 1)
+		request := receiver.requests[0]
+		assert.Equal(t, []Event{*event}, receiver.events(t))
+		assert.Equal(t, "deploy_succeeded", request.header.Get(WebhookEventHeader))
+		assert.Equal(t, "application/json", request.header.Get("Content-Type"))
+		assert.Equal(t, SignPayload(request.body, "secret"), request.header.Get(WebhookSignatureHeader))
+		assert.NotEqual(t, SignPayload(request.body, "other"), request.header.Get(WebhookSignatureHeader))
+	})
+
+	t.Run("Template", func(t *testing.T) {
+		receiver, url := newTestReceiver(t)
+		notifier, _ := NewWebhookNotifier(&WebhookOptions{Webhooks: []Webhook{{
+			URL:      url,
+			Template: `{"text": {{ printf "%s: %s (%s)" .Container .Type .Tag | json }}}`,
+		}}})
+
+		notifier.Notify(event)
+		notifier.Close()
+
+		assert.Len(t, receiver.requests, 1)
+		assert.Equal(t, `{"text": "test-image: deploy_succeeded (2)"}`, string(receiver.requests[0].body))
+		assert.Empty(t, receiver.requests[0].header.Get(WebhookSignatureHeader))
+	})
+
+	t.Run("Bad template", func(t *testing.T) {
+		_, err := NewWebhookNotifier(&WebhookOptions{Webhooks: []Webhook{{URL: "http://localhost", Template: "{{ .Type"}}})
+
+		assert.ErrorContains(t, err, "webhooks[0]")
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		receiver, url := newTestReceiver(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+		var errs []error
+		notifier, _ := NewWebhookNotifier(&WebhookOptions{
+			Webhooks: []Webhook{{URL: url}},
+			Backoff:  time.Millisecond,
+			OnError:  func(webhook *Webhook, event *Event, err error) { errs = append(errs, err) },
+		})
+
+		notifier.Notify(event)
+		notifier.Close()
+
+		assert.Len(t, receiver.requests, 3)
+		assert.Empty(t, errs)
+	})
+
+	t.Run("Give up", func(t *testing.T) {
+		receiver, url := newTestReceiver(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadRequest)
+		var errs []error
+		notifier, _ := NewWebhookNotifier(&WebhookOptions{
+			Webhooks: []Webhook{{URL: url}},
+			Retries:  5,
+			Backoff:  time.Millisecond,
+			OnError:  func(webhook *Webhook, event *Event, err error) { errs = append(errs, err) },
+		})
+
+		notifier.Notify(event)
+		notifier.Close()
+
+		assert.Len(t, receiver.requests, 3)
+		assert.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "status 400")
+	})
+
+	t.Run("Events filter", func(t *testing.T) {
+		receiver, url := newTestReceiver(t)
+		notifier, _ := NewWebhookNotifier(&WebhookOptions{
+			Webhooks: []Webhook{{URL: url, Events: []EventType{EventDeployFailed}}},
+		})
+
+		notifier.Notify(event)
+		notifier.Notify(&Event{Type: EventDeployFailed, Container: "test-image", Error: "failure"})
+		notifier.Close()
+
+		events := receiver.events(t)
+		assert.Len(t, events, 1)
+		assert.Equal(t, EventDeployFailed, events[0].Type)
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		var notifier *WebhookNotifier
+		var errs []error
+		notifier, _ = NewWebhookNotifier(&WebhookOptions{
+			Webhooks: []Webhook{{URL: "http://localhost"}},
+			OnError: func(webhook *Webhook, event *Event, err error) {
+				errs = append(errs, err)
+				if len(errs) == 1 {
+					notifier.Notify(event)
+				}
+			},
+		})
+		notifier.Close()
+
+		notifier.Notify(event)
+
+		assert.Len(t, errs, 2)
+		assert.EqualError(t, errs[0], "notifier is closed")
+	})
+}
+
+func TestRunContainerNotify(t *testing.T) {
+	cfg := &Config{
+		ImageName: "test-image",
+		Ports:     []core.Mapping{{Source: "5001", Target: "80"}},
+	}
+
+	t.Run("Operations", func(t *testing.T) {
+		receiver, url := newTestReceiver(t)
+		notifier, _ := NewWebhookNotifier(&WebhookOptions{Webhooks: []Webhook{{URL: url}}})
+		engine, cli := newTestEngine()
+		imageID := engine.AddImage("test-image:1")
+		options := &Options{Tag: "1", Actor: "admin", Notifier: notifier}
+
+		cont, _ := RunContainer(cli, cfg, options)
+		RunContainer(cli, cfg, options)
+		RunContainer(cli, cfg, &Options{Tag: "2", Actor: "admin", Notifier: notifier})
+		RunContainer(cli, cfg, &Options{Remove: true, Actor: "admin", Notifier: notifier})
+		notifier.Close()
+
+		events := receiver.events(t)
+		for i := range events {
+			assert.False(t, events[i].Time.IsZero())
+			events[i].Time = time.Time{}
+		}
+		assert.Equal(t, []Event{
+			{
+				Type: EventDeployStarted, Container: "test-image", Operation: OperationRun, Actor: "admin",
+				ImageID: imageID, Tag: "1",
+			},
+			{
+				Type: EventDeploySucceeded, Container: "test-image", Operation: OperationRun, Actor: "admin",
+				ContainerID: cont.ID(), ImageID: imageID, Tag: "1",
+			},
+			{
+				Type: EventDeployFailed, Container: "test-image", Operation: OperationRun, Actor: "admin",
+				Error: "image 'test-image:2' is not found",
+			},
+			{
+				Type: EventContainerRemoved, Container: "test-image", Operation: OperationRemove, Actor: "admin",
+				ContainerID: cont.ID(), ImageID: imageID, Tag: "1",
+			},
+		}, events)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		receiver, url := newTestReceiver(t)
+		notifier, _ := NewWebhookNotifier(&WebhookOptions{
+			Webhooks: []Webhook{{URL: url, Events: []EventType{EventRollback}}},
+		})
+		setTestClock(t)
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		keepCfg := &Config{ImageName: "test-image", Deploy: &DeployConfig{Keep: 1}}
+		prev, _ := RunContainer(cli, keepCfg, &Options{Tag: "1"})
+		RunContainer(cli, keepCfg, &Options{Tag: "2"})
+
+		_, err := Rollback(cli, keepCfg, &Options{Notifier: notifier})
+		notifier.Close()
+
+		assert.NoError(t, err)
+		events := receiver.events(t)
+		assert.Len(t, events, 1)
+		assert.Equal(t, OperationRollback, events[0].Operation)
+		assert.Equal(t, prev.ID(), events[0].ContainerID)
+		assert.Equal(t, "1", events[0].Tag)
+	})
+
+	t.Run("Config webhooks", func(t *testing.T) {
+		receiver, url := newTestReceiver(t)
+		t.Setenv("TEST_WEBHOOK_URL", url)
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		webhookCfg := &Config{
+			ImageName: "test-image",
+			Webhooks:  []Webhook{{URL: "${TEST_WEBHOOK_URL}", Events: []EventType{EventDeploySucceeded}}},
+		}
+
+		cont, err := RunContainer(cli, webhookCfg, &Options{Tag: "1"})
+
+		assert.NoError(t, err)
+		events := receiver.events(t)
+		assert.Len(t, events, 1)
+		assert.Equal(t, EventDeploySucceeded, events[0].Type)
+		assert.Equal(t, cont.ID(), events[0].ContainerID)
+	})
+
+	t.Run("Config webhook errors", func(t *testing.T) {
+		_, url := newTestReceiver(t, http.StatusBadRequest, http.StatusBadRequest)
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		webhookCfg := &Config{
+			ImageName: "test-image",
+			Webhooks:  []Webhook{{URL: url, Events: []EventType{EventDeploySucceeded}}},
+		}
+		var errs []error
+		var buffer bytes.Buffer
+		log.SetOutput(&buffer)
+		defer log.SetOutput(os.Stderr)
+
+		RunContainer(cli, webhookCfg, &Options{Tag: "1", Webhooks: &WebhookOptions{
+			OnError: func(webhook *Webhook, event *Event, err error) { errs = append(errs, err) },
+		}})
+		RunContainer(cli, webhookCfg, &Options{Tag: "1", Force: true})
+
+		assert.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "status 400")
+		assert.Contains(t, buffer.String(), "containerator: webhook "+url+": test-image deploy_succeeded")
+	})
+
+	t.Run("Config webhook flush timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		webhookCfg := &Config{ImageName: "test-image", Webhooks: []Webhook{{URL: server.URL}}}
+		start := time.Now()
+
+		_, err := RunContainer(cli, webhookCfg, &Options{Tag: "1", Webhooks: &WebhookOptions{
+			FlushTimeout: 50 * time.Millisecond,
+			OnError:      func(webhook *Webhook, event *Event, err error) {},
+		}})
+
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
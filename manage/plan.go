@@ -0,0 +1,138 @@
+package manage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+)
+
+// PlanAction defines what RunContainer is going to do.
+type PlanAction string
+
+// Plan actions.
+const (
+	ActionCreate  PlanAction = "create"  // New container is created
+	ActionReplace PlanAction = "replace" // Running container is replaced with new one
+	ActionRemove  PlanAction = "remove"  // Running container is removed
+	ActionNone    PlanAction = "none"    // Running container matches config
+)
+
+// DeployPlan describes changes that RunContainer would make.
+type DeployPlan struct {
+	Action      PlanAction                // What is going to be done
+	Name        string                    // Container name
+	Current     core.Container            // Container to replace or remove; nil if there is no container
+	Image       core.Image                // Image to create container from; nil for ActionRemove
+	Options     *core.RunContainerOptions // Options of container to create; nil for ActionRemove and ActionNone
+	Strategy    DeployStrategy            // How current container is replaced
+	Differences []Difference              // Differences between current container and config
+
+	health *HealthGate
+	keep   int
+	hooks  *HooksConfig
+}
+
+func (plan *DeployPlan) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s %s\n", plan.Action, plan.Name)
+	if plan.Action == ActionReplace {
+		fmt.Fprintf(&builder, "  strategy: %s\n", plan.Strategy)
+	}
+	if plan.Current != nil {
+		fmt.Fprintf(&builder, "  current: %s (%s)\n", plan.Current.ShortID(), plan.Current.State())
+	}
+	if plan.Image != nil {
+		fmt.Fprintf(&builder, "  image: %s (%s)\n", plan.Image.FullName(), plan.Image.ShortID())
+	}
+	for _, diff := range plan.Differences {
+		fmt.Fprintf(&builder, "  %s\n", diff)
+	}
+	return builder.String()
+}
+
+/*
+Plan shows what RunContainer would do with the same arguments; nothing is changed.
+
+Plan resolves image and container, builds container options and compares them with running container.
+Errors that RunContainer would return before changing anything (no image, port conflict) are returned.
+
+	Plan(cli, cfg, &Options{Tag: "2"}) -> &DeployPlan{Action: ActionReplace, Differences: []Difference{{"image", ...}}}, err
+*/
+func Plan(cli core.Runtime, cfg *Config, options *Options) (*DeployPlan, error) {
+	cfg, err := applyProfile(cfg, options.Postfix)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err = resolveConfig(cfg, options.Postfix, options.Tag)
+	if err != nil {
+		return nil, err
+	}
+	plan := &DeployPlan{Name: getContainerName(cfg, options.Postfix), Strategy: getStrategy(cfg.Deploy)}
+	if plan.Strategy != StrategyRecreate && plan.Strategy != StrategyBlueGreen && plan.Strategy != StrategyProxy {
+		return nil, fmt.Errorf("unknown deploy strategy '%s'", plan.Strategy)
+	}
+	if plan.Strategy == StrategyBlueGreen && hasHostPorts(cfg.Ports) {
+		return nil, errBlueGreenHostPorts
+	}
+
+	plan.hooks = cfg.Hooks
+	plan.Current, err = core.IgnoreNotFound(core.FindContainerByName(cli, plan.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Remove {
+		if plan.Current == nil {
+			return nil, &NoContainerError{plan.Name}
+		}
+		plan.Action = ActionRemove
+		return plan, nil
+	}
+
+	plan.Image, err = findImage(cli, cfg.ImageName, options.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	runOptions, err := buildContainerOptions(cfg, plan.Image.FullName(), plan.Name, options)
+	if err != nil {
+		return nil, err
+	}
+	deployTime := now().UTC()
+	runOptions.Labels = map[string]string{
+		LabelConfigHash: hashOptions(runOptions),
+		LabelName:       plan.Name,
+		LabelDeployTime: deployTime.Format(time.RFC3339Nano),
+		LabelOperation:  newOperationID(deployTime),
+	}
+
+	plan.Action = ActionCreate
+	if plan.Current != nil {
+		desired := runOptions
+		if plan.Strategy == StrategyProxy {
+			desired = withDynamicPorts(runOptions)
+		}
+		plan.Differences, err = detectDrift(cli, plan.Current, desired, plan.Image.ID())
+		if err != nil {
+			return nil, err
+		}
+		plan.Action = ActionReplace
+		if len(plan.Differences) == 0 && !options.Force {
+			plan.Action = ActionNone
+			return plan, nil
+		}
+	}
+
+	// With proxy strategy configured host ports are bound by router rather than by container.
+	if plan.Strategy != StrategyProxy {
+		if err := checkPortConflicts(cli, runOptions.Ports, plan.Current); err != nil {
+			return nil, err
+		}
+	}
+	plan.Options = runOptions
+	plan.health = getHealthGate(cfg.Deploy)
+	plan.keep = getKeep(cfg.Deploy)
+	return plan, nil
+}
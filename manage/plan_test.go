@@ -0,0 +1,101 @@
+package manage
+
+import (
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan(t *testing.T) {
+	cfg := &Config{
+		ImageName: "test-image",
+		Ports: []core.Mapping{
+			{Source: "5001", Target: "80"},
+		},
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		imageID := engine.AddImage("test-image:1")
+
+		plan, err := Plan(cli, cfg, &Options{Tag: "1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, ActionCreate, plan.Action)
+		assert.Equal(t, "test-image", plan.Name)
+		assert.Nil(t, plan.Current)
+		assert.Equal(t, imageID, plan.Image.ID())
+		assert.Equal(t, "1", plan.Image.Tag())
+		assert.Equal(t, "test-image:1", plan.Options.Image)
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Empty(t, ids)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		imageID := engine.AddImage("test-image:2")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+		changed := *cfg
+		changed.Ports = []core.Mapping{{Source: "5002", Target: "80"}}
+
+		plan, err := Plan(cli, &changed, &Options{Tag: "2"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, ActionReplace, plan.Action)
+		assert.Equal(t, prev.ID(), plan.Current.ID())
+		assert.Equal(t, []Difference{
+			{"image", prev.ImageID(), imageID},
+			{"ports", "5001:80/tcp", "5002:80/tcp"},
+		}, plan.Differences)
+		cont, _ := core.FindContainerByName(cli, "test-image")
+		assert.Equal(t, prev.ID(), cont.ID())
+	})
+
+	t.Run("None", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		plan, err := Plan(cli, cfg, &Options{Tag: "1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, ActionNone, plan.Action)
+		assert.Equal(t, prev.ID(), plan.Current.ID())
+		assert.Nil(t, plan.Options)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		plan, err := Plan(cli, cfg, &Options{Remove: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, ActionRemove, plan.Action)
+		assert.Equal(t, prev.ID(), plan.Current.ID())
+		_, err = core.FindContainerByID(cli, prev.ID())
+		assert.NoError(t, err)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+		changed := *cfg
+		changed.Env = []core.Mapping{{Source: "A", Target: "1"}}
+
+		plan, _ := Plan(cli, &changed, &Options{Tag: "1"})
+
+		assert.Equal(t,
+			"replace test-image\n"+
+				"  strategy: recreate\n"+
+				"  current: "+prev.ShortID()+" (running)\n"+
+				"  image: test-image:1 ("+plan.Image.ShortID()+")\n"+
+				"  env: <none> -> A=1\n",
+			plan.String(),
+		)
+	})
+}
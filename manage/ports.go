@@ -0,0 +1,110 @@
+package manage
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/docker/go-connections/nat"
+)
+
+const maxPort = 65535
+
+// getPortOffset returns port offset for the postfix.
+//
+// Offset from options has priority over config offsets table.
+func getPortOffset(cfg *Config, options *Options) int {
+	if options.PortOffset != 0 {
+		return options.PortOffset
+	}
+	return cfg.PortOffsets[options.Postfix]
+}
+
+// shiftPorts adds offset to host ports.
+//
+// Host port can be a single port or a range with optional host IP; empty host port (dynamic port) is kept.
+//
+//	shiftPorts([]core.Mapping{{"5001", "80"}, {"6000-6001", "90-91"}}, 10) -> []core.Mapping{{"5011", "80"}, {"6010-6011", "90-91"}}
+//	shiftPorts([]core.Mapping{{"127.0.0.1:5001", "80"}}, 10) -> []core.Mapping{{"127.0.0.1:5011", "80"}}
+func shiftPorts(mappings []core.Mapping, offset int) ([]core.Mapping, error) {
+	if offset == 0 || len(mappings) == 0 {
+		return mappings, nil
+	}
+	result := make([]core.Mapping, len(mappings))
+	for i, mapping := range mappings {
+		result[i] = mapping
+		ip, hostPort := core.SplitHostIP(mapping.Source)
+		if hostPort == "" {
+			continue
+		}
+		start, end, err := nat.ParsePortRange(hostPort)
+		if err != nil {
+			return nil, fmt.Errorf("bad host port '%s': %w", mapping.Source, err)
+		}
+		first, last := int(start)+offset, int(end)+offset
+		if first < 1 || last > maxPort {
+			return nil, fmt.Errorf("host port '%s' with offset %d is out of range", mapping.Source, offset)
+		}
+		shifted := strconv.Itoa(first)
+		if first != last {
+			shifted = fmt.Sprintf("%d-%d", first, last)
+		}
+		if ip != "" {
+			shifted = net.JoinHostPort(ip, shifted)
+		}
+		result[i].Source = shifted
+	}
+	return result, nil
+}
+
+var errBlueGreenHostPorts = errors.New(
+	"blue-green strategy cannot replace container with host ports; use proxy strategy or dynamic host ports",
+)
+
+// hasHostPorts tells if some of container ports are published on configured host ports.
+//
+//	hasHostPorts([]core.Mapping{{"", "80"}}) -> false
+//	hasHostPorts([]core.Mapping{{"", "80"}, {"127.0.0.1:5001", "81"}}) -> true
+func hasHostPorts(mappings []core.Mapping) bool {
+	for _, mapping := range mappings {
+		if _, hostPort := core.SplitHostIP(mapping.Source); hostPort != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPortConflicts returns error if host ports are bound by other containers.
+//
+// Ports are compared along with protocol, so "53/udp" does not conflict with "53/tcp".
+// Ports of the current container are not checked because it is replaced.
+func checkPortConflicts(cli core.Runtime, mappings []core.Mapping, currentContainer core.Container) error {
+	if len(mappings) == 0 {
+		return nil
+	}
+	boundPorts, err := core.ListHostPorts(cli)
+	if err != nil {
+		return err
+	}
+	for _, mapping := range mappings {
+		_, hostPort := core.SplitHostIP(mapping.Source)
+		if hostPort == "" {
+			continue
+		}
+		start, end, err := nat.ParsePortRange(hostPort)
+		if err != nil {
+			return fmt.Errorf("bad host port '%s': %w", mapping.Source, err)
+		}
+		proto, _ := nat.SplitProtoPort(mapping.Target)
+		for port := start; port <= end; port++ {
+			hostPort, _ := nat.NewPort(proto, strconv.FormatUint(port, 10))
+			other, ok := boundPorts[hostPort]
+			if ok && (currentContainer == nil || other.ID() != currentContainer.ID()) {
+				return &PortConflictError{int(port), other.Name()}
+			}
+		}
+	}
+	return nil
+}
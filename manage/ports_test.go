@@ -0,0 +1,122 @@
+package manage
+
+import (
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPortOffset(t *testing.T) {
+	cfg := &Config{PortOffsets: map[string]int{"test": 10, "prod": 20}}
+
+	assert.Equal(t, 0, getPortOffset(cfg, &Options{Postfix: "dev"}))
+	assert.Equal(t, 10, getPortOffset(cfg, &Options{Postfix: "test"}))
+	assert.Equal(t, 5, getPortOffset(cfg, &Options{Postfix: "test", PortOffset: 5}))
+}
+
+func TestShiftPorts(t *testing.T) {
+	t.Run("Shift", func(t *testing.T) {
+		ports := []core.Mapping{
+			{Source: "5001", Target: "80"},
+			{Source: "6000-6001", Target: "90-91"},
+			{Source: "", Target: "100"},
+			{Source: "127.0.0.1:7001", Target: "110"},
+			{Source: "[::1]:8001", Target: "120"},
+			{Source: "127.0.0.1:", Target: "130"},
+		}
+
+		actual, err := shiftPorts(ports, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []core.Mapping{
+			{Source: "5011", Target: "80"},
+			{Source: "6010-6011", Target: "90-91"},
+			{Source: "", Target: "100"},
+			{Source: "127.0.0.1:7011", Target: "110"},
+			{Source: "[::1]:8011", Target: "120"},
+			{Source: "127.0.0.1:", Target: "130"},
+		}, actual)
+		assert.Equal(t, "5001", ports[0].Source)
+	})
+
+	t.Run("Out of range", func(t *testing.T) {
+		_, err := shiftPorts([]core.Mapping{{Source: "65530", Target: "80"}}, 10)
+
+		assert.EqualError(t, err, "host port '65530' with offset 10 is out of range")
+	})
+
+	t.Run("Bad port", func(t *testing.T) {
+		_, err := shiftPorts([]core.Mapping{{Source: "abc", Target: "80"}}, 10)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestRunContainerPortOffset(t *testing.T) {
+	cfg := &Config{
+		ImageName:   "test-image",
+		Ports:       []core.Mapping{{Source: "5001", Target: "80"}},
+		PortOffsets: map[string]int{"test": 10},
+	}
+
+	t.Run("Side by side", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+
+		_, err := RunContainer(cli, cfg, &Options{Postfix: "dev", Tag: "1"})
+		assert.NoError(t, err)
+		_, err = RunContainer(cli, cfg, &Options{Postfix: "test", Tag: "1"})
+		assert.NoError(t, err)
+
+		ports, _ := core.ListHostPorts(cli)
+		assert.Equal(t, "test-image-dev", ports["5001/tcp"].Name())
+		assert.Equal(t, "test-image-test", ports["5011/tcp"].Name())
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		_, err := RunContainer(cli, cfg, &Options{Postfix: "dev", Tag: "1"})
+		assert.NoError(t, err)
+
+		_, err = RunContainer(cli, cfg, &Options{Postfix: "prod", Tag: "1"})
+
+		var conflictErr *PortConflictError
+		assert.ErrorAs(t, err, &conflictErr)
+		assert.ErrorIs(t, err, core.ErrConflict)
+		assert.Equal(t, 5001, conflictErr.Port())
+		assert.Equal(t, "test-image-dev", conflictErr.Container())
+		_, err = core.FindContainerByName(cli, "test-image-prod")
+		assert.ErrorIs(t, err, core.ErrNotFound)
+	})
+
+	t.Run("Other protocol", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("dns-image:1")
+		_, err := RunContainer(cli, &Config{
+			ImageName: "dns-image",
+			Ports:     []core.Mapping{{Source: "5001", Target: "53/udp"}},
+		}, &Options{Tag: "1"})
+		assert.NoError(t, err)
+
+		_, err = RunContainer(cli, cfg, &Options{Postfix: "dev", Tag: "1"})
+
+		assert.NoError(t, err)
+		ports, _ := core.ListHostPorts(cli)
+		assert.Equal(t, "dns-image", ports["5001/udp"].Name())
+		assert.Equal(t, "test-image-dev", ports["5001/tcp"].Name())
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		_, err := RunContainer(cli, cfg, &Options{Postfix: "dev", Tag: "1"})
+		assert.NoError(t, err)
+
+		_, err = RunContainer(cli, cfg, &Options{Postfix: "dev", Tag: "1", Force: true})
+
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,119 @@
+package manage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DmitryBogomolov/containerator/core"
+)
+
+// MergeStrategy defines how profile lists are combined with config lists.
+type MergeStrategy string
+
+// Merge strategies.
+const (
+	// MergeAppend adds profile items to config items; items with the same key are overridden.
+	// Key is variable name for env, container port and protocol for ports, container path for volumes.
+	MergeAppend MergeStrategy = "append"
+	// MergeReplace replaces config list with profile list (if profile list is set).
+	MergeReplace MergeStrategy = "replace"
+)
+
+// Profile contains config overrides for a postfix.
+type Profile struct {
+	Merge   MergeStrategy  `yaml:",omitempty" json:"merge,omitempty" toml:"merge,omitempty"`               // Lists merge strategy; "append" by default
+	Network string         `yaml:",omitempty" json:"network,omitempty" toml:"network,omitempty"`           // Container network
+	Ports   []core.Mapping `yaml:",omitempty" json:"ports,omitempty" toml:"ports,omitempty"`               // Ports mapping
+	Volumes []core.Mapping `yaml:",omitempty" json:"volumes,omitempty" toml:"volumes,omitempty"`           // Volumes mapping
+	Env     []core.Mapping `yaml:",omitempty" json:"env,omitempty" toml:"env,omitempty"`                   // Environment variables
+	EnvFile []string       `yaml:"env_file,omitempty" json:"env_file,omitempty" toml:"env_file,omitempty"` // Env files
+}
+
+func mergeMappings(
+	base []core.Mapping, items []core.Mapping, strategy MergeStrategy, getKey func(core.Mapping) string,
+) []core.Mapping {
+	if items == nil {
+		return base
+	}
+	if strategy == MergeReplace {
+		return items
+	}
+	result := make([]core.Mapping, 0, len(base)+len(items))
+	indexes := map[string]int{}
+	for _, list := range [][]core.Mapping{base, items} {
+		for _, mapping := range list {
+			key := getKey(mapping)
+			if idx, ok := indexes[key]; ok {
+				result[idx] = mapping
+				continue
+			}
+			indexes[key] = len(result)
+			result = append(result, mapping)
+		}
+	}
+	return result
+}
+
+func mergeStrings(base []string, items []string, strategy MergeStrategy) []string {
+	if items == nil {
+		return base
+	}
+	if strategy == MergeReplace {
+		return items
+	}
+	result := make([]string, 0, len(base)+len(items))
+	result = append(result, base...)
+	for _, item := range items {
+		if findIndex(item, result) < 0 {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func getMappingSource(mapping core.Mapping) string {
+	return mapping.Source
+}
+
+// getContainerPort returns container port with protocol; "tcp" is default protocol.
+//
+//	getContainerPort({"5001", "80"}) -> "80/tcp"
+func getContainerPort(mapping core.Mapping) string {
+	if strings.Contains(mapping.Target, "/") {
+		return mapping.Target
+	}
+	return mapping.Target + "/tcp"
+}
+
+func getVolumePath(mapping core.Mapping) string {
+	path, _ := core.SplitVolumeTarget(mapping.Target)
+	return path
+}
+
+// applyProfile makes copy of config merged with profile for the postfix.
+//
+// Config is returned as is if there is no such profile.
+//
+//	applyProfile(&Config{Env: {{"A", "1"}}, Profiles: {"dev": {Env: {{"B", "2"}}}}}, "dev") -> &Config{Env: {{"A", "1"}, {"B", "2"}}}
+func applyProfile(cfg *Config, postfix string) (*Config, error) {
+	profile := cfg.Profiles[postfix]
+	if profile == nil {
+		return cfg, nil
+	}
+	strategy := profile.Merge
+	if strategy == "" {
+		strategy = MergeAppend
+	}
+	if strategy != MergeAppend && strategy != MergeReplace {
+		return nil, fmt.Errorf("profile '%s': unknown merge strategy '%s'", postfix, strategy)
+	}
+	result := *cfg
+	if profile.Network != "" {
+		result.Network = profile.Network
+	}
+	result.Ports = mergeMappings(cfg.Ports, profile.Ports, strategy, getContainerPort)
+	result.Volumes = mergeMappings(cfg.Volumes, profile.Volumes, strategy, getVolumePath)
+	result.Env = mergeMappings(cfg.Env, profile.Env, strategy, getMappingSource)
+	result.EnvFile = mergeStrings(cfg.EnvFile, profile.EnvFile, strategy)
+	return &result, nil
+}
@@ -0,0 +1,119 @@
+package manage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyProfile(t *testing.T) {
+	cfg := &Config{
+		ImageName: "test-image",
+		Network:   "net",
+		Ports:     []core.Mapping{{Source: "5001", Target: "80"}, {Source: "5002", Target: "81"}},
+		Volumes:   []core.Mapping{{Source: "/a", Target: "/data"}},
+		Env:       []core.Mapping{{Source: "A", Target: "1"}, {Source: "B", Target: "2"}},
+		EnvFile:   []string{"/env.list"},
+		Profiles: map[string]*Profile{
+			"dev": {
+				Network: "dev-net",
+				Ports:   []core.Mapping{{Source: "6001", Target: "80"}, {Source: "5003", Target: "81/udp"}},
+				Volumes: []core.Mapping{{Source: "/b", Target: "/data"}},
+				Env:     []core.Mapping{{Source: "B", Target: "3"}},
+				EnvFile: []string{"/env.list", "/env-dev.list"},
+			},
+			"prod": {
+				Merge: MergeReplace,
+				Env:   []core.Mapping{{Source: "C", Target: "4"}},
+			},
+			"bad": {
+				Merge: "other",
+			},
+		},
+	}
+
+	t.Run("Append", func(t *testing.T) {
+		result, err := applyProfile(cfg, "dev")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "dev-net", result.Network)
+		assert.Equal(t, []core.Mapping{
+			{Source: "6001", Target: "80"}, {Source: "5002", Target: "81"}, {Source: "5003", Target: "81/udp"},
+		}, result.Ports)
+		assert.Equal(t, []core.Mapping{{Source: "/b", Target: "/data"}}, result.Volumes)
+		assert.Equal(t, []core.Mapping{{Source: "A", Target: "1"}, {Source: "B", Target: "3"}}, result.Env)
+		assert.Equal(t, []string{"/env.list", "/env-dev.list"}, result.EnvFile)
+		assert.Equal(t, "net", cfg.Network, "source config is not changed")
+		assert.Equal(t, []core.Mapping{{Source: "A", Target: "1"}, {Source: "B", Target: "2"}}, cfg.Env)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		result, err := applyProfile(cfg, "prod")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "net", result.Network)
+		assert.Equal(t, cfg.Ports, result.Ports)
+		assert.Equal(t, []core.Mapping{{Source: "C", Target: "4"}}, result.Env)
+	})
+
+	t.Run("No profile", func(t *testing.T) {
+		result, err := applyProfile(cfg, "test")
+
+		assert.NoError(t, err)
+		assert.Same(t, cfg, result)
+	})
+
+	t.Run("Bad strategy", func(t *testing.T) {
+		_, err := applyProfile(cfg, "bad")
+
+		assert.EqualError(t, err, "profile 'bad': unknown merge strategy 'other'")
+	})
+}
+
+func TestReadConfigProfiles(t *testing.T) {
+	pathToFile := writeTestFile(t, "test.yaml",
+		"image_name: test-image",
+		"env:",
+		"- A: 1",
+		"profiles:",
+		"  prod:",
+		"    merge: replace",
+		"    network: prod-net",
+		"    volumes:",
+		"    - ./data: /data",
+		"    env:",
+		"    - A: 2",
+	)
+
+	config, err := ReadConfig(pathToFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]*Profile{
+		"prod": {
+			Merge:   MergeReplace,
+			Network: "prod-net",
+			Volumes: []core.Mapping{{Source: filepath.Join(filepath.Dir(pathToFile), "data"), Target: "/data"}},
+			Env:     []core.Mapping{{Source: "A", Target: "2"}},
+		},
+	}, config.Profiles)
+}
+
+func TestRunContainerProfile(t *testing.T) {
+	engine, cli := newTestEngine()
+	engine.AddImage("test-image:1")
+	cfg := &Config{
+		ImageName: "test-image",
+		Env:       []core.Mapping{{Source: "A", Target: "1"}},
+		Profiles: map[string]*Profile{
+			"prod": {Env: []core.Mapping{{Source: "A", Target: "2"}}},
+		},
+	}
+
+	container, err := RunContainer(cli, cfg, &Options{Postfix: "prod", Tag: "1"})
+
+	assert.NoError(t, err)
+	info, _ := cli.ContainerInspect(nil, container.ID())
+	assert.Equal(t, []string{"A=2"}, info.Config.Env)
+}
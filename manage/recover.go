@@ -0,0 +1,129 @@
+package manage
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/DmitryBogomolov/containerator/core"
+)
+
+// Recovery describes what Recover has done.
+type Recovery struct {
+	Resumed core.Container   // Suspended container that is resumed under its name
+	Cleaned []core.Container // Suspended and unfinished containers that are removed (or kept as versions)
+}
+
+// resumeSuspended gives container its name back and starts it if it has not been stopped.
+func resumeSuspended(cli core.Runtime, container core.Container, name string) error {
+	if container.State() == "running" {
+		return core.RenameContainer(cli, container, name)
+	}
+	return core.ResumeContainer(cli, container, name)
+}
+
+// retireSuspended stops container if it has not been stopped and keeps or removes it.
+func retireSuspended(cli core.Runtime, container core.Container, name string, keep int) error {
+	if container.State() == "running" {
+		if err := core.StopContainer(cli, container); err != nil {
+			return err
+		}
+	}
+	return retireContainer(cli, container, name, keep)
+}
+
+/*
+Recover cleans up after deployment that has been interrupted (e.g. process has been terminated).
+
+Interrupted deployment leaves running container suspended under temporary name (see core.SuspendContainer)
+and new container under temporary name or not started. Only containers labeled with configured name
+(see LabelName) are considered; such container under temporary name is suspended, the latest one is selected
+by deploy operation (see LabelOperation). If there is no running container with configured name,
+the latest suspended container is resumed and unfinished container is removed. Other suspended containers
+are removed (or kept as versions, see config `deploy.keep`).
+
+	Recover(cli, cfg, &Options{Postfix: "prod"}) -> &Recovery{Resumed: &container}, err
+*/
+func Recover(cli core.Runtime, cfg *Config, options *Options) (*Recovery, error) {
+	name, err := ContainerName(cfg, options)
+	if err != nil {
+		return nil, err
+	}
+	options, closeNotifier, err := withConfigNotifier(cfg, options)
+	if err != nil {
+		return nil, err
+	}
+	defer closeNotifier()
+	release, err := lockContainer(name, OperationRecover, options)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	record := &HistoryRecord{Operation: OperationRecover, Container: name}
+	recovery, err := recoverContainer(cli, cfg, options, name)
+	var container core.Container
+	if recovery != nil {
+		container = recovery.Resumed
+	}
+	return recovery, recordOperation(cli, options, record, container, err)
+}
+
+func recoverContainer(cli core.Runtime, cfg *Config, options *Options, name string) (*Recovery, error) {
+	cfg, err := prepareConfig(cfg, options)
+	if err != nil {
+		return nil, err
+	}
+	keep := getKeep(cfg.Deploy)
+	recovery := &Recovery{}
+
+	containers, err := core.FindContainersByLabel(cli, LabelName, name)
+	if err != nil {
+		return nil, err
+	}
+	var suspended []core.Container
+	for _, container := range containers {
+		if container.Name() == name+candidateSuffix {
+			if err := core.RemoveContainer(cli, container); err != nil {
+				return nil, err
+			}
+			recovery.Cleaned = append(recovery.Cleaned, container)
+		} else if isSuspended(container, name) {
+			suspended = append(suspended, container)
+		}
+	}
+	if len(suspended) == 0 {
+		return recovery, nil
+	}
+	// Container that is deployed last has been running before deployment is interrupted.
+	sort.SliceStable(suspended, func(i, j int) bool {
+		return suspended[i].Labels()[LabelOperation] > suspended[j].Labels()[LabelOperation]
+	})
+	currentContainer, err := core.IgnoreNotFound(core.FindContainerByName(cli, name))
+	if err != nil {
+		return nil, err
+	}
+	if currentContainer == nil || currentContainer.State() != "running" {
+		if currentContainer != nil {
+			if currentContainer.Labels()[LabelName] != name {
+				return nil, fmt.Errorf("container '%s' is not deployed with config, suspended container is not resumed", name)
+			}
+			if err := core.RemoveContainer(cli, currentContainer); err != nil {
+				return nil, err
+			}
+			recovery.Cleaned = append(recovery.Cleaned, currentContainer)
+		}
+		if err := resumeSuspended(cli, suspended[0], name); err != nil {
+			return nil, err
+		}
+		if recovery.Resumed, err = core.FindContainerByID(cli, suspended[0].ID()); err != nil {
+			return nil, err
+		}
+		suspended = suspended[1:]
+	}
+	for _, container := range suspended {
+		if err := retireSuspended(cli, container, name, keep); err != nil {
+			return nil, err
+		}
+		recovery.Cleaned = append(recovery.Cleaned, container)
+	}
+	return recovery, nil
+}
@@ -0,0 +1,97 @@
+package manage
+
+import (
+	"testing"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+	cfg := &Config{ImageName: "test-image", Ports: []core.Mapping{{Source: "5001", Target: "80"}}}
+	labels := map[string]string{LabelName: "test-image"}
+
+	t.Run("Resume", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+		core.SuspendContainer(cli, prev)
+
+		recovery, err := Recover(cli, cfg, &Options{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, prev.ID(), recovery.Resumed.ID())
+		assert.Equal(t, "test-image", recovery.Resumed.Name())
+		assert.Equal(t, "running", recovery.Resumed.State())
+		assert.Empty(t, recovery.Cleaned)
+	})
+
+	t.Run("Unfinished container", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+		core.SuspendContainer(cli, prev)
+		next, _ := core.RunContainer(
+			cli, &core.RunContainerOptions{Image: "test-image:2", Name: "test-image", Labels: labels},
+		)
+		core.StopContainer(cli, next)
+
+		recovery, err := Recover(cli, cfg, &Options{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, prev.ID(), recovery.Resumed.ID())
+		assert.Equal(t, []string{next.ID()}, core.TransformSlice(recovery.Cleaned, core.Container.ID))
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Equal(t, []string{prev.ID()}, ids)
+	})
+
+	t.Run("Clean up", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		prev, _ := RunContainer(cli, &Config{ImageName: "test-image"}, &Options{Tag: "1"})
+		core.SuspendContainer(cli, prev)
+		candidate, _ := core.RunContainer(
+			cli, &core.RunContainerOptions{Image: "test-image:2", Name: "test-image" + candidateSuffix, Labels: labels},
+		)
+		cont, _ := core.RunContainer(cli, &core.RunContainerOptions{Image: "test-image:2", Name: "test-image"})
+
+		recovery, err := Recover(cli, cfg, &Options{})
+
+		assert.NoError(t, err)
+		assert.Nil(t, recovery.Resumed)
+		assert.Equal(t, []string{candidate.ID(), prev.ID()}, core.TransformSlice(recovery.Cleaned, core.Container.ID))
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Equal(t, []string{cont.ID()}, ids)
+	})
+
+	t.Run("Not deployed containers", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+		core.SuspendContainer(cli, prev)
+		other, _ := core.RunContainer(cli, &core.RunContainerOptions{Image: "test-image:2", Name: "other"})
+		core.StopContainer(cli, other)
+		next, _ := core.RunContainer(cli, &core.RunContainerOptions{Image: "test-image:2", Name: "test-image"})
+		core.StopContainer(cli, next)
+
+		_, err := Recover(cli, cfg, &Options{})
+
+		assert.EqualError(t, err, "container 'test-image' is not deployed with config, suspended container is not resumed")
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.ElementsMatch(t, []string{prev.ID(), other.ID(), next.ID()}, ids, "containers are not removed")
+	})
+
+	t.Run("Nothing to recover", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		recovery, err := Recover(cli, cfg, &Options{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &Recovery{}, recovery)
+	})
+}
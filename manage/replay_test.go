@@ -0,0 +1,38 @@
+package manage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/DmitryBogomolov/containerator/core/replay"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+// Scenario is skipped until recorded with `make record-replay` (see core replay tests).
+func TestReplayRunContainer(t *testing.T) {
+	defer func(original func() time.Time) { now = original }(now)
+	now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	cfg := &Config{
+		ImageName:     "nginx",
+		ContainerName: "containerator-test",
+		Ports:         []core.Mapping{{Source: "8080", Target: "80"}},
+	}
+
+	replay.Run(t, filepath.Join("testdata", "replay"), "run_container.json",
+		func(t *testing.T, cli *client.Client, transport *replay.Transport) {
+			transport.IgnoreQuery("/rename", "name")
+
+			cont, err := RunContainer(cli, cfg, &Options{Tag: "alpine"})
+			assert.NoError(t, err)
+			assert.Equal(t, "containerator-test", cont.Name())
+			assert.Equal(t, "running", cont.State())
+
+			removed, err := RunContainer(cli, cfg, &Options{Remove: true})
+			assert.NoError(t, err)
+			assert.Equal(t, cont.ID(), removed.ID())
+		},
+	)
+}
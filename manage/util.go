@@ -1,9 +1,11 @@
 package manage
 
 import (
+	"errors"
+	"time"
+
 	"github.com/DmitryBogomolov/containerator/core"
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
 )
 
 func findIndex[T comparable](item T, list []T) int {
@@ -26,7 +28,7 @@ func getContainerName(conf *Config, postfix string) string {
 	return name
 }
 
-func removeContainer(cli client.ContainerAPIClient, container core.Container, name string) (core.Container, error) {
+func removeContainer(cli core.Runtime, container core.Container, name string) (core.Container, error) {
 	if container == nil {
 		return nil, &NoContainerError{name}
 	}
@@ -36,32 +38,65 @@ func removeContainer(cli client.ContainerAPIClient, container core.Container, na
 	return container, nil
 }
 
-func findImage(cli client.ImageAPIClient, name string, tag string) (core.Image, error) {
+func findImage(cli core.Runtime, name string, tag string) (core.Image, error) {
 	imageName := name
 	if tag != "" {
 		imageName += ":" + tag
 	}
-	image, err := core.FindImageByName(cli.(client.ImageAPIClient), imageName)
+	image, err := core.FindImageByName(cli, imageName)
+	if errors.Is(err, core.ErrNotFound) {
+		return nil, &NoImageError{imageName}
+	}
 	if err != nil {
 		return nil, err
 	}
-	if image == nil {
-		return nil, &NoImageError{imageName}
-	}
 	return image, nil
 }
 
 func buildContainerOptions(
 	cfg *Config, imageName string, containerName string, options *Options,
 ) (*core.RunContainerOptions, error) {
+	env, err := buildEnv(cfg, options)
+	if err != nil {
+		return nil, err
+	}
+	ports, err := shiftPorts(cfg.Ports, getPortOffset(cfg, options))
+	if err != nil {
+		return nil, err
+	}
+	stopTimeout, err := getStopTimeout(cfg)
+	if err != nil {
+		return nil, err
+	}
+	restartPolicy := container.RestartPolicyMode(cfg.Restart)
+	if restartPolicy == "" {
+		restartPolicy = container.RestartPolicyAlways
+	}
 	result := core.RunContainerOptions{
-		Image:         imageName,
-		Name:          containerName,
-		RestartPolicy: container.RestartPolicyAlways,
-		Network:       cfg.Network,
-		Volumes:       cfg.Volumes,
-		Ports:         cfg.Ports,
-		Env:           cfg.Env,
+		Image:             imageName,
+		Name:              containerName,
+		RestartPolicy:     restartPolicy,
+		RestartMaxRetries: cfg.RestartMaxRetries,
+		StopSignal:        cfg.StopSignal,
+		StopTimeout:       stopTimeout,
+		Network:           cfg.Network,
+		Volumes:           cfg.Volumes,
+		Ports:             ports,
+		Env:               env,
+		KeepEmptyEnv:      true,
 	}
 	return &result, nil
 }
+
+// getStopTimeout returns stop timeout in seconds (rounded up); nil if it is not set.
+func getStopTimeout(cfg *Config) (*int, error) {
+	if cfg.StopTimeout == "" {
+		return nil, nil
+	}
+	timeout, err := time.ParseDuration(cfg.StopTimeout)
+	if err != nil {
+		return nil, err
+	}
+	seconds := int((timeout + time.Second - 1) / time.Second)
+	return &seconds, nil
+}
@@ -70,7 +70,48 @@ func TestBuildContainerOptions(t *testing.T) {
 				{Source: "A", Target: "1"},
 				{Source: "B", Target: "2"},
 			},
+			KeepEmptyEnv: true,
 		},
 		actual,
 	)
 }
+
+func TestBuildContainerOptions_Stop(t *testing.T) {
+	t.Run("Restart and stop", func(t *testing.T) {
+		actual, err := buildContainerOptions(
+			&Config{
+				ImageName:         "test-image",
+				Restart:           "on-failure",
+				RestartMaxRetries: 5,
+				StopSignal:        "SIGINT",
+				StopTimeout:       "1m30s",
+			},
+			"test-image",
+			"test-container",
+			&Options{},
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, container.RestartPolicyOnFailure, actual.RestartPolicy)
+		assert.Equal(t, 5, actual.RestartMaxRetries)
+		assert.Equal(t, "SIGINT", actual.StopSignal)
+		assert.Equal(t, 90, *actual.StopTimeout)
+	})
+
+	t.Run("Round timeout up", func(t *testing.T) {
+		actual, err := buildContainerOptions(
+			&Config{ImageName: "test-image", StopTimeout: "500ms"}, "test-image", "test-container", &Options{},
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, *actual.StopTimeout)
+	})
+
+	t.Run("Bad timeout", func(t *testing.T) {
+		_, err := buildContainerOptions(
+			&Config{ImageName: "test-image", StopTimeout: "soon"}, "test-image", "test-container", &Options{},
+		)
+
+		assert.Error(t, err)
+	})
+}
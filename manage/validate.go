@@ -0,0 +1,506 @@
+package manage
+
+import (
+	// Required for go:embed.
+	_ "embed"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigSchema contains JSON Schema of config file.
+//
+//go:embed config.schema.json
+var ConfigSchema []byte
+
+// ConfigProblem describes single config problem.
+type ConfigProblem struct {
+	Path    string // Path to value, e.g. "profiles.dev.ports[1]"
+	Line    int    // Line in config file
+	Message string // Problem description
+}
+
+func (problem ConfigProblem) String() string {
+	return fmt.Sprintf("%d: %s: %s", problem.Line, problem.Path, problem.Message)
+}
+
+// ConfigValidationError is returned when config has problems.
+type ConfigValidationError struct {
+	file     string
+	problems []ConfigProblem
+}
+
+func (err ConfigValidationError) Error() string {
+	lines := make([]string, len(err.problems))
+	for i, problem := range err.problems {
+		lines[i] = err.file + ":" + problem.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// File returns path to config file.
+func (err ConfigValidationError) File() string {
+	return err.file
+}
+
+// Problems returns list of config problems.
+func (err ConfigValidationError) Problems() []ConfigProblem {
+	return err.problems
+}
+
+type _ConfigValidator struct {
+	interpolator *_Interpolator
+	dir          string
+	lines        map[string]int
+	reported     map[string]bool
+	problems     []ConfigProblem
+}
+
+var mappingType = reflect.TypeOf(core.Mapping{})
+
+func (validator *_ConfigValidator) report(path string, line int, format string, args ...interface{}) {
+	validator.reported[path] = true
+	validator.problems = append(validator.problems, ConfigProblem{path, line, fmt.Sprintf(format, args...)})
+}
+
+// reportAt reports problem at line of value; line of the closest parent is taken if value is not set.
+//
+// Value that already has a problem is not reported again.
+func (validator *_ConfigValidator) reportAt(path string, format string, args ...interface{}) {
+	if validator.reported[path] {
+		return
+	}
+	line, ok := validator.lines[path]
+	for parent := path; !ok && parent != ""; {
+		idx := strings.LastIndexAny(parent, ".[")
+		if idx < 0 {
+			idx = 0
+		}
+		parent = parent[:idx]
+		line, ok = validator.lines[parent]
+	}
+	validator.report(path, line, format, args...)
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// getYAMLFields returns struct fields by yaml keys.
+func getYAMLFields(typ reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
+// reject reports problem and replaces node with empty node of expected kind so that the rest of config can be decoded.
+func (validator *_ConfigValidator) reject(node *yaml.Node, kind yaml.Kind, path string, message string) {
+	validator.report(path, node.Line, message)
+	tag := ""
+	if kind == yaml.ScalarNode {
+		tag = "!!null"
+	}
+	*node = yaml.Node{Kind: kind, Tag: tag, Line: node.Line, Column: node.Column}
+}
+
+func (validator *_ConfigValidator) checkScalar(node *yaml.Node, typ reflect.Type, path string) {
+	if node.Kind != yaml.ScalarNode {
+		validator.reject(node, yaml.ScalarNode, path, "expected value")
+		return
+	}
+	value, err := validator.interpolator.interpolate(node.Value)
+	if err != nil {
+		validator.report(path, node.Line, "%v", err)
+		return
+	}
+	setScalarValue(node, value)
+	if typ.Kind() == reflect.Int && !strings.Contains(value, "$") {
+		if _, err := strconv.Atoi(value); err != nil {
+			validator.reject(node, yaml.ScalarNode, path, "expected integer")
+		}
+	}
+}
+
+// checkNode checks that node matches type of config field.
+func (validator *_ConfigValidator) checkNode(node *yaml.Node, typ reflect.Type, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	validator.lines[path] = node.Line
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if isNull(node) {
+		return
+	}
+	switch {
+	case typ == mappingType:
+		if node.Kind == yaml.ScalarNode {
+			validator.checkScalar(node, typ.Field(0).Type, path)
+			return
+		}
+		if node.Kind != yaml.MappingNode || len(node.Content) != 2 {
+			validator.reject(node, yaml.MappingNode, path, "expected single key-value pair")
+			return
+		}
+		validator.checkScalar(node.Content[0], typ.Field(0).Type, path)
+		validator.checkScalar(node.Content[1], typ.Field(1).Type, path)
+	case typ.Kind() == reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			validator.reject(node, yaml.MappingNode, path, "expected mapping")
+			return
+		}
+		fields := getYAMLFields(typ)
+		for i := 0; i < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			childPath := joinPath(path, key.Value)
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				validator.report(childPath, key.Line, "unknown field")
+				continue
+			}
+			validator.checkNode(value, fieldType, childPath)
+		}
+	case typ.Kind() == reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			validator.reject(node, yaml.SequenceNode, path, "expected list")
+			return
+		}
+		for i, child := range node.Content {
+			validator.checkNode(child, typ.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case typ.Kind() == reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			validator.reject(node, yaml.MappingNode, path, "expected mapping")
+			return
+		}
+		for i := 0; i < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			childPath := joinPath(path, key.Value)
+			validator.checkScalar(key, typ.Key(), childPath)
+			validator.checkNode(value, typ.Elem(), childPath)
+		}
+	default:
+		validator.checkScalar(node, typ, path)
+	}
+}
+
+func isDeferred(value string) bool {
+	return strings.Contains(value, "$")
+}
+
+func (validator *_ConfigValidator) checkPorts(path string, ports []core.Mapping) {
+	hostPorts := map[string]string{}
+	for i, mapping := range ports {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if isDeferred(mapping.Source) || isDeferred(mapping.Target) {
+			continue
+		}
+		proto, containerPort := nat.SplitProtoPort(mapping.Target)
+		if _, _, err := nat.ParsePortRange(containerPort); err != nil || containerPort == "" {
+			validator.reportAt(itemPath, "bad container port '%s'", mapping.Target)
+			continue
+		}
+		ip, hostPort := core.SplitHostIP(mapping.Source)
+		if hostPort == "" {
+			continue
+		}
+		if ip != "" && net.ParseIP(ip) == nil {
+			validator.reportAt(itemPath, "bad host ip '%s'", ip)
+			continue
+		}
+		start, end, err := nat.ParsePortRange(hostPort)
+		if err != nil || start == 0 {
+			validator.reportAt(itemPath, "bad host port '%s'", mapping.Source)
+			continue
+		}
+		for port := start; port <= end; port++ {
+			key := fmt.Sprintf("%d/%s", port, proto)
+			if other, ok := hostPorts[key]; ok {
+				validator.reportAt(itemPath, "host port %d is already used by %s", port, other)
+				break
+			}
+			hostPorts[key] = itemPath
+		}
+	}
+}
+
+func (validator *_ConfigValidator) checkVolumes(path string, volumes []core.Mapping) {
+	targets := map[string]string{}
+	for i, mapping := range volumes {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if mapping.Source == "" {
+			validator.reportAt(itemPath, "empty volume source")
+		}
+		if isDeferred(mapping.Target) {
+			continue
+		}
+		target, _ := core.SplitVolumeTarget(mapping.Target)
+		if !strings.HasPrefix(target, "/") {
+			validator.reportAt(itemPath, "container path '%s' is not absolute", target)
+			continue
+		}
+		if other, ok := targets[target]; ok {
+			validator.reportAt(itemPath, "container path '%s' is already used by %s", target, other)
+			continue
+		}
+		targets[target] = itemPath
+	}
+}
+
+func (validator *_ConfigValidator) checkEnv(path string, env []core.Mapping) {
+	names := map[string]string{}
+	for i, mapping := range env {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if mapping.Source == "" || strings.ContainsAny(mapping.Source, "= \t") {
+			validator.reportAt(itemPath, "invalid variable name '%s'", mapping.Source)
+			continue
+		}
+		if other, ok := names[mapping.Source]; ok {
+			validator.reportAt(itemPath, "variable '%s' is already defined by %s", mapping.Source, other)
+			continue
+		}
+		names[mapping.Source] = itemPath
+	}
+}
+
+func (validator *_ConfigValidator) checkFile(path string, pathToFile string, kind string) {
+	if pathToFile == "" || isDeferred(pathToFile) {
+		return
+	}
+	if _, err := os.Stat(resolvePath(pathToFile, validator.dir)); err != nil {
+		validator.reportAt(path, "%s file '%s' is not found", kind, pathToFile)
+	}
+}
+
+func (validator *_ConfigValidator) checkFiles(path string, files []string, kind string) {
+	for i, pathToFile := range files {
+		validator.checkFile(fmt.Sprintf("%s[%d]", path, i), pathToFile, kind)
+	}
+}
+
+func (validator *_ConfigValidator) checkDeploy(path string, deploy *DeployConfig) {
+	if deploy == nil {
+		return
+	}
+	if deploy.Strategy != "" && deploy.Strategy != StrategyRecreate && deploy.Strategy != StrategyBlueGreen &&
+		deploy.Strategy != StrategyProxy {
+		validator.reportAt(joinPath(path, "strategy"), "unknown deploy strategy '%s'", deploy.Strategy)
+	}
+	if deploy.Keep < 0 {
+		validator.reportAt(joinPath(path, "keep"), "negative number of kept containers")
+	}
+	if deploy.Health == nil {
+		return
+	}
+	path = joinPath(path, "health")
+	for _, field := range []struct{ name, value string }{
+		{"timeout", deploy.Health.Timeout},
+		{"interval", deploy.Health.Interval},
+	} {
+		if field.value == "" || isDeferred(field.value) {
+			continue
+		}
+		if _, err := time.ParseDuration(field.value); err != nil {
+			validator.reportAt(joinPath(path, field.name), "bad duration '%s'", field.value)
+		}
+	}
+}
+
+func (validator *_ConfigValidator) checkHooks(path string, hooks *HooksConfig) {
+	if hooks == nil {
+		return
+	}
+	for _, hook := range []struct {
+		name  string
+		steps []HookStep
+	}{
+		{HookPreDeploy, hooks.PreDeploy},
+		{HookPostDeploy, hooks.PostDeploy},
+		{HookPreRemove, hooks.PreRemove},
+		{HookOnFailure, hooks.OnFailure},
+	} {
+		for i, step := range hook.steps {
+			stepPath := fmt.Sprintf("%s[%d]", joinPath(path, hook.name), i)
+			if (step.Command == "") == (len(step.Exec) == 0) {
+				validator.reportAt(stepPath, "expected either command or exec")
+			}
+			if step.Timeout != "" && !isDeferred(step.Timeout) {
+				if _, err := time.ParseDuration(step.Timeout); err != nil {
+					validator.reportAt(joinPath(stepPath, "timeout"), "bad duration '%s'", step.Timeout)
+				}
+			}
+		}
+	}
+}
+
+func (validator *_ConfigValidator) checkWebhooks(path string, webhooks []Webhook) {
+	for i, webhook := range webhooks {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if webhook.URL == "" {
+			validator.reportAt(joinPath(itemPath, "url"), "url is required")
+		}
+		if webhook.Template != "" {
+			if _, err := template.New("").Funcs(webhookFuncs).Parse(webhook.Template); err != nil {
+				validator.reportAt(joinPath(itemPath, "template"), "bad template: %v", err)
+			}
+		}
+		for j, eventType := range webhook.Events {
+			if !isEventType(eventType) {
+				validator.reportAt(fmt.Sprintf("%s[%d]", joinPath(itemPath, "events"), j), "unknown event '%s'", eventType)
+			}
+		}
+	}
+}
+
+// signalNames contains Linux signal names without "SIG" prefix.
+var signalNames = map[string]bool{
+	"HUP": true, "INT": true, "QUIT": true, "ILL": true, "TRAP": true, "ABRT": true, "BUS": true, "FPE": true,
+	"KILL": true, "USR1": true, "SEGV": true, "USR2": true, "PIPE": true, "ALRM": true, "TERM": true,
+	"STKFLT": true, "CHLD": true, "CONT": true, "STOP": true, "TSTP": true, "TTIN": true, "TTOU": true,
+	"URG": true, "XCPU": true, "XFSZ": true, "VTALRM": true, "PROF": true, "WINCH": true, "IO": true,
+	"PWR": true, "SYS": true,
+}
+
+// isSignal tells if value is signal name ("SIGINT", "INT") or number ("2").
+func isSignal(value string) bool {
+	if number, err := strconv.Atoi(value); err == nil {
+		return number > 0 && number <= 64
+	}
+	return signalNames[strings.TrimPrefix(strings.ToUpper(value), "SIG")]
+}
+
+func (validator *_ConfigValidator) checkStop(cfg *Config) {
+	switch container.RestartPolicyMode(cfg.Restart) {
+	case "", container.RestartPolicyDisabled, container.RestartPolicyAlways,
+		container.RestartPolicyUnlessStopped, container.RestartPolicyOnFailure:
+	default:
+		validator.reportAt("restart", "unknown restart policy '%s'", cfg.Restart)
+	}
+	if cfg.RestartMaxRetries < 0 {
+		validator.reportAt("restart_max_retries", "negative number of retries")
+	} else if cfg.RestartMaxRetries > 0 && container.RestartPolicyMode(cfg.Restart) != container.RestartPolicyOnFailure {
+		validator.reportAt("restart_max_retries", "retries require 'on-failure' restart policy")
+	}
+	if cfg.StopSignal != "" && !isDeferred(cfg.StopSignal) && !isSignal(cfg.StopSignal) {
+		validator.reportAt("stop_signal", "bad signal '%s'", cfg.StopSignal)
+	}
+	if cfg.StopTimeout != "" && !isDeferred(cfg.StopTimeout) {
+		if timeout, err := time.ParseDuration(cfg.StopTimeout); err != nil || timeout < 0 {
+			validator.reportAt("stop_timeout", "bad duration '%s'", cfg.StopTimeout)
+		}
+	}
+}
+
+func (validator *_ConfigValidator) checkConfig(cfg *Config) {
+	// Image name can be defined by parent config or fragments.
+	if cfg.ImageName == "" && cfg.Extends == "" && len(cfg.Include) == 0 {
+		validator.reportAt("image_name", "image name is required")
+	}
+	validator.checkFile("extends", cfg.Extends, "config")
+	validator.checkFiles("include", cfg.Include, "config")
+	validator.checkPorts("ports", cfg.Ports)
+	validator.checkVolumes("volumes", cfg.Volumes)
+	validator.checkEnv("env", cfg.Env)
+	validator.checkFiles("env_file", cfg.EnvFile, "env")
+	validator.checkStop(cfg)
+	validator.checkDeploy("deploy", cfg.Deploy)
+	isBlueGreen := cfg.Deploy != nil && cfg.Deploy.Strategy == StrategyBlueGreen
+	if isBlueGreen && hasHostPorts(cfg.Ports) {
+		validator.reportAt("deploy.strategy", "%v", errBlueGreenHostPorts)
+	}
+	validator.checkHooks("hooks", cfg.Hooks)
+	validator.checkWebhooks("webhooks", cfg.Webhooks)
+	for postfix, profile := range cfg.Profiles {
+		if profile == nil {
+			continue
+		}
+		path := joinPath("profiles", postfix)
+		if profile.Merge != "" && profile.Merge != MergeAppend && profile.Merge != MergeReplace {
+			validator.reportAt(joinPath(path, "merge"), "unknown merge strategy '%s'", profile.Merge)
+		}
+		validator.checkPorts(joinPath(path, "ports"), profile.Ports)
+		if isBlueGreen && hasHostPorts(profile.Ports) {
+			validator.reportAt(joinPath(path, "ports"), "%v", errBlueGreenHostPorts)
+		}
+		validator.checkVolumes(joinPath(path, "volumes"), profile.Volumes)
+		validator.checkEnv(joinPath(path, "env"), profile.Env)
+		validator.checkFiles(joinPath(path, "env_file"), profile.EnvFile, "env")
+	}
+}
+
+/*
+ValidateConfig checks config file.
+
+Unknown fields, values of wrong types, unresolved required variables and semantic problems
+(missing image name, duplicate host ports, container paths or variables, bad ports, missing env files,
+host ports with blue-green strategy)
+are reported with *ConfigValidationError. Each problem contains path to value and line number.
+
+	ValidateConfig("/path/to/config.yaml") -> err
+*/
+func ValidateConfig(pathToFile string) error {
+	bytes, err := ioutil.ReadFile(pathToFile)
+	if err != nil {
+		return err
+	}
+	root, err := parseConfigNode(bytes, GetConfigFormat(pathToFile))
+	if err != nil {
+		return err
+	}
+	dir, _ := filepath.Abs(filepath.Dir(pathToFile))
+	validator := _ConfigValidator{
+		interpolator: newConfigInterpolator(dir),
+		dir:          dir,
+		lines:        map[string]int{},
+		reported:     map[string]bool{},
+	}
+	var cfg Config
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		validator.checkNode(root.Content[0], reflect.TypeOf(cfg), "")
+		err = root.Decode(&cfg)
+	}
+	if err != nil && len(validator.problems) == 0 {
+		return err
+	}
+	if err == nil {
+		validator.checkConfig(&cfg)
+	}
+	if len(validator.problems) > 0 {
+		sort.SliceStable(validator.problems, func(i, j int) bool {
+			return validator.problems[i].Line < validator.problems[j].Line
+		})
+		return &ConfigValidationError{pathToFile, validator.problems}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package manage
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfig(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		t.Setenv("TEST_PORT", "5001")
+		pathToFile := writeTestFile(t, "test.yaml",
+			"image_name: test-image",
+			"ports:",
+			"- ${TEST_PORT}: 80",
+			"- 5001: 53/udp",
+			"- 6000-6001: 90-91",
+			"volumes:",
+			"- ./data-${POSTFIX}: /data",
+			"env:",
+			"- A: 1",
+			"port_offsets:",
+			"  test: 10",
+			"profiles:",
+			"  prod:",
+			"    merge: replace",
+			"    env:",
+			"    - A: 2",
+			"deploy:",
+			"  strategy: proxy",
+			"  health:",
+			"    port: 80",
+			"    timeout: 30s",
+		)
+
+		assert.NoError(t, ValidateConfig(pathToFile))
+	})
+
+	t.Run("Problems", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "test.yaml",
+			"container_name: test",
+			"ports:",
+			"- 5001: 80",
+			"- 5001: 81",
+			"- 5002: http",
+			"- 70000: 80",
+			"- 300.0.0.1:5003: 80",
+			"volumes:",
+			"- /a: data",
+			"- /a: /data",
+			"- /b:/data:ro",
+			"env:",
+			"- A: 1",
+			"  B: 2",
+			"- A B: 1",
+			"- C: ${TEST_MISSING:?is required}",
+			"env_file:",
+			"- ./missing.list",
+			"port_offsets:",
+			"  test: ten",
+			"restart: sometimes",
+			"profiles:",
+			"  dev:",
+			"    merge: prepend",
+			"    network: [a]",
+			"    env:",
+			"    - A: 1",
+			"    - A: 2",
+			"deploy:",
+			"  strategy: canary",
+			"  health:",
+			"    timeout: soon",
+			"    interval: 1s",
+			"  keep: -1",
+			"hooks:",
+			"  pre_deploy:",
+			"  - command: ./migrate.sh",
+			"    exec: [./migrate]",
+			"  post_deploy:",
+			"  - exec: [curl, localhost]",
+			"    timeout: later",
+			"webhooks:",
+			"- template: '{{ .Type'",
+			"  events: [deployed]",
+		)
+
+		err := ValidateConfig(pathToFile)
+
+		var target *ConfigValidationError
+		assert.True(t, errors.As(err, &target))
+		assert.Equal(t, pathToFile, target.File())
+		assert.Equal(t, []ConfigProblem{
+			{"image_name", 1, "image name is required"},
+			{"ports[1]", 4, "host port 5001 is already used by ports[0]"},
+			{"ports[2]", 5, "bad container port 'http'"},
+			{"ports[3]", 6, "bad host port '70000'"},
+			{"ports[4]", 7, "bad host ip '300.0.0.1'"},
+			{"volumes[0]", 9, "container path 'data' is not absolute"},
+			{"volumes[2]", 11, "container path '/data' is already used by volumes[1]"},
+			{"env[0]", 13, "expected single key-value pair"},
+			{"env[1]", 15, "invalid variable name 'A B'"},
+			{"env[2]", 16, "variable 'TEST_MISSING': is required"},
+			{"env_file[0]", 18, "env file './missing.list' is not found"},
+			{"port_offsets.test", 20, "expected integer"},
+			{"restart", 21, "unknown restart policy 'sometimes'"},
+			{"profiles.dev.merge", 24, "unknown merge strategy 'prepend'"},
+			{"profiles.dev.network", 25, "expected value"},
+			{"profiles.dev.env[1]", 28, "variable 'A' is already defined by profiles.dev.env[0]"},
+			{"deploy.strategy", 30, "unknown deploy strategy 'canary'"},
+			{"deploy.health.timeout", 32, "bad duration 'soon'"},
+			{"deploy.keep", 34, "negative number of kept containers"},
+			{"hooks.pre_deploy[0]", 37, "expected either command or exec"},
+			{"hooks.post_deploy[0].timeout", 41, "bad duration 'later'"},
+			{"webhooks[0].url", 43, "url is required"},
+			{"webhooks[0].template", 43, "bad template: template: :1: unclosed action"},
+			{"webhooks[0].events[0]", 44, "unknown event 'deployed'"},
+		}, target.Problems())
+		assert.Contains(t, err.Error(), pathToFile+":4: ports[1]: host port 5001 is already used by ports[0]")
+	})
+
+	t.Run("Blue-green with host ports", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "test.yaml",
+			"image_name: test-image",
+			"ports:",
+			"- 5001: 80",
+			"- '': 81",
+			"profiles:",
+			"  dev:",
+			"    ports:",
+			"    - 127.0.0.1:5002: 82",
+			"deploy:",
+			"  strategy: blue-green",
+		)
+
+		err := ValidateConfig(pathToFile)
+
+		var target *ConfigValidationError
+		assert.True(t, errors.As(err, &target))
+		message := "blue-green strategy cannot replace container with host ports; use proxy strategy or dynamic host ports"
+		assert.Equal(t, []ConfigProblem{
+			{"profiles.dev.ports", 8, message},
+			{"deploy.strategy", 10, message},
+		}, target.Problems())
+	})
+
+	t.Run("Restart and stop", func(t *testing.T) {
+		pathToFile := writeTestFile(t, "test.yaml",
+			"image_name: test",
+			"restart: always",
+			"restart_max_retries: 3",
+			"stop_signal: SIGNOPE",
+			"stop_timeout: -5s",
+		)
+
+		err := ValidateConfig(pathToFile)
+
+		var target *ConfigValidationError
+		assert.True(t, errors.As(err, &target))
+		assert.Equal(t, []ConfigProblem{
+			{"restart_max_retries", 3, "retries require 'on-failure' restart policy"},
+			{"stop_signal", 4, "bad signal 'SIGNOPE'"},
+			{"stop_timeout", 5, "bad duration '-5s'"},
+		}, target.Problems())
+
+		pathToFile = writeTestFile(t, "test.yaml",
+			"image_name: test",
+			"restart: on-failure",
+			"restart_max_retries: 3",
+			"stop_signal: SIGINT",
+			"stop_timeout: 30s",
+		)
+		assert.NoError(t, ValidateConfig(pathToFile))
+	})
+
+	t.Run("No file", func(t *testing.T) {
+		err := ValidateConfig("missing.yaml")
+
+		assert.Error(t, err)
+	})
+}
+
+func collectSchemaKeys(schema map[string]interface{}, definitions map[string]interface{}) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		schema = definitions[ref[len("#/definitions/"):]].(map[string]interface{})
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	var keys []string
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func collectStructKeys(typ reflect.Type) []string {
+	var keys []string
+	for key := range getYAMLFields(typ) {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestConfigSchema(t *testing.T) {
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal(ConfigSchema, &schema))
+	definitions := schema["definitions"].(map[string]interface{})
+
+	assert.Equal(t, collectStructKeys(reflect.TypeOf(Config{})), collectSchemaKeys(schema, definitions), "config")
+	assert.Equal(t,
+		collectStructKeys(reflect.TypeOf(Profile{})),
+		collectSchemaKeys(definitions["profile"].(map[string]interface{}), definitions),
+		"profile",
+	)
+	assert.Equal(t,
+		collectStructKeys(reflect.TypeOf(DeployConfig{})),
+		collectSchemaKeys(definitions["deploy"].(map[string]interface{}), definitions),
+		"deploy",
+	)
+	assert.Equal(t,
+		collectStructKeys(reflect.TypeOf(HealthGate{})),
+		collectSchemaKeys(definitions["health"].(map[string]interface{}), definitions),
+		"health",
+	)
+	assert.Equal(t,
+		collectStructKeys(reflect.TypeOf(HooksConfig{})),
+		collectSchemaKeys(definitions["hooks"].(map[string]interface{}), definitions),
+		"hooks",
+	)
+	assert.Equal(t,
+		collectStructKeys(reflect.TypeOf(HookStep{})),
+		collectSchemaKeys(definitions["hook_step"].(map[string]interface{}), definitions),
+		"hook_step",
+	)
+	assert.Equal(t,
+		collectStructKeys(reflect.TypeOf(Webhook{})),
+		collectSchemaKeys(definitions["webhook"].(map[string]interface{}), definitions),
+		"webhook",
+	)
+}
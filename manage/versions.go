@@ -0,0 +1,267 @@
+package manage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/docker/docker/api/types"
+)
+
+// Container labels that identify container versions and suspended containers.
+const (
+	LabelName       = "containerator.name"        // Container name the container is deployed with
+	LabelDeployTime = "containerator.deploy-time" // Time (RFC3339) when the container is deployed
+	LabelOperation  = "containerator.operation"   // Id of deploy operation that has created the container
+)
+
+// now returns current time; replaced in tests.
+var now = time.Now
+
+// newOperationID returns id of deploy operation; ids of later operations are greater.
+func newOperationID(deployTime time.Time) string {
+	return fmt.Sprintf("%016x", deployTime.UnixNano())
+}
+
+// Version is a replaced container that is kept stopped for rollback.
+type Version struct {
+	Container  core.Container // Stopped container
+	DeployTime time.Time      // When container was deployed
+}
+
+// getVersionName returns name that replaced container is kept under.
+func getVersionName(name string, container core.Container) string {
+	return name + "-" + container.ShortID()
+}
+
+/*
+isSuspended checks if container that is deployed with name is suspended (see core.SuspendContainer).
+
+Deployed container has one of the names that are given to it: the name itself, candidate name or version name;
+suspended container has temporary name.
+*/
+func isSuspended(container core.Container, name string) bool {
+	switch container.Name() {
+	case name, name + candidateSuffix, getVersionName(name, container):
+		return false
+	}
+	return true
+}
+
+func getDeployTime(container core.Container) time.Time {
+	value, _ := time.Parse(time.RFC3339Nano, container.Labels()[LabelDeployTime])
+	return value
+}
+
+func getKeep(deploy *DeployConfig) int {
+	if deploy == nil {
+		return 0
+	}
+	return deploy.Keep
+}
+
+// prepareConfig applies profile and resolves config for options.
+func prepareConfig(cfg *Config, options *Options) (*Config, error) {
+	cfg, err := applyProfile(cfg, options.Postfix)
+	if err != nil {
+		return nil, err
+	}
+	return resolveConfig(cfg, options.Postfix, options.Tag)
+}
+
+// listVersions returns kept containers, newest first.
+func listVersions(cli core.Runtime, name string) ([]Version, error) {
+	containers, err := core.FindContainersByLabel(cli, LabelName, name)
+	if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	for _, container := range containers {
+		if container.Name() != getVersionName(name, container) || container.State() == "running" {
+			continue
+		}
+		versions = append(versions, Version{container, getDeployTime(container)})
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].DeployTime.After(versions[j].DeployTime)
+	})
+	return versions, nil
+}
+
+// pruneVersions removes kept containers except the newest ones.
+func pruneVersions(cli core.Runtime, name string, keep int) error {
+	versions, err := listVersions(cli, name)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(versions); i++ {
+		if err := core.RemoveContainer(cli, versions[i].Container); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retireContainer keeps replaced (already stopped) container for rollback or removes it if nothing is kept.
+func retireContainer(cli core.Runtime, container core.Container, name string, keep int) error {
+	if keep <= 0 {
+		return core.RemoveContainer(cli, container)
+	}
+	if err := core.RenameContainer(cli, container, getVersionName(name, container)); err != nil {
+		return err
+	}
+	return pruneVersions(cli, name, keep)
+}
+
+// Versions returns replaced containers that are kept for rollback, newest first.
+//
+//	Versions(cli, cfg, &Options{Postfix: "prod"}) -> []Version{{container, deployTime}}, err
+func Versions(cli core.Runtime, cfg *Config, options *Options) ([]Version, error) {
+	cfg, err := prepareConfig(cfg, options)
+	if err != nil {
+		return nil, err
+	}
+	return listVersions(cli, getContainerName(cfg, options.Postfix))
+}
+
+// selectPrevious returns the newest version deployed before current container.
+func selectPrevious(versions []Version, currentContainer core.Container) *Version {
+	var deployTime time.Time
+	if currentContainer != nil {
+		deployTime = getDeployTime(currentContainer)
+	}
+	for i, version := range versions {
+		if deployTime.IsZero() || version.DeployTime.Before(deployTime) {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+func rollbackProxy(
+	cli core.Runtime, cfg *Config, options *Options, previous core.Container, gate *HealthGate,
+) error {
+	if options.Router == nil {
+		return fmt.Errorf("strategy '%s' requires router", StrategyProxy)
+	}
+	ports, err := shiftPorts(cfg.Ports, getPortOffset(cfg, options))
+	if err != nil {
+		return err
+	}
+	if err := core.StartContainer(cli, previous); err != nil {
+		return err
+	}
+	err = waitHealthy(cli, previous, gate)
+	if err == nil {
+		var info *types.ContainerJSON
+		info, err = core.InspectContainer(cli, previous)
+		if err == nil {
+			host := ""
+			if gate != nil {
+				host = gate.Host
+			}
+			var targets map[int]string
+			targets, err = getProxyTargets(info, ports, host)
+			if err == nil {
+				err = options.Router.Switch(targets)
+			}
+		}
+	}
+	if err != nil {
+		core.StopContainer(cli, previous)
+	}
+	return err
+}
+
+/*
+Rollback replaces running container with the previous version.
+
+Previous version is the newest kept container deployed before running container (see config `deploy.keep`).
+Running container is kept as a version itself (or removed if nothing is kept).
+Previous version is checked with health gate like new container of "blue-green" and "proxy" strategies;
+if it fails, running container is resumed.
+Returns NoPreviousVersionError if there is no previous version.
+
+	Rollback(cli, cfg, &Options{Postfix: "prod"}) -> &container, err
+*/
+func Rollback(cli core.Runtime, cfg *Config, options *Options) (core.Container, error) {
+	name, err := ContainerName(cfg, options)
+	if err != nil {
+		return nil, err
+	}
+	options, closeNotifier, err := withConfigNotifier(cfg, options)
+	if err != nil {
+		return nil, err
+	}
+	defer closeNotifier()
+	release, err := lockContainer(name, OperationRollback, options)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	record := &HistoryRecord{Operation: OperationRollback, Container: name}
+	container, err := rollback(cli, cfg, options)
+	return container, recordOperation(cli, options, record, container, err)
+}
+
+func rollback(cli core.Runtime, cfg *Config, options *Options) (core.Container, error) {
+	cfg, err := prepareConfig(cfg, options)
+	if err != nil {
+		return nil, err
+	}
+	name := getContainerName(cfg, options.Postfix)
+	currentContainer, err := core.IgnoreNotFound(core.FindContainerByName(cli, name))
+	if err != nil {
+		return nil, err
+	}
+	versions, err := listVersions(cli, name)
+	if err != nil {
+		return nil, err
+	}
+	version := selectPrevious(versions, currentContainer)
+	if version == nil {
+		return nil, &NoPreviousVersionError{name}
+	}
+	previous := version.Container
+
+	if getStrategy(cfg.Deploy) == StrategyProxy {
+		if err := rollbackProxy(cli, cfg, options, previous, getHealthGate(cfg.Deploy)); err != nil {
+			return nil, err
+		}
+		if currentContainer != nil {
+			if err := core.SuspendContainer(cli, currentContainer); err != nil {
+				return nil, err
+			}
+		}
+		if err := core.RenameContainer(cli, previous, name); err != nil {
+			return nil, err
+		}
+	} else {
+		if currentContainer != nil {
+			if err := core.SuspendContainer(cli, currentContainer); err != nil {
+				return nil, err
+			}
+		}
+		err := core.ResumeContainer(cli, previous, name)
+		if err == nil && getStrategy(cfg.Deploy) != StrategyRecreate {
+			err = waitHealthy(cli, previous, getHealthGate(cfg.Deploy))
+		}
+		if err != nil {
+			core.StopContainer(cli, previous)
+			core.RenameContainer(cli, previous, getVersionName(name, previous))
+			if currentContainer != nil {
+				if otherErr := core.ResumeContainer(cli, currentContainer, name); otherErr != nil {
+					err = fmt.Errorf("%w (%v)", err, otherErr)
+				}
+			}
+			return nil, err
+		}
+	}
+	if currentContainer != nil {
+		if err := retireContainer(cli, currentContainer, name, getKeep(cfg.Deploy)); err != nil {
+			return nil, err
+		}
+	}
+	return core.FindContainerByID(cli, previous.ID())
+}
@@ -0,0 +1,189 @@
+package manage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DmitryBogomolov/containerator/core"
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// setTestClock makes each deploy happen a minute after the previous one.
+func setTestClock(t *testing.T) {
+	original := now
+	current := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		current = current.Add(time.Minute)
+		return current
+	}
+	t.Cleanup(func() { now = original })
+}
+
+func TestSelectPrevious(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	versions := []Version{{DeployTime: base.Add(2 * time.Minute)}, {DeployTime: base}}
+
+	assert.Equal(t, &versions[0], selectPrevious(versions, nil))
+	assert.Nil(t, selectPrevious(nil, nil))
+}
+
+func TestVersions(t *testing.T) {
+	cfg := &Config{
+		ImageName: "test-image",
+		Ports:     []core.Mapping{{Source: "5001", Target: "80"}},
+		Deploy:    &DeployConfig{Keep: 2},
+	}
+
+	t.Run("Keep", func(t *testing.T) {
+		setTestClock(t)
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		engine.AddImage("test-image:3")
+		engine.AddImage("test-image:4")
+		first, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+		second, _ := RunContainer(cli, cfg, &Options{Tag: "2"})
+		third, _ := RunContainer(cli, cfg, &Options{Tag: "3"})
+
+		_, err := RunContainer(cli, cfg, &Options{Tag: "4"})
+
+		assert.NoError(t, err)
+		versions, err := Versions(cli, cfg, &Options{})
+		assert.NoError(t, err)
+		assert.Len(t, versions, 2)
+		assert.Equal(t, third.ID(), versions[0].Container.ID())
+		assert.Equal(t, "test-image-"+third.ShortID(), versions[0].Container.Name())
+		assert.Equal(t, "exited", versions[0].Container.State())
+		assert.Equal(t, second.ID(), versions[1].Container.ID())
+		assert.True(t, versions[0].DeployTime.After(versions[1].DeployTime))
+		_, err = core.FindContainerByID(cli, first.ID())
+		assert.ErrorIs(t, err, core.ErrNotFound)
+	})
+
+	t.Run("Nothing kept", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		plain := &Config{ImageName: "test-image"}
+		RunContainer(cli, plain, &Options{Tag: "1"})
+		RunContainer(cli, plain, &Options{Tag: "2"})
+
+		versions, err := Versions(cli, plain, &Options{})
+
+		assert.NoError(t, err)
+		assert.Empty(t, versions)
+		ids, _ := core.ListAllContainerIDs(cli)
+		assert.Len(t, ids, 1)
+	})
+}
+
+func TestRollback(t *testing.T) {
+	cfg := &Config{
+		ImageName: "test-image",
+		Ports:     []core.Mapping{{Source: "5001", Target: "80"}},
+		Deploy:    &DeployConfig{Keep: 2},
+	}
+
+	t.Run("Previous version", func(t *testing.T) {
+		setTestClock(t)
+		engine, cli := newTestEngine()
+		prevImageID := engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		prev, _ := RunContainer(cli, cfg, &Options{Tag: "1"})
+		current, _ := RunContainer(cli, cfg, &Options{Tag: "2"})
+
+		cont, err := Rollback(cli, cfg, &Options{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, prev.ID(), cont.ID())
+		assert.Equal(t, "test-image", cont.Name())
+		assert.Equal(t, prevImageID, cont.ImageID())
+		assert.Equal(t, "running", cont.State())
+		ports, _ := core.ListHostPorts(cli)
+		assert.Equal(t, prev.ID(), ports["5001/tcp"].ID())
+		versions, _ := Versions(cli, cfg, &Options{})
+		assert.Len(t, versions, 1)
+		assert.Equal(t, current.ID(), versions[0].Container.ID())
+
+		_, err = Rollback(cli, cfg, &Options{})
+		var versionErr *NoPreviousVersionError
+		assert.ErrorAs(t, err, &versionErr)
+		assert.Equal(t, "test-image", versionErr.Container())
+	})
+
+	t.Run("Proxy", func(t *testing.T) {
+		setTestClock(t)
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		proxyCfg := &Config{
+			ImageName: "test-image",
+			Ports:     cfg.Ports,
+			Deploy: &DeployConfig{
+				Strategy: StrategyProxy,
+				Health:   &HealthGate{Timeout: "200ms", Interval: "10ms"},
+				Keep:     1,
+			},
+		}
+		router := &_TestRouter{}
+		setHealthWhenCreated(engine, "test-image"+candidateSuffix, "healthy")
+		prev, _ := RunContainer(cli, proxyCfg, &Options{Tag: "1", Router: router})
+		setHealthWhenCreated(engine, "test-image"+candidateSuffix, "healthy")
+		current, _ := RunContainer(cli, proxyCfg, &Options{Tag: "2", Router: router})
+		engine.SetHealth(prev.ID(), "healthy")
+
+		cont, err := Rollback(cli, proxyCfg, &Options{Router: router})
+
+		assert.NoError(t, err)
+		assert.Equal(t, prev.ID(), cont.ID())
+		assert.Equal(t, "test-image", cont.Name())
+		assert.Len(t, router.targets, 3)
+		info, _ := core.InspectContainer(cli, cont)
+		assert.Equal(t, map[int]string{5001: "127.0.0.1:" + findHostPort(info, "80")}, router.targets[2])
+		versions, _ := Versions(cli, proxyCfg, &Options{})
+		assert.Len(t, versions, 1)
+		assert.Equal(t, current.ID(), versions[0].Container.ID())
+	})
+
+	t.Run("Health failure", func(t *testing.T) {
+		setTestClock(t)
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		engine.AddImage("test-image:2")
+		blueGreenCfg := &Config{
+			ImageName: "test-image",
+			Deploy: &DeployConfig{
+				Strategy: StrategyBlueGreen,
+				Health:   &HealthGate{Timeout: "200ms", Interval: "10ms"},
+				Keep:     1,
+			},
+		}
+		prev, _ := RunContainer(cli, blueGreenCfg, &Options{Tag: "1"})
+		current, _ := RunContainer(cli, blueGreenCfg, &Options{Tag: "2"})
+		versions, _ := Versions(cli, blueGreenCfg, &Options{})
+		assert.NoError(t, engine.SetHealth(versions[0].Container.Name(), types.Unhealthy))
+
+		_, err := Rollback(cli, blueGreenCfg, &Options{})
+
+		var healthErr *HealthCheckError
+		assert.ErrorAs(t, err, &healthErr)
+		cont, _ := core.FindContainerByName(cli, "test-image")
+		assert.Equal(t, current.ID(), cont.ID())
+		assert.Equal(t, "running", cont.State())
+		versions, _ = Versions(cli, blueGreenCfg, &Options{})
+		assert.Len(t, versions, 1)
+		assert.Equal(t, prev.ID(), versions[0].Container.ID())
+		assert.Equal(t, "exited", versions[0].Container.State())
+	})
+
+	t.Run("No versions", func(t *testing.T) {
+		engine, cli := newTestEngine()
+		engine.AddImage("test-image:1")
+		RunContainer(cli, cfg, &Options{Tag: "1"})
+
+		_, err := Rollback(cli, cfg, &Options{})
+
+		assert.ErrorIs(t, err, core.ErrNotFound)
+	})
+}
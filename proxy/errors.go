@@ -0,0 +1,19 @@
+package proxy
+
+import (
+	"fmt"
+)
+
+// UnknownModeError is returned when proxy mode is not supported.
+type UnknownModeError struct {
+	mode Mode
+}
+
+func (err UnknownModeError) Error() string {
+	return fmt.Sprintf("unknown proxy mode '%s'", err.mode)
+}
+
+// Mode returns proxy mode.
+func (err UnknownModeError) Mode() Mode {
+	return err.mode
+}
@@ -0,0 +1,254 @@
+/*
+Package proxy contains reverse proxy that forwards public port to active container version.
+
+Proxy listens on public address and forwards HTTP requests or TCP connections to active target.
+Target is switched atomically: new connections go to the new target while connections to previous
+targets are kept until they are finished (drained).
+*/
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Mode defines what proxy forwards.
+type Mode string
+
+// Proxy modes.
+const (
+	ModeTCP  Mode = "tcp"  // Forwards TCP connections
+	ModeHTTP Mode = "http" // Forwards HTTP requests
+)
+
+// Proxy forwards connections from public address to active target.
+type Proxy struct {
+	mode     Mode
+	listener net.Listener
+	server   *http.Server
+	active   atomic.Value // *_Target
+
+	lock     sync.Mutex
+	previous []*_Target
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+/*
+Listen starts proxy on address. Proxy has no target until Switch is called;
+HTTP requests get "502 Bad Gateway" and TCP connections are closed.
+
+	Listen(ModeHTTP, ":8080") -> &proxy, err
+*/
+func Listen(mode Mode, address string) (*Proxy, error) {
+	if mode != ModeTCP && mode != ModeHTTP {
+		return nil, &UnknownModeError{mode}
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	proxy := &Proxy{mode: mode, listener: listener}
+	proxy.wg.Add(1)
+	if mode == ModeHTTP {
+		proxy.server = &http.Server{Handler: http.HandlerFunc(proxy.serveHTTP)}
+		go func() {
+			defer proxy.wg.Done()
+			proxy.server.Serve(listener)
+		}()
+	} else {
+		go func() {
+			defer proxy.wg.Done()
+			proxy.serveTCP()
+		}()
+	}
+	return proxy, nil
+}
+
+// Addr returns address that proxy listens on.
+func (proxy *Proxy) Addr() net.Addr {
+	return proxy.listener.Addr()
+}
+
+// Mode returns proxy mode.
+func (proxy *Proxy) Mode() Mode {
+	return proxy.mode
+}
+
+func (proxy *Proxy) getTarget() *_Target {
+	target, _ := proxy.active.Load().(*_Target)
+	return target
+}
+
+// acquireTarget returns active target with connection counted; nil if there is no target.
+//
+// Target is taken under the same lock as Switch, so it cannot be retired and drained between lookup and acquire.
+func (proxy *Proxy) acquireTarget() *_Target {
+	proxy.lock.Lock()
+	defer proxy.lock.Unlock()
+	target := proxy.getTarget()
+	if target != nil {
+		target.acquire()
+	}
+	return target
+}
+
+// Target returns address of active target; empty string if there is no target.
+func (proxy *Proxy) Target() string {
+	if target := proxy.getTarget(); target != nil {
+		return target.address
+	}
+	return ""
+}
+
+// Switch makes address active target; connections to previous target are kept until Drain.
+//
+//	proxy.Switch("127.0.0.1:32768")
+func (proxy *Proxy) Switch(address string) {
+	proxy.lock.Lock()
+	defer proxy.lock.Unlock()
+	previous := proxy.getTarget()
+	proxy.active.Store(newTarget(proxy.mode, address))
+	if previous != nil {
+		previous.retire()
+		proxy.previous = append(proxy.previous, previous)
+	}
+}
+
+/*
+Drain waits until connections to previous targets are finished.
+
+Connections that are not finished in timeout are closed. Returns true if all connections have finished by themselves.
+
+	proxy.Switch("127.0.0.1:32769")
+	proxy.Drain(10 * time.Second) -> true
+*/
+func (proxy *Proxy) Drain(timeout time.Duration) bool {
+	proxy.lock.Lock()
+	previous := proxy.previous
+	proxy.previous = nil
+	proxy.lock.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	expired := false
+	result := true
+	for _, target := range previous {
+		if !expired {
+			select {
+			case <-target.idle:
+				continue
+			case <-timer.C:
+				expired = true
+			}
+		}
+		select {
+		case <-target.idle:
+		default:
+			target.drop()
+			result = false
+		}
+	}
+	return result
+}
+
+// Close stops proxy and closes all connections.
+func (proxy *Proxy) Close() error {
+	proxy.lock.Lock()
+	if proxy.closed {
+		proxy.lock.Unlock()
+		return nil
+	}
+	proxy.closed = true
+	targets := append([]*_Target{}, proxy.previous...)
+	if target := proxy.getTarget(); target != nil {
+		targets = append(targets, target)
+	}
+	proxy.lock.Unlock()
+
+	var err error
+	if proxy.server != nil {
+		err = proxy.server.Close()
+	} else {
+		err = proxy.listener.Close()
+	}
+	for _, target := range targets {
+		target.drop()
+	}
+	proxy.wg.Wait()
+	return err
+}
+
+func (proxy *Proxy) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	target := proxy.acquireTarget()
+	if target == nil {
+		http.Error(w, "no active target", http.StatusBadGateway)
+		return
+	}
+	defer target.release()
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-target.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	target.handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (proxy *Proxy) serveTCP() {
+	for {
+		conn, err := proxy.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		target := proxy.acquireTarget()
+		if target == nil {
+			conn.Close()
+			continue
+		}
+		proxy.wg.Add(1)
+		go func() {
+			defer proxy.wg.Done()
+			defer target.release()
+			forwardTCP(conn, target)
+		}()
+	}
+}
+
+func forwardTCP(conn net.Conn, target *_Target) {
+	defer conn.Close()
+	target.addConn(conn)
+	defer target.removeConn(conn)
+	backend, err := net.Dial("tcp", target.address)
+	if err != nil {
+		return
+	}
+	defer backend.Close()
+	target.addConn(backend)
+	defer target.removeConn(backend)
+
+	done := make(chan struct{}, 2)
+	pipe := func(dst net.Conn, src net.Conn) {
+		io.Copy(dst, src)
+		if conn, ok := dst.(*net.TCPConn); ok {
+			conn.CloseWrite()
+		}
+		done <- struct{}{}
+	}
+	go pipe(backend, conn)
+	go pipe(conn, backend)
+	<-done
+	<-done
+}
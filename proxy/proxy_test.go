@@ -0,0 +1,179 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newBackend(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, name)
+	}))
+}
+
+// newBlockingBackend returns backend that responds only when channel is closed.
+func newBlockingBackend(name string, started chan<- struct{}, release <-chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		io.WriteString(w, name)
+	}))
+}
+
+func backendAddress(server *httptest.Server) string {
+	return server.Listener.Addr().String()
+}
+
+func get(address string) (string, error) {
+	client := http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	response, err := client.Get("http://" + address + "/")
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", response.StatusCode)
+	}
+	return string(data), err
+}
+
+func TestProxy(t *testing.T) {
+	for _, mode := range []Mode{ModeHTTP, ModeTCP} {
+		mode := mode
+		t.Run(string(mode), func(t *testing.T) {
+			t.Run("Switch", func(t *testing.T) {
+				backend1, backend2 := newBackend("backend-1"), newBackend("backend-2")
+				defer backend1.Close()
+				defer backend2.Close()
+				proxy, err := Listen(mode, "127.0.0.1:0")
+				assert.NoError(t, err)
+				defer proxy.Close()
+				address := proxy.Addr().String()
+
+				_, err = get(address)
+				assert.Error(t, err, "no target")
+
+				proxy.Switch(backendAddress(backend1))
+				assert.Equal(t, backendAddress(backend1), proxy.Target())
+				body, err := get(address)
+				assert.NoError(t, err)
+				assert.Equal(t, "backend-1", body)
+
+				proxy.Switch(backendAddress(backend2))
+				assert.True(t, proxy.Drain(time.Second))
+				body, err = get(address)
+				assert.NoError(t, err)
+				assert.Equal(t, "backend-2", body)
+			})
+
+			t.Run("Drain", func(t *testing.T) {
+				started, release := make(chan struct{}, 1), make(chan struct{})
+				backend1, backend2 := newBlockingBackend("backend-1", started, release), newBackend("backend-2")
+				defer backend1.Close()
+				defer backend2.Close()
+				proxy, _ := Listen(mode, "127.0.0.1:0")
+				defer proxy.Close()
+				address := proxy.Addr().String()
+				proxy.Switch(backendAddress(backend1))
+				results := make(chan string)
+				go func() {
+					body, _ := get(address)
+					results <- body
+				}()
+				<-started
+
+				proxy.Switch(backendAddress(backend2))
+				body, err := get(address)
+				assert.NoError(t, err)
+				assert.Equal(t, "backend-2", body, "new requests go to new target")
+				drained := make(chan bool)
+				go func() {
+					drained <- proxy.Drain(time.Second)
+				}()
+				select {
+				case <-drained:
+					assert.Fail(t, "drained before request is finished")
+				case <-time.After(20 * time.Millisecond):
+				}
+				close(release)
+
+				assert.Equal(t, "backend-1", <-results, "in-flight request is finished by old target")
+				assert.True(t, <-drained)
+			})
+
+			t.Run("Drain timeout", func(t *testing.T) {
+				started, release := make(chan struct{}, 1), make(chan struct{})
+				defer close(release)
+				backend1, backend2 := newBlockingBackend("backend-1", started, release), newBackend("backend-2")
+				defer backend1.Close()
+				defer backend2.Close()
+				proxy, _ := Listen(mode, "127.0.0.1:0")
+				defer proxy.Close()
+				address := proxy.Addr().String()
+				proxy.Switch(backendAddress(backend1))
+				results := make(chan string)
+				go func() {
+					body, _ := get(address)
+					results <- body
+				}()
+				<-started
+
+				proxy.Switch(backendAddress(backend2))
+
+				assert.False(t, proxy.Drain(20*time.Millisecond))
+				assert.Equal(t, "", <-results, "in-flight request is dropped")
+			})
+		})
+	}
+}
+
+func TestProxyTCP(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				io.WriteString(conn, strings.ToUpper(line))
+			}()
+		}
+	}()
+	proxy, _ := Listen(ModeTCP, "127.0.0.1:0")
+	defer proxy.Close()
+	proxy.Switch(listener.Addr().String())
+
+	conn, err := net.Dial("tcp", proxy.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+	io.WriteString(conn, "hello\n")
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "HELLO\n", line)
+}
+
+func TestListen(t *testing.T) {
+	_, err := Listen("udp", "127.0.0.1:0")
+
+	var modeErr *UnknownModeError
+	assert.ErrorAs(t, err, &modeErr)
+	assert.Equal(t, Mode("udp"), modeErr.Mode())
+}
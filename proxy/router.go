@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultDrainTimeout defines how long Router waits for connections to previous targets.
+const DefaultDrainTimeout = 30 * time.Second
+
+// Router keeps proxies for public ports.
+type Router struct {
+	mode         Mode
+	host         string
+	drainTimeout time.Duration
+
+	lock    sync.Mutex
+	proxies map[int]*Proxy
+}
+
+/*
+NewRouter creates router; proxies listen on host (all interfaces if empty).
+
+	NewRouter(ModeHTTP, "", 10 * time.Second) -> &router
+*/
+func NewRouter(mode Mode, host string, drainTimeout time.Duration) *Router {
+	if drainTimeout == 0 {
+		drainTimeout = DefaultDrainTimeout
+	}
+	return &Router{mode: mode, host: host, drainTimeout: drainTimeout, proxies: map[int]*Proxy{}}
+}
+
+/*
+Switch forwards public ports to targets and waits until connections to previous targets are drained.
+
+Proxy is started for public port when port is met first time. If any proxy cannot be started,
+no port is switched. Router is not locked while connections are drained.
+
+	router.Switch(map[int]string{8080: "127.0.0.1:32768"}) -> err
+*/
+func (router *Router) Switch(targets map[int]string) error {
+	switched, err := router.switchTargets(targets)
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for _, proxy := range switched {
+		wg.Add(1)
+		go func(proxy *Proxy) {
+			defer wg.Done()
+			proxy.Drain(router.drainTimeout)
+		}(proxy)
+	}
+	wg.Wait()
+	return nil
+}
+
+// switchTargets starts missing proxies and switches all of them; started proxies are closed if any start fails.
+func (router *Router) switchTargets(targets map[int]string) ([]*Proxy, error) {
+	router.lock.Lock()
+	defer router.lock.Unlock()
+	ports := make([]int, 0, len(targets))
+	for port := range targets {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	started := map[int]*Proxy{}
+	for _, port := range ports {
+		if _, ok := router.proxies[port]; ok {
+			continue
+		}
+		proxy, err := Listen(router.mode, fmt.Sprintf("%s:%d", router.host, port))
+		if err != nil {
+			for _, proxy := range started {
+				proxy.Close()
+			}
+			return nil, err
+		}
+		started[port] = proxy
+	}
+	switched := make([]*Proxy, 0, len(ports))
+	for _, port := range ports {
+		proxy, ok := router.proxies[port]
+		if !ok {
+			proxy = started[port]
+			router.proxies[port] = proxy
+		}
+		proxy.Switch(targets[port])
+		switched = append(switched, proxy)
+	}
+	return switched, nil
+}
+
+// Targets returns active targets by public ports.
+func (router *Router) Targets() map[int]string {
+	router.lock.Lock()
+	defer router.lock.Unlock()
+	result := map[int]string{}
+	for port, proxy := range router.proxies {
+		if target := proxy.Target(); target != "" {
+			result[port] = target
+		}
+	}
+	return result
+}
+
+// Close stops all proxies.
+func (router *Router) Close() error {
+	router.lock.Lock()
+	defer router.lock.Unlock()
+	var result error
+	for port, proxy := range router.proxies {
+		if err := proxy.Close(); err != nil && result == nil {
+			result = err
+		}
+		delete(router.proxies, port)
+	}
+	return result
+}
@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getFreePort() int {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestRouter(t *testing.T) {
+	backend1, backend2 := newBackend("backend-1"), newBackend("backend-2")
+	defer backend1.Close()
+	defer backend2.Close()
+	router := NewRouter(ModeHTTP, "127.0.0.1", time.Second)
+	defer router.Close()
+	port := getFreePort()
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+
+	assert.NoError(t, router.Switch(map[int]string{port: backendAddress(backend1)}))
+	body, err := get(address)
+	assert.NoError(t, err)
+	assert.Equal(t, "backend-1", body)
+
+	assert.NoError(t, router.Switch(map[int]string{port: backendAddress(backend2)}))
+	body, err = get(address)
+	assert.NoError(t, err)
+	assert.Equal(t, "backend-2", body)
+	assert.Equal(t, map[int]string{port: backendAddress(backend2)}, router.Targets())
+
+	assert.NoError(t, router.Close())
+	_, err = get(address)
+	assert.Error(t, err)
+}
+
+func TestRouterSwitchFailure(t *testing.T) {
+	backend := newBackend("backend")
+	defer backend.Close()
+	router := NewRouter(ModeHTTP, "127.0.0.1", time.Second)
+	defer router.Close()
+	busy, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer busy.Close()
+	busyPort := busy.Addr().(*net.TCPAddr).Port
+	port := getFreePort()
+
+	err := router.Switch(map[int]string{port: backendAddress(backend), busyPort: backendAddress(backend)})
+
+	assert.Error(t, err)
+	assert.Empty(t, router.Targets(), "no port is switched")
+	_, err = get(fmt.Sprintf("127.0.0.1:%d", port))
+	assert.Error(t, err, "started proxy is closed")
+}
+
+func TestRouterDrainUnlocked(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	backend1, backend2 := newBlockingBackend("backend-1", started, release), newBackend("backend-2")
+	defer backend1.Close()
+	defer backend2.Close()
+	router := NewRouter(ModeHTTP, "127.0.0.1", time.Second)
+	defer router.Close()
+	port := getFreePort()
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+	assert.NoError(t, router.Switch(map[int]string{port: backendAddress(backend1)}))
+	go get(address)
+	<-started
+
+	switched := make(chan error)
+	go func() {
+		switched <- router.Switch(map[int]string{port: backendAddress(backend2)})
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	assert.Equal(t, map[int]string{port: backendAddress(backend2)}, router.Targets(), "router is not locked while draining")
+	close(release)
+	assert.NoError(t, <-switched)
+}
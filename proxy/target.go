@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+)
+
+// _Target keeps connections (or requests) that are forwarded to a single address.
+type _Target struct {
+	address string
+	handler http.Handler
+
+	lock    sync.Mutex
+	active  int
+	retired bool
+	conns   map[net.Conn]struct{}
+	idle    chan struct{} // Closed when target is retired and has no connections
+	done    chan struct{} // Closed when remaining connections are dropped
+	closed  bool
+}
+
+func newTarget(mode Mode, address string) *_Target {
+	target := &_Target{
+		address: address,
+		conns:   map[net.Conn]struct{}{},
+		idle:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if mode == ModeHTTP {
+		target.handler = httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: address})
+	}
+	return target
+}
+
+func (target *_Target) acquire() {
+	target.lock.Lock()
+	defer target.lock.Unlock()
+	target.active++
+}
+
+func (target *_Target) release() {
+	target.lock.Lock()
+	defer target.lock.Unlock()
+	target.active--
+	target.checkIdle()
+}
+
+func (target *_Target) checkIdle() {
+	if target.retired && target.active == 0 && !target.closed {
+		target.closed = true
+		close(target.idle)
+	}
+}
+
+// retire marks target as previous one; it becomes idle when last connection is finished.
+func (target *_Target) retire() {
+	target.lock.Lock()
+	defer target.lock.Unlock()
+	target.retired = true
+	target.checkIdle()
+}
+
+func (target *_Target) addConn(conn net.Conn) {
+	target.lock.Lock()
+	defer target.lock.Unlock()
+	select {
+	case <-target.done:
+		conn.Close()
+	default:
+		target.conns[conn] = struct{}{}
+	}
+}
+
+func (target *_Target) removeConn(conn net.Conn) {
+	target.lock.Lock()
+	defer target.lock.Unlock()
+	delete(target.conns, conn)
+}
+
+// drop closes remaining connections and cancels remaining requests.
+func (target *_Target) drop() {
+	target.lock.Lock()
+	defer target.lock.Unlock()
+	select {
+	case <-target.done:
+	default:
+		close(target.done)
+	}
+	for conn := range target.conns {
+		conn.Close()
+	}
+}